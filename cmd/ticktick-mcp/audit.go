@@ -0,0 +1,135 @@
+package main
+
+import (
+	"dida/internal/audit"
+	"dida/internal/config"
+	"dida/internal/dateparse"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// runAudit 实现 audit 子命令：按时间、工具和项目查询审计日志，返回进程退出码
+func runAudit(args []string, out io.Writer) int {
+	cfg := config.LoadAuditConfig()
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	file := fs.String("file", cfg.Path, "audit log file (rotated files file.1, file.2, ... are read too)")
+	since := fs.String("since", "", `only entries after this time: a duration such as "24h" or a date such as "2026-11-01", "yesterday"`)
+	until := fs.String("until", "", "only entries before this time, same formats as -since")
+	tool := fs.String("tool", "", "only calls of this tool, glob patterns such as *_task are supported")
+	project := fs.String("project", "", `only entries involving this project ID ("inbox" for the inbox)`)
+	kind := fs.String("type", "", "only entries of this type: tool_call or api_request")
+	onlyErrors := fs.Bool("errors", false, "only failed calls and requests")
+	limit := fs.Int("limit", 50, "show at most this many of the most recent entries, 0 for all")
+	asJSON := fs.Bool("json", false, "print matching entries as JSON Lines")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dida audit [flags]\n\nQuery the audit log of tool calls and mutating API requests.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	loc, err := config.UserConfig{TimeZone: os.Getenv("TICKTICK_TIMEZONE")}.Location()
+	if err != nil {
+		loc = time.Local
+	}
+	now := time.Now().In(loc)
+	filter := audit.Filter{Tool: *tool, ProjectID: *project, Type: *kind}
+	if *onlyErrors {
+		filter.Outcome = audit.OutcomeError
+	}
+	if filter.Type != "" && filter.Type != audit.TypeToolCall && filter.Type != audit.TypeAPIRequest {
+		fmt.Fprintf(os.Stderr, "invalid -type %q: use %s or %s\n", filter.Type, audit.TypeToolCall, audit.TypeAPIRequest)
+		return 2
+	}
+	if filter.Since, err = parseTimeFlag(*since, now); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -since: %v\n", err)
+		return 2
+	}
+	if filter.Until, err = parseTimeFlag(*until, now); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -until: %v\n", err)
+		return 2
+	}
+
+	entries, err := audit.Query(*file, filter, *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading audit log: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		for _, entry := range entries {
+			enc.Encode(entry)
+		}
+		return 0
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No matching audit entries.")
+		return 0
+	}
+	for _, entry := range entries {
+		fmt.Fprint(out, formatAuditEntry(entry, loc))
+	}
+	return 0
+}
+
+// parseTimeFlag 解析时间参数：Go 时长表示距今多久，否则按日期解析；空字符串返回零值
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return now.Add(-d), nil
+	}
+	result, err := dateparse.Parse(value, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return result.Time, nil
+}
+
+// formatAuditEntry 以易读的形式输出一条审计记录
+func formatAuditEntry(entry audit.Entry, loc *time.Location) string {
+	var b strings.Builder
+	target := entry.Tool
+	if entry.Type == audit.TypeAPIRequest {
+		target = entry.Method + " " + entry.Endpoint
+	}
+	fmt.Fprintf(&b, "%s  %-11s  %-30s  %-5s  %6dms", entry.Time.In(loc).Format("2006-01-02 15:04:05"),
+		entry.Type, target, entry.Outcome, entry.DurationMS)
+	if len(entry.ProjectIDs) > 0 {
+		fmt.Fprintf(&b, "  project=%s", strings.Join(entry.ProjectIDs, ","))
+	}
+	if entry.TaskID != "" {
+		fmt.Fprintf(&b, "  task=%s", entry.TaskID)
+	}
+	if entry.Client != "" {
+		fmt.Fprintf(&b, "  client=%q", entry.Client)
+	}
+	if entry.SessionID != "" {
+		fmt.Fprintf(&b, "  session=%s", entry.SessionID)
+	}
+	b.WriteString("\n")
+	if len(entry.Arguments) > 0 {
+		if data, err := json.Marshal(entry.Arguments); err == nil {
+			fmt.Fprintf(&b, "    args: %s\n", data)
+		}
+	}
+	if entry.Error != "" {
+		status := ""
+		if entry.StatusCode != 0 {
+			status = fmt.Sprintf(" (HTTP %d)", entry.StatusCode)
+		}
+		fmt.Fprintf(&b, "    error%s: %s\n", status, strings.ReplaceAll(entry.Error, "\n", " "))
+	}
+	return b.String()
+}
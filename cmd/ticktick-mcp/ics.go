@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"dida/globalinit"
+	"dida/internal/ical"
+	"dida/internal/server"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runExportICS 实现 export-ics 子命令：将任务导出为 .ics 文件，返回进程退出码
+func runExportICS(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("export-ics", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	projects := fs.String("project", "", `comma-separated project IDs to export ("inbox" for the inbox), all projects when empty`)
+	from := fs.String("from", "", `only tasks dated on or after this day, e.g. "today", "2026-11-01"`)
+	to := fs.String("to", "", `only tasks dated on or before this day, e.g. "next friday", "2026-11-30"`)
+	mode := fs.String("mode", ical.ModeTodo, "todo exports tasks as VTODO, event exports dated tasks as VEVENT")
+	output := fs.String("o", "", "write to this file instead of standard output")
+	refresh := fs.Bool("refresh", false, "skip the local mirror and response cache")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dida export-ics [flags]\n\nExport open tasks as an iCalendar (.ics) file.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := globalinit.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "initialization failed: %v\n", err)
+		return 1
+	}
+	if err := server.InitCommand(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	export, err := server.ExportICS(context.Background(), server.ICSExportOptions{
+		ProjectIDs: splitList(*projects),
+		From:       *from,
+		To:         *to,
+		Mode:       *mode,
+		Refresh:    *refresh,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error exporting tasks: %v\n", err)
+		return 1
+	}
+
+	if *output == "" {
+		out.Write(export.Data)
+	} else if err := os.WriteFile(*output, export.Data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %s: %v\n", *output, err)
+		return 1
+	}
+	summary := fmt.Sprintf("Exported %d tasks", export.Exported)
+	if export.Skipped > 0 {
+		summary += fmt.Sprintf(" (%d tasks without dates skipped)", export.Skipped)
+	}
+	fmt.Fprintln(os.Stderr, summary+strings.TrimRight(export.Warnings, "\n"))
+	return 0
+}
+
+// runImportICS 实现 import-ics 子命令：从 .ics 文件导入 VTODO 任务，返回进程退出码
+func runImportICS(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("import-ics", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	project := fs.String("project", "", "project ID to create the tasks in, defaults to the inbox")
+	includeCompleted := fs.Bool("include-completed", false, "also import completed entries and mark them completed")
+	dryRun := fs.Bool("dry-run", false, "only preview the tasks that would be created")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dida import-ics [flags] <file.ics>\n\nCreate tasks from the VTODO entries of an iCalendar file.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+
+	if err := globalinit.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "initialization failed: %v\n", err)
+		return 1
+	}
+	if err := server.InitCommand(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	opts := server.ICSImportOptions{ProjectID: *project, IncludeCompleted: *includeCompleted}
+	var result string
+	if *dryRun {
+		result, err = server.PreviewICSImport(context.Background(), data, opts)
+	} else {
+		result, err = server.ImportICS(context.Background(), data, opts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error importing %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	fmt.Fprint(out, result)
+	return 0
+}
+
+// splitList 拆分逗号分隔的参数，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"dida/globalinit"
 	"dida/internal/server"
+	"io"
 	"log"
 	"os"
 	"os/signal"
@@ -11,6 +12,16 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// subcommands 命令行子命令，返回进程退出码
+var subcommands = map[string]func(args []string, out io.Writer) int{
+	"audit":        runAudit,
+	"export-ics":   runExportICS,
+	"import-ics":   runImportICS,
+	"export-tasks": runExportTasks,
+	"import-tasks": runImportTasks,
+	"search":       runSearch,
+}
+
 // initializeEnvironment 初始化环境变量和配置
 func initializeEnvironment() error {
 	err := godotenv.Load()
@@ -26,6 +37,14 @@ func initializeEnvironment() error {
 }
 
 func main() {
+	// 子命令不启动服务器，.env 不存在时使用环境变量和默认值
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			godotenv.Load()
+			os.Exit(run(os.Args[2:], os.Stdout))
+		}
+	}
+
 	// 初始化环境变量和配置
 	if err := initializeEnvironment(); err != nil {
 		log.Printf("初始化失败: %v", err)
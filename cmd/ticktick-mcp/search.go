@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"dida/globalinit"
+	"dida/internal/query"
+	"dida/internal/server"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// runSearch 实现 search 子命令：用与 search_tasks 相同的查询语言跨项目搜索任务，返回进程退出码
+func runSearch(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	sort := fs.String("sort", "", "comma-separated sort fields (due, start, priority, title), prefix with - for descending; overrides sort: in the query")
+	limit := fs.Int("limit", 0, "maximum number of tasks to print (default 50); overrides limit: in the query")
+	refresh := fs.Bool("refresh", false, "skip the local mirror and response cache")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dida search [flags] <query>\n\nSearch tasks across all projects, e.g.\n  dida search 'report priority:high due:<\"next monday\" -project:Personal sort:due'\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	input := strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(input) == "" {
+		fs.Usage()
+		return 2
+	}
+	// 先校验查询，语法错误时不需要凭证
+	if _, err := query.Parse(input, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid query: %v\n", err)
+		return 2
+	}
+
+	if err := globalinit.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "initialization failed: %v\n", err)
+		return 1
+	}
+	if err := server.InitCommand(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	result, err := server.SearchTasks(context.Background(), server.SearchOptions{
+		Query:   input,
+		Sort:    *sort,
+		Limit:   *limit,
+		Refresh: *refresh,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error searching tasks: %v\n", err)
+		return 1
+	}
+	fmt.Fprint(out, result)
+	return 0
+}
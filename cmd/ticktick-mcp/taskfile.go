@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"dida/globalinit"
+	"dida/internal/server"
+	"dida/internal/taskfile"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runExportTasks 实现 export-tasks 子命令：将项目任务导出为 CSV 或 Markdown 清单，返回进程退出码
+func runExportTasks(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("export-tasks", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	project := fs.String("project", "", `project ID to export ("inbox" for the inbox)`)
+	format := fs.String("format", "", "csv or markdown, inferred from the -o file extension when omitted")
+	columns := fs.String("columns", "", "comma-separated CSV columns in output order, defaults to "+strings.Join(taskfile.DefaultColumns, ","))
+	output := fs.String("o", "", "write to this file instead of standard output")
+	refresh := fs.Bool("refresh", false, "skip the local mirror and response cache")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dida export-tasks -project <id> [flags]\n\nExport the open tasks of a project, including subtasks, as CSV or a Markdown checklist.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format == "" {
+		*format = taskfile.FormatFromPath(*output)
+	}
+	if *project == "" || *format == "" {
+		fs.Usage()
+		return 2
+	}
+
+	if err := globalinit.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "initialization failed: %v\n", err)
+		return 1
+	}
+	if err := server.InitCommand(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	export, err := server.ExportProjectTasks(context.Background(), server.TaskExportOptions{
+		ProjectID: *project,
+		Format:    *format,
+		Columns:   splitList(*columns),
+		Refresh:   *refresh,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error exporting tasks: %v\n", err)
+		return 1
+	}
+
+	if *output == "" {
+		out.Write(export.Data)
+	} else if err := os.WriteFile(*output, export.Data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %s: %v\n", *output, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d tasks with %d subtasks\n", export.Tasks, export.Subtasks)
+	return 0
+}
+
+// runImportTasks 实现 import-tasks 子命令：从 CSV 或 Markdown 清单创建任务，返回进程退出码
+func runImportTasks(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("import-tasks", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	project := fs.String("project", "", "project ID to create the tasks in, defaults to the inbox")
+	format := fs.String("format", "", "csv or markdown, inferred from the file extension when omitted")
+	mapping := fs.String("map", "", `CSV column mapping as field=header pairs, e.g. "title=Name,due_date=Deadline"`)
+	delimiter := fs.String("delimiter", "", `CSV field delimiter, defaults to "," (tab for .tsv files); use "tab" for tabs`)
+	dryRun := fs.Bool("dry-run", false, "only preview the tasks that would be created")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dida import-tasks [flags] <file.csv|file.md>\n\nCreate tasks with subtasks from a CSV file or a Markdown checklist.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = taskfile.FormatFromPath(path)
+	}
+	if *format == "" {
+		fmt.Fprintf(os.Stderr, "cannot infer the format of %s, use -format csv or -format markdown\n", path)
+		return 2
+	}
+	if *delimiter == "" && strings.HasSuffix(strings.ToLower(path), ".tsv") {
+		*delimiter = "tab"
+	}
+	comma, err := server.ParseDelimiter(*delimiter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	columns, err := parseMapping(*mapping)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading %s: %v\n", path, err)
+		return 1
+	}
+
+	if err := globalinit.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "initialization failed: %v\n", err)
+		return 1
+	}
+	if err := server.InitCommand(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	opts := server.TaskImportOptions{
+		ProjectID: *project,
+		Format:    *format,
+		CSV:       taskfile.CSVOptions{Mapping: columns, Delimiter: comma},
+	}
+	var result string
+	if *dryRun {
+		result, err = server.PreviewTaskImport(data, opts)
+	} else {
+		result, err = server.ImportTasks(data, opts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error importing %s: %v\n", path, err)
+		return 1
+	}
+	fmt.Fprint(out, result)
+	return 0
+}
+
+// parseMapping 解析 -map 参数中逗号分隔的 field=header 对
+func parseMapping(value string) (map[string]string, error) {
+	items := splitList(value)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	mapping := make(map[string]string, len(items))
+	for _, item := range items {
+		field, header, ok := strings.Cut(item, "=")
+		field, header = strings.TrimSpace(field), strings.TrimSpace(header)
+		if !ok || field == "" || header == "" {
+			return nil, fmt.Errorf("invalid column mapping %q, expected field=header", item)
+		}
+		mapping[field] = header
+	}
+	return mapping, nil
+}
@@ -0,0 +1,294 @@
+// Package audit 以 JSON Lines 格式追加写入审计日志，记录工具调用和修改数据的 API 请求
+//
+// 日志文件超过大小上限时轮转为 path.1、path.2……，只保留指定数量的旧文件。
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 审计记录的类型
+const (
+	TypeToolCall   = "tool_call"
+	TypeAPIRequest = "api_request"
+)
+
+// 调用结果
+const (
+	OutcomeOK    = "ok"
+	OutcomeError = "error"
+)
+
+// redacted 替换敏感参数值的占位符
+const redacted = "[REDACTED]"
+
+// Entry 一条审计记录
+type Entry struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	// Tool 工具名，仅工具调用
+	Tool string `json:"tool,omitempty"`
+	// Arguments 工具参数，敏感字段已脱敏
+	Arguments map[string]any `json:"arguments,omitempty"`
+	SessionID string         `json:"session_id,omitempty"`
+	// Client MCP 客户端的名称和版本
+	Client string `json:"client,omitempty"`
+	// Method 和 Endpoint 仅 API 请求
+	Method     string   `json:"method,omitempty"`
+	Endpoint   string   `json:"endpoint,omitempty"`
+	ProjectIDs []string `json:"project_ids,omitempty"`
+	TaskID     string   `json:"task_id,omitempty"`
+	DurationMS int64    `json:"duration_ms"`
+	Outcome    string   `json:"outcome"`
+	Error      string   `json:"error,omitempty"`
+	StatusCode int      `json:"status_code,omitempty"`
+}
+
+// Log 追加写入的审计日志，并发安全
+type Log struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Open 打开或创建审计日志；maxSize 为单个文件的字节数上限（<= 0 表示不轮转），maxBackups 为保留的旧文件数
+func Open(path string, maxSize int64, maxBackups int) (*Log, error) {
+	l := &Log{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file, l.size = file, info.Size()
+	return nil
+}
+
+// Write 追加一条记录，写入后文件超过上限时轮转
+func (l *Log) Write(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if l.maxSize > 0 && l.size >= l.maxSize {
+		return l.rotate()
+	}
+	return nil
+}
+
+// rotate 将当前文件依次改名为 path.1、path.2……并重新打开，超出 maxBackups 的旧文件被删除
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if l.maxBackups <= 0 {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return l.open()
+	}
+	os.Remove(backupPath(l.path, l.maxBackups))
+	for i := l.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(l.path, i), backupPath(l.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(l.path, backupPath(l.path, 1)); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Close 关闭日志文件
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// backupPath 返回第 n 个轮转文件的路径
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Redact 返回参数的副本，名称中包含 token、secret、password 等字样的参数值被替换
+func Redact(args map[string]any) map[string]any {
+	if args == nil {
+		return nil
+	}
+	return redactValue(args).(map[string]any)
+}
+
+// sensitiveKeys 参数名中出现这些片段时视为敏感参数
+var sensitiveKeys = []string{"token", "secret", "password", "passwd", "authorization", "credential", "api_key", "apikey", "cookie"}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			if isSensitive(key) {
+				copied[key] = redacted
+				continue
+			}
+			copied[key] = redactValue(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = redactValue(item)
+		}
+		return copied
+	}
+	return value
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter 查询条件，零值字段不参与过滤
+type Filter struct {
+	Since time.Time
+	Until time.Time
+	// Tool 工具名，支持 glob 模式（如 *_task）
+	Tool string
+	// ProjectID 涉及的项目 ID
+	ProjectID string
+	// Type 记录类型，TypeToolCall 或 TypeAPIRequest
+	Type string
+	// Outcome 调用结果，OutcomeOK 或 OutcomeError
+	Outcome string
+}
+
+// Match 判断记录是否满足查询条件
+func (f Filter) Match(e Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if f.Type != "" && e.Type != f.Type {
+		return false
+	}
+	if f.Outcome != "" && e.Outcome != f.Outcome {
+		return false
+	}
+	if f.Tool != "" {
+		if ok, _ := path.Match(f.Tool, e.Tool); !ok {
+			return false
+		}
+	}
+	if f.ProjectID != "" {
+		found := false
+		for _, id := range e.ProjectIDs {
+			if id == f.ProjectID || (strings.HasPrefix(f.ProjectID, "inbox") && strings.HasPrefix(id, "inbox")) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Query 按时间顺序读取审计日志（包括轮转的旧文件）中满足条件的记录，limit > 0 时只返回最近的 limit 条
+// 无法解析的行被跳过
+func Query(logPath string, filter Filter, limit int) ([]Entry, error) {
+	var files []string
+	for i := 1; ; i++ {
+		backup := backupPath(logPath, i)
+		if _, err := os.Stat(backup); err != nil {
+			break
+		}
+		files = append([]string{backup}, files...)
+	}
+	files = append(files, logPath)
+
+	var entries []Entry
+	for _, name := range files {
+		file, err := os.Open(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var entry Entry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue
+			}
+			if filter.Match(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %v", name, err)
+		}
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
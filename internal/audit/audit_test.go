@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogRotation(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxBackups int
+		// files 写入 5 条记录后存在的文件（相对日志路径的后缀）
+		files []string
+		// entries Query 能读到的记录数
+		entries int
+	}{
+		{"keeps two backups", 2, []string{"", ".1", ".2"}, 5},
+		{"drops the oldest backup", 1, []string{"", ".1"}, 3},
+		{"no backups", 0, []string{""}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logPath := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+			// 每条记录约 100 字节，上限 150 字节时每写入两条轮转一次
+			l, err := Open(logPath, 150, tt.maxBackups)
+			if err != nil {
+				t.Fatal(err)
+			}
+			base := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+			for i := 0; i < 5; i++ {
+				if err := l.Write(Entry{Time: base.Add(time.Duration(i) * time.Minute), Type: TypeToolCall, Tool: "create_task", Outcome: OutcomeOK}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, suffix := range tt.files {
+				if _, err := os.Stat(logPath + suffix); err != nil {
+					t.Errorf("missing %s: %v", filepath.Base(logPath+suffix), err)
+				}
+			}
+			if _, err := os.Stat(backupPath(logPath, len(tt.files))); err == nil {
+				t.Errorf("%s kept beyond maxBackups", backupPath(logPath, len(tt.files)))
+			}
+
+			entries, err := Query(logPath, Filter{}, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tt.entries {
+				t.Fatalf("Query returned %d entries, want %d", len(entries), tt.entries)
+			}
+			// 跨文件按时间顺序返回，最新的一条在最后
+			for i := 1; i < len(entries); i++ {
+				if !entries[i].Time.After(entries[i-1].Time) {
+					t.Errorf("entries out of order: %v", entries)
+				}
+			}
+			if last := entries[len(entries)-1].Time; !last.Equal(base.Add(4 * time.Minute)) {
+				t.Errorf("last entry at %v", last)
+			}
+		})
+	}
+}
+
+func TestLogWriteAfterClose(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "audit.jsonl"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if err := l.Write(Entry{Type: TypeToolCall}); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(logPath, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: base, Type: TypeToolCall, Tool: "create_task", ProjectIDs: []string{"p1"}, Outcome: OutcomeOK},
+		{Time: base.Add(time.Hour), Type: TypeAPIRequest, Method: "POST", ProjectIDs: []string{"inbox123"}, Outcome: OutcomeOK},
+		{Time: base.Add(2 * time.Hour), Type: TypeToolCall, Tool: "delete_task", ProjectIDs: []string{"p2"}, Outcome: OutcomeError},
+		{Time: base.Add(3 * time.Hour), Type: TypeToolCall, Tool: "get_projects", Outcome: OutcomeOK},
+	}
+	for _, e := range entries {
+		if err := l.Write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+	// 无法解析的行被跳过
+	f, _ := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString("not json\n")
+	f.Close()
+
+	tests := []struct {
+		name   string
+		filter Filter
+		limit  int
+		want   string
+	}{
+		{"all", Filter{}, 0, "create_task,,delete_task,get_projects"},
+		{"tool glob", Filter{Tool: "*_task"}, 0, "create_task,delete_task"},
+		{"type", Filter{Type: TypeAPIRequest}, 0, ""},
+		{"outcome", Filter{Outcome: OutcomeError}, 0, "delete_task"},
+		{"inbox project", Filter{ProjectID: "inbox"}, 0, ""},
+		{"time range", Filter{Since: base.Add(time.Hour), Until: base.Add(3 * time.Hour)}, 0, ",delete_task"},
+		{"limit keeps the latest", Filter{Type: TypeToolCall}, 2, "delete_task,get_projects"},
+	}
+	for _, tt := range tests {
+		got, err := Query(logPath, tt.filter, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tools := make([]string, len(got))
+		for i, e := range got {
+			tools[i] = e.Tool
+		}
+		if strings.Join(tools, ",") != tt.want || len(got) == 0 {
+			t.Errorf("%s: got %q (%d entries), want %q", tt.name, strings.Join(tools, ","), len(got), tt.want)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	args := map[string]any{
+		"title":        "x",
+		"access_token": "secret-value",
+		"nested":       map[string]any{"Client_Secret": "s", "list": []any{map[string]any{"password": "p", "ok": 1}}},
+	}
+	got := Redact(args)
+	if got["title"] != "x" || got["access_token"] != redacted {
+		t.Errorf("Redact = %v", got)
+	}
+	nested := got["nested"].(map[string]any)
+	item := nested["list"].([]any)[0].(map[string]any)
+	if nested["Client_Secret"] != redacted || item["password"] != redacted || item["ok"] != 1 {
+		t.Errorf("nested values not redacted: %v", nested)
+	}
+	// 原参数不被修改
+	if args["access_token"] != "secret-value" {
+		t.Error("Redact modified its argument")
+	}
+	if Redact(nil) != nil {
+		t.Error("Redact(nil) != nil")
+	}
+}
@@ -0,0 +1,111 @@
+// Package cache 提供带过期时间和容量上限的内存缓存，超过容量时淘汰最久未使用的条目
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache 以字符串为键的并发安全缓存
+type Cache struct {
+	maxEntries int
+	mu         sync.Mutex
+	// order 按最近使用排列的条目，队首最新
+	order *list.List
+	items map[string]*list.Element
+	now   func() time.Time
+}
+
+// entry 缓存条目
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// New 创建缓存，maxEntries <= 0 表示不限制条目数
+func New(maxEntries int) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get 返回未过期的缓存值，过期的条目会被删除
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := elem.Value.(*entry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Set 写入缓存，ttl <= 0 时不缓存并删除已有条目
+func (c *Cache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+	if ttl <= 0 {
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: c.now().Add(ttl)})
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// Delete 删除指定的键
+func (c *Cache) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+// DeletePrefix 删除以 prefix 开头的所有键
+func (c *Cache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+		}
+	}
+}
+
+// Clear 清空缓存
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// Len 返回当前条目数，包括尚未清理的过期条目
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry).key)
+}
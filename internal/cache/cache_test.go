@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// testCache 返回时钟可由 advance 推进的缓存
+func testCache(maxEntries int) (*Cache, func(time.Duration)) {
+	c := New(maxEntries)
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c, advance := testCache(0)
+	c.Set("a", []byte("1"), time.Minute)
+	c.Set("b", []byte("2"), time.Hour)
+
+	tests := []struct {
+		advance time.Duration
+		key     string
+		want    bool
+	}{
+		{0, "a", true},
+		{59 * time.Second, "a", true},
+		{time.Second, "a", false}, // 到达过期时间即失效
+		{0, "b", true},
+		{time.Hour, "b", false},
+	}
+	for _, tt := range tests {
+		advance(tt.advance)
+		if _, ok := c.Get(tt.key); ok != tt.want {
+			t.Errorf("Get(%q) ok = %v, want %v", tt.key, ok, tt.want)
+		}
+	}
+	if c.Len() != 0 {
+		t.Errorf("expired entries not removed, Len = %d", c.Len())
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	c, _ := testCache(2)
+	c.Set("a", []byte("1"), time.Hour)
+	c.Set("b", []byte("2"), time.Hour)
+	// 读取 a 后 b 成为最久未使用的条目
+	c.Get("a")
+	c.Set("c", []byte("3"), time.Hour)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q) ok = %v, want %v", key, ok, want)
+		}
+	}
+	// 覆盖已有的键不淘汰其他条目
+	c.Set("c", []byte("4"), time.Hour)
+	if v, _ := c.Get("c"); string(v) != "4" || c.Len() != 2 {
+		t.Errorf("overwrite: value %q, Len %d", v, c.Len())
+	}
+}
+
+func TestCacheDelete(t *testing.T) {
+	c, _ := testCache(0)
+	for _, key := range []string{"tasks:p1", "tasks:p2", "project:p1", "other"} {
+		c.Set(key, []byte(key), time.Hour)
+	}
+
+	c.Set("other", []byte("x"), 0)
+	c.Delete("project:p1", "missing")
+	c.DeletePrefix("tasks:")
+	if c.Len() != 0 {
+		t.Errorf("Len = %d after deleting every key", c.Len())
+	}
+
+	c.Set("a", []byte("1"), time.Hour)
+	c.Clear()
+	if _, ok := c.Get("a"); ok || c.Len() != 0 {
+		t.Error("Clear kept entries")
+	}
+}
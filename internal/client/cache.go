@@ -0,0 +1,98 @@
+package client
+
+import (
+	"dida/internal/cache"
+	"dida/internal/config"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// responseCache 按接口路径缓存 GET 请求的响应
+type responseCache struct {
+	store       *cache.Cache
+	projectsTTL time.Duration
+	tasksTTL    time.Duration
+	// generation 每次失效时递增，请求期间发生过失效的响应不写入缓存
+	generation atomic.Int64
+}
+
+// newResponseCache 根据配置创建响应缓存，未启用时返回 nil
+func newResponseCache(cfg config.CacheConfig) *responseCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &responseCache{
+		store:       cache.New(cfg.MaxEntries),
+		projectsTTL: cfg.ProjectsTTL,
+		tasksTTL:    cfg.TasksTTL,
+	}
+}
+
+// ttl 返回接口对应资源的缓存时长：项目列表和项目详情使用 projectsTTL，项目数据和任务使用 tasksTTL
+func (rc *responseCache) ttl(endpoint string) time.Duration {
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if parts[0] != "project" {
+		return 0
+	}
+	switch len(parts) {
+	case 1, 2:
+		return rc.projectsTTL
+	case 3, 4:
+		return rc.tasksTTL
+	}
+	return 0
+}
+
+// cachedGet 执行 GET 请求，缓存中有未过期的响应时直接返回
+func (c *TickTickClient) cachedGet(endpoint string) ([]byte, error) {
+	rc := c.cache
+	if !c.bypassCache {
+		if body, ok := rc.store.Get(endpoint); ok {
+			return body, nil
+		}
+	}
+	generation := rc.generation.Load()
+	body, err := c.doRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rc.generation.Load() == generation {
+		rc.store.Set(endpoint, body, rc.ttl(endpoint))
+	}
+	return body, nil
+}
+
+// Fresh 返回跳过缓存读取的客户端，响应仍会写入缓存
+// 用于用户要求刷新的读取，以及先读后写、同步等需要最新数据的场景
+func (c *TickTickClient) Fresh() *TickTickClient {
+	fresh := *c
+	fresh.bypassCache = true
+	return &fresh
+}
+
+// invalidateProjectList 使项目列表的缓存失效
+func (c *TickTickClient) invalidateProjectList() {
+	if c.cache == nil {
+		return
+	}
+	c.cache.generation.Add(1)
+	c.cache.store.Delete("/project")
+}
+
+// invalidateProjects 使项目详情、项目数据及其中任务的缓存失效
+// 收集箱的 ID 有 "inbox" 和 "inbox123" 两种写法，统一按前缀失效
+func (c *TickTickClient) invalidateProjects(projectIDs ...string) {
+	if c.cache == nil {
+		return
+	}
+	c.cache.generation.Add(1)
+	for _, projectID := range projectIDs {
+		if projectID == "" || IsInbox(projectID) {
+			c.cache.store.DeletePrefix("/project/" + InboxProjectID)
+			continue
+		}
+		c.cache.store.Delete("/project/" + projectID)
+		c.cache.store.DeletePrefix("/project/" + projectID + "/")
+	}
+}
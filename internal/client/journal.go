@@ -0,0 +1,54 @@
+package client
+
+// 任务修改的类型
+const (
+	MutationCreate   = "create"
+	MutationUpdate   = "update"
+	MutationComplete = "complete"
+	MutationDelete   = "delete"
+	MutationMove     = "move"
+)
+
+// Mutation 一次成功的任务修改
+type Mutation struct {
+	Kind string
+	// ProjectID 和 TaskID 修改后任务所在的项目和任务 ID
+	ProjectID string
+	TaskID    string
+	// Before 修改前的任务，创建时为 nil；修改前未能读取任务时也为 nil
+	Before *Task
+	// After 修改后的任务，完成和删除时为 nil
+	After *Task
+}
+
+// SetMutationRecorder 设置任务修改的记录函数，用于撤销
+// 设置后更新、完成、删除任务前会先读取任务的当前状态，每次修改多一次请求
+func (c *TickTickClient) SetMutationRecorder(record func(Mutation)) {
+	c.recorder = record
+}
+
+// snapshotTask 在修改前读取任务的当前状态，未设置记录函数或读取失败时返回 nil
+func (c *TickTickClient) snapshotTask(projectID, taskID string) *Task {
+	if c.recorder == nil {
+		return nil
+	}
+	task, err := c.Fresh().GetTask(projectID, taskID)
+	if err != nil {
+		return nil
+	}
+	return task
+}
+
+// record 记录一次成功的修改
+func (c *TickTickClient) record(kind, projectID, taskID string, before, after *Task) {
+	if c.recorder != nil {
+		c.recorder(Mutation{Kind: kind, ProjectID: projectID, TaskID: taskID, Before: before, After: after})
+	}
+}
+
+// withoutRecorder 返回不记录修改的客户端，用于由多个请求组成的操作内部
+func (c *TickTickClient) withoutRecorder() *TickTickClient {
+	quiet := *c
+	quiet.recorder = nil
+	return &quiet
+}
@@ -1,37 +1,132 @@
 package client
 
-import "time"
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 任务状态
+const (
+	TaskStatusNormal    = 0
+	TaskStatusCompleted = 2
+)
+
+// 子任务（检查项）状态
+const (
+	ItemStatusNormal    = 0
+	ItemStatusCompleted = 1
+)
 
 // Task 表示TickTick任务
 type Task struct {
 	ID            string     `json:"id,omitempty"`
-	ProjectID     string     `json:"projectId"`
+	ProjectID     string     `json:"projectId,omitempty"`
 	Title         string     `json:"title"`
 	Content       string     `json:"content,omitempty"`
 	Desc          string     `json:"desc,omitempty"`
 	IsAllDay      bool       `json:"isAllDay,omitempty"`
-	StartDate     string     `json:"startDate,omitempty"`
-	DueDate       string     `json:"dueDate,omitempty"`
+	StartDate     Time       `json:"startDate"`
+	DueDate       Time       `json:"dueDate"`
 	TimeZone      string     `json:"timeZone,omitempty"`
 	Reminders     []string   `json:"reminders,omitempty"`
 	RepeatFlag    string     `json:"repeatFlag,omitempty"`
 	Priority      int        `json:"priority,omitempty"`
 	Status        int        `json:"status,omitempty"`
-	CompletedTime string     `json:"completedTime,omitempty"`
-	SortOrder     int        `json:"sortOrder,omitempty"`
+	CompletedTime Time       `json:"completedTime"`
+	SortOrder     int64      `json:"sortOrder,omitempty"`
+	ColumnID      string     `json:"columnId,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
 	Items         []TaskItem `json:"items,omitempty"`
 }
 
-// TaskItem 表示子任务
+// Location 返回任务所在时区，未设置或无法识别时返回 fallback
+func (t Task) Location(fallback *time.Location) *time.Location {
+	if t.TimeZone != "" {
+		if loc, err := time.LoadLocation(t.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return fallback
+}
+
+// CreatedAt 返回任务 ID 中记录的创建时间：TickTick 的任务 ID 是 24 位十六进制的 ObjectID，
+// 前 8 位为创建时刻的 Unix 秒数；ID 不是这种格式时返回 false
+func (t Task) CreatedAt() (time.Time, bool) {
+	if len(t.ID) != 24 {
+		return time.Time{}, false
+	}
+	if _, err := hex.DecodeString(t.ID); err != nil {
+		return time.Time{}, false
+	}
+	seconds, _ := strconv.ParseInt(t.ID[:8], 16, 64)
+	return time.Unix(seconds, 0), true
+}
+
+// taskFields 与 Task 字段相同但不带 MarshalJSON 方法，用于构造请求体
+type taskFields Task
+
+// taskPayload 任务的请求体，外层的时间字段覆盖 taskFields 中的同名字段：
+// 未设置的时间不发送，只有 ClearTime 标记的时间发送 null
+type taskPayload struct {
+	taskFields
+	StartDate     *Time `json:"startDate,omitempty"`
+	DueDate       *Time `json:"dueDate,omitempty"`
+	CompletedTime *Time `json:"completedTime,omitempty"`
+}
+
+// newTaskPayload 根据任务构建请求体
+func newTaskPayload(t Task) taskPayload {
+	return taskPayload{
+		taskFields:    taskFields(t),
+		StartDate:     t.StartDate.payload(),
+		DueDate:       t.DueDate.payload(),
+		CompletedTime: t.CompletedTime.payload(),
+	}
+}
+
+// MarshalJSON 实现 json.Marshaler：未设置的时间不发送，只有 ClearTime 标记的时间发送 null
+func (t Task) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newTaskPayload(t))
+}
+
+// ClearRemovedDates 将 t 中为空而 current 中有值的时间标记为清除，用于以保存的完整数据覆盖任务
+func (t *Task) ClearRemovedDates(current Task) {
+	for _, field := range []struct{ target, current *Time }{
+		{&t.StartDate, &current.StartDate},
+		{&t.DueDate, &current.DueDate},
+		{&t.CompletedTime, &current.CompletedTime},
+	} {
+		if field.target.IsZero() && !field.current.IsZero() {
+			*field.target = ClearTime()
+		}
+	}
+}
+
+// TaskItem 表示任务中的子任务（检查项）
 type TaskItem struct {
 	ID            string `json:"id,omitempty"`
-	Status        int    `json:"status"`
+	Status        int    `json:"status"` // 0=Normal, 1=Completed
 	Title         string `json:"title"`
-	SortOrder     int    `json:"sortOrder,omitempty"`
-	StartDate     string `json:"startDate,omitempty"`
+	SortOrder     int64  `json:"sortOrder,omitempty"`
+	StartDate     Time   `json:"startDate"`
 	IsAllDay      bool   `json:"isAllDay,omitempty"`
 	TimeZone      string `json:"timeZone,omitempty"`
-	CompletedTime string `json:"completedTime,omitempty"`
+	CompletedTime Time   `json:"completedTime"`
+}
+
+// taskItemFields 与 TaskItem 字段相同但不带 MarshalJSON 方法
+type taskItemFields TaskItem
+
+// MarshalJSON 实现 json.Marshaler：未设置的时间不发送，只有 ClearTime 标记的时间发送 null
+func (i TaskItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		taskItemFields
+		StartDate     *Time `json:"startDate,omitempty"`
+		CompletedTime *Time `json:"completedTime,omitempty"`
+	}{taskItemFields(i), i.StartDate.payload(), i.CompletedTime.payload()})
 }
 
 // Project 表示TickTick项目
@@ -44,27 +139,35 @@ type Project struct {
 	Kind      string `json:"kind,omitempty"`
 }
 
-// ChecklistItem 表示一个待办事项清单中的子任务
-type ChecklistItem struct {
-	ID            string    `json:"id"`            // 子任务标识符
-	Title         string    `json:"title"`         // 子任务标题
-	Status        int32     `json:"status"`        // 子任务完成状态: 0=Normal, 1=Completed
-	CompletedTime time.Time `json:"completedTime"` // 子任务完成时间
-	IsAllDay      bool      `json:"isAllDay"`      // 是否全天任务
-	SortOrder     int64     `json:"sortOrder"`     // 子任务排序顺序
-	StartDate     time.Time `json:"startDate"`     // 子任务开始时间
-	TimeZone      string    `json:"timeZone"`      // 子任务时区
+// InboxProjectID 收集箱的项目 ID
+// 收集箱不会出现在 GetProjects 的结果中，但 Open API 接受 "inbox" 作为项目 ID；
+// 收集箱中任务的 projectId 形如 "inbox123456789"
+const InboxProjectID = "inbox"
+
+// IsInbox 判断项目 ID 是否指向收集箱
+func IsInbox(projectID string) bool {
+	return strings.HasPrefix(projectID, InboxProjectID)
+}
+
+// InboxProject 返回表示收集箱的伪项目
+func InboxProject() Project {
+	return Project{ID: InboxProjectID, Name: "Inbox", Kind: "INBOX"}
 }
 
+// Column 表示看板项目中的列
 type Column struct {
 	ID        string `json:"id"`
-	ProjectID string `json:"projectID"`
-	Name      string `json:"Name"`
+	ProjectID string `json:"projectId"`
+	Name      string `json:"name"`
 	SortOrder int64  `json:"sortOrder"`
 }
 
+// ProjectData 表示项目及其未完成任务和看板列
 type ProjectData struct {
-	Project Project
-	Tasks   []Task
-	Columns []Column
+	Project Project  `json:"project"`
+	Tasks   []Task   `json:"tasks"`
+	Columns []Column `json:"columns"`
 }
+
+// ViewModeKanban 看板视图的 viewMode
+const ViewModeKanban = "kanban"
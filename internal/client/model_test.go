@@ -0,0 +1,26 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskCreatedAt(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+		ok   bool
+	}{
+		{"6540a3c0e4b0a1b2c3d4e5f6", "2023-10-31T06:50:40Z", true},
+		{"6540A3C0E4B0A1B2C3D4E5F6", "2023-10-31T06:50:40Z", true},
+		{"6540a3c0", "", false},
+		{"zz40a3c0e4b0a1b2c3d4e5f6", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Task{ID: tt.id}.CreatedAt()
+		if ok != tt.ok || (ok && got.UTC().Format(time.RFC3339) != tt.want) {
+			t.Errorf("CreatedAt(%q) = %v, %v, want %s, %v", tt.id, got.UTC(), ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package client
+
+import "time"
+
+// Request 一次修改数据的 API 请求（非 GET）
+type Request struct {
+	Method   string
+	Endpoint string
+	// Body 请求体，未发送请求体时为 nil 或空字符串
+	Body     any
+	Duration time.Duration
+	// Err 请求失败时的错误
+	Err error
+}
+
+// SetRequestObserver 设置修改数据的 API 请求完成后的回调，用于审计
+func (c *TickTickClient) SetRequestObserver(observe func(Request)) {
+	c.observer = observe
+}
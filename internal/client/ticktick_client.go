@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"dida/internal/auth"
@@ -18,6 +21,16 @@ type TickTickClient struct {
 	config     *config.Config
 	HTTPClient *http.Client
 	auth       *auth.TickTickAuth
+	// cache GET 响应缓存，未启用时为 nil
+	cache *responseCache
+	// bypassCache 为 true 时读取不使用缓存，见 Fresh
+	bypassCache bool
+	// recorder 任务修改的记录函数，见 SetMutationRecorder
+	recorder func(Mutation)
+	// observer 修改数据的请求完成后的回调，见 SetRequestObserver
+	observer func(Request)
+	// tokenMu 保护 config 中的令牌；Fresh 等返回的副本共享同一把锁，并发请求同时遇到 401 时只刷新一次
+	tokenMu *sync.RWMutex
 }
 
 func NewTickTickClient() (*TickTickClient, error) {
@@ -48,9 +61,20 @@ func NewTickTickClient() (*TickTickClient, error) {
 		config:     cfg,
 		HTTPClient: httpClient,
 		auth:       tickAuth,
+		cache:      newResponseCache(cfg.Cache),
+		tokenMu:    &sync.RWMutex{},
 	}, nil
 }
+
+// RefreshAccessToken 使用刷新令牌获取新的访问令牌
 func (c *TickTickClient) RefreshAccessToken() error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.refreshLocked()
+}
+
+// refreshLocked 刷新令牌，调用方需持有 tokenMu 的写锁
+func (c *TickTickClient) refreshLocked() error {
 	if c.config.TickTick.RefreshToken == "" {
 		return errors.New(errors.ErrTokenRefreshFailed, "no refresh token available")
 	}
@@ -70,13 +94,43 @@ func (c *TickTickClient) RefreshAccessToken() error {
 	return nil
 }
 
+// refreshAfter 在使用 stale 令牌的请求返回 401 后刷新令牌，返回新的访问令牌
+// 其他请求已经刷新过时直接返回当前令牌，不重复刷新
+func (c *TickTickClient) refreshAfter(stale string) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.config.TickTick.AccessToken != stale {
+		return c.config.TickTick.AccessToken, nil
+	}
+	if c.config.TickTick.RefreshToken == "" {
+		return "", errors.New(errors.ErrTokenRefreshFailed,
+			"Access token expired and no refresh token available. Please use the oauth_authorize tool to re-authenticate.")
+	}
+	if err := c.refreshLocked(); err != nil {
+		return "", errors.Wrapf(errors.ErrTokenRefreshFailed, err, "failed to refresh access token")
+	}
+	return c.config.TickTick.AccessToken, nil
+}
+
 // GetAccessToken 获取当前的访问令牌
 func (c *TickTickClient) GetAccessToken() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
 	return c.config.TickTick.AccessToken
 }
+
 func (c *TickTickClient) makeRequest(method, endpoint string, data interface{}) ([]byte, error) {
-	// 直接执行请求
-	return c.doRequest(method, endpoint, data)
+	if method == "GET" && c.cache != nil {
+		return c.cachedGet(endpoint)
+	}
+	if method == "GET" || c.observer == nil {
+		// 直接执行请求
+		return c.doRequest(method, endpoint, data)
+	}
+	start := time.Now()
+	body, err := c.doRequest(method, endpoint, data)
+	c.observer(Request{Method: method, Endpoint: endpoint, Body: data, Duration: time.Since(start), Err: err})
+	return body, err
 }
 
 func (c *TickTickClient) doRequest(method, endpoint string, data interface{}) ([]byte, error) {
@@ -100,7 +154,8 @@ func (c *TickTickClient) doRequest(method, endpoint string, data interface{}) ([
 	}
 
 	// 设置请求头
-	request.Header.Set("Authorization", "Bearer "+c.config.TickTick.AccessToken)
+	token := c.GetAccessToken()
+	request.Header.Set("Authorization", "Bearer "+token)
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Accept-Encoding", "identity")
 	request.Header.Set("User-Agent", "ticktick-mcp-go/1.0")
@@ -119,18 +174,18 @@ func (c *TickTickClient) doRequest(method, endpoint string, data interface{}) ([
 
 	// 检查是否需要刷新令牌（如果是401未授权）
 	if response.StatusCode == http.StatusUnauthorized {
-		// 检查是否有 refresh token
-		if c.config.TickTick.RefreshToken == "" {
-			return nil, errors.New(errors.ErrTokenRefreshFailed,
-				"Access token expired and no refresh token available. Please use the oauth_authorize tool to re-authenticate.")
+		token, err := c.refreshAfter(token)
+		if err != nil {
+			return nil, err
 		}
 
-		if err := c.RefreshAccessToken(); err != nil {
-			return nil, errors.Wrapf(errors.ErrTokenRefreshFailed, err, "failed to refresh access token")
+		// 重试请求，请求体已在第一次发送时读完，需要重新获取
+		if request.GetBody != nil {
+			if request.Body, err = request.GetBody(); err != nil {
+				return nil, errors.Wrapf(errors.ErrAPIRequest, err, "failed to rebuild request body")
+			}
 		}
-
-		// 重试请求
-		request.Header.Set("Authorization", "Bearer "+c.config.TickTick.AccessToken)
+		request.Header.Set("Authorization", "Bearer "+token)
 		response, err = c.HTTPClient.Do(request)
 		if err != nil {
 			return nil, errors.Wrapf(errors.ErrAPIRequest, err, "failed to send request after token refresh")
@@ -145,7 +200,9 @@ func (c *TickTickClient) doRequest(method, endpoint string, data interface{}) ([
 
 	// 检查HTTP状态码
 	if response.StatusCode >= 400 {
-		return nil, errors.Newf(errors.ErrAPIResponse, "API error %s: %s", response.Status, string(body))
+		apiErr := errors.Newf(errors.ErrAPIResponse, "API error %s: %s", response.Status, string(body))
+		apiErr.StatusCode = response.StatusCode
+		return nil, apiErr
 	}
 
 	return body, nil
@@ -188,9 +245,14 @@ func (c *TickTickClient) GetProjectWithData(projectID string) (*ProjectData, err
 	if err := json.Unmarshal(body, &projectData); err != nil {
 		return nil, fmt.Errorf("error unmarshlling project data: %v", err)
 	}
+	// 收集箱的数据中不包含项目信息
+	if projectData.Project.ID == "" && IsInbox(projectID) {
+		projectData.Project = InboxProject()
+	}
 	return &projectData, nil
 }
 func (c *TickTickClient) CreateProject(project Project) (*Project, error) {
+	defer c.invalidateProjectList()
 	body, err := c.makeRequest("POST", "/project", project)
 	if err != nil {
 		return nil, err
@@ -203,6 +265,8 @@ func (c *TickTickClient) CreateProject(project Project) (*Project, error) {
 	return &createdProject, nil
 }
 func (c *TickTickClient) UpdateProject(project Project) (*Project, error) {
+	defer c.invalidateProjectList()
+	defer c.invalidateProjects(project.ID)
 	body, err := c.makeRequest("POST", "/project/"+project.ID, project)
 	if err != nil {
 		return nil, err
@@ -216,6 +280,8 @@ func (c *TickTickClient) UpdateProject(project Project) (*Project, error) {
 
 }
 func (c *TickTickClient) DeleteProject(projectID string) error {
+	defer c.invalidateProjectList()
+	defer c.invalidateProjects(projectID)
 	_, err := c.makeRequest("DELETE", "/project/"+projectID, "")
 	if err != nil {
 		return err
@@ -225,6 +291,7 @@ func (c *TickTickClient) DeleteProject(projectID string) error {
 
 // CreateTask 创建任务
 func (c *TickTickClient) CreateTask(task Task) (*Task, error) {
+	defer c.invalidateProjects(task.ProjectID)
 	body, err := c.makeRequest("POST", "/task", task)
 	if err != nil {
 		return nil, err
@@ -233,38 +300,271 @@ func (c *TickTickClient) CreateTask(task Task) (*Task, error) {
 	if err := json.Unmarshal(body, &createdTask); err != nil {
 		return nil, fmt.Errorf("error unmarshalling created task: %v", err)
 	}
+	c.record(MutationCreate, createdTask.ProjectID, createdTask.ID, nil, &createdTask)
 	return &createdTask, nil
 }
 
-// UpdateTask 更新任务
+// taskMove 移动任务接口的请求项
+type taskMove struct {
+	FromProjectID string `json:"fromProjectId"`
+	ToProjectID   string `json:"toProjectId"`
+	TaskID        string `json:"taskId"`
+}
+
+// MoveTask 将任务移动到另一个项目，返回移动后的任务；toProjectID 可以是 "inbox"
+// 优先使用 Open API 的移动接口；接口不可用时在目标项目中创建副本，
+// 校验副本与原任务一致后再删除原任务，任一步失败都会删除副本回滚
+func (c *TickTickClient) MoveTask(task Task, toProjectID string) (*Task, error) {
+	// 复制移动由多次创建、删除组成，整体作为一次移动记录
+	inner := c.withoutRecorder()
+	moved, err := inner.moveTaskNative(task, toProjectID)
+	if err != nil && isUnsupportedEndpoint(err) {
+		moved, err = inner.moveTaskByCopy(task, toProjectID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.record(MutationMove, moved.ProjectID, moved.ID, &task, moved)
+	return moved, nil
+}
+
+// moveTaskNative 调用 Open API 的移动接口
+func (c *TickTickClient) moveTaskNative(task Task, toProjectID string) (*Task, error) {
+	moves := []taskMove{{FromProjectID: task.ProjectID, ToProjectID: toProjectID, TaskID: task.ID}}
+	_, err := c.makeRequest("POST", "/task/move", moves)
+	c.invalidateProjects(task.ProjectID, toProjectID)
+	if err != nil {
+		return nil, err
+	}
+	moved, err := c.GetTask(toProjectID, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("task moved but could not be fetched from the target project: %v", err)
+	}
+	return moved, nil
+}
+
+// isUnsupportedEndpoint 判断错误是否表示接口不存在或不支持
+func isUnsupportedEndpoint(err error) bool {
+	switch errors.GetStatusCode(err) {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// moveTaskByCopy 通过创建副本再删除原任务的方式移动任务
+func (c *TickTickClient) moveTaskByCopy(task Task, toProjectID string) (*Task, error) {
+	created, err := c.CreateTask(moveCopy(task, toProjectID))
+	if err != nil {
+		return nil, fmt.Errorf("error creating task in target project: %v", err)
+	}
+	if task.Status == TaskStatusCompleted && created.Status != TaskStatusCompleted {
+		if err := c.CompletedTask(created.ProjectID, created.ID); err != nil {
+			return nil, c.rollbackMove(created, fmt.Errorf("error completing the copied task: %v", err))
+		}
+	}
+
+	// 重新读取副本，确认子任务、提醒、重复规则、优先级和日期都已保留
+	verified, err := c.GetTask(created.ProjectID, created.ID)
+	if err != nil {
+		return nil, c.rollbackMove(created, fmt.Errorf("error verifying the copied task: %v", err))
+	}
+	if diffs := moveDifferences(task, *verified); len(diffs) > 0 {
+		return nil, c.rollbackMove(created, fmt.Errorf("copied task does not match the original (%s)", strings.Join(diffs, ", ")))
+	}
+
+	if err := c.DeleteTask(task.ProjectID, task.ID); err != nil {
+		return nil, c.rollbackMove(created, fmt.Errorf("error deleting the original task: %v", err))
+	}
+	return verified, nil
+}
+
+// moveCopy 构造要在目标项目中创建的副本
+// 看板列和排序属于原项目，在目标项目中无效，由服务器重新分配
+func moveCopy(task Task, toProjectID string) Task {
+	copied := task
+	copied.ID = ""
+	copied.ColumnID = ""
+	copied.SortOrder = 0
+	copied.ProjectID = toProjectID
+	if IsInbox(toProjectID) {
+		// 不指定 projectId 时任务创建在收集箱
+		copied.ProjectID = ""
+	}
+	copied.Items = make([]TaskItem, len(task.Items))
+	for i, item := range task.Items {
+		item.ID = ""
+		copied.Items[i] = item
+	}
+	return copied
+}
+
+// rollbackMove 删除移动过程中创建的副本，返回包含回滚结果的错误
+func (c *TickTickClient) rollbackMove(created *Task, cause error) error {
+	if err := c.DeleteTask(created.ProjectID, created.ID); err != nil {
+		return fmt.Errorf("%v; rollback failed, please delete the copy %s in project %s manually: %v", cause, created.ID, created.ProjectID, err)
+	}
+	return fmt.Errorf("%v; the move was rolled back and the original task is unchanged", cause)
+}
+
+// moveDifferences 比较原任务与移动后的任务，返回未被保留的字段
+func moveDifferences(original, moved Task) []string {
+	var diffs []string
+	if moved.Title != original.Title {
+		diffs = append(diffs, "title")
+	}
+	if moved.Content != original.Content {
+		diffs = append(diffs, "content")
+	}
+	// API 时间精确到秒，按 API 格式比较
+	if moved.StartDate.String() != original.StartDate.String() {
+		diffs = append(diffs, "start date")
+	}
+	if moved.DueDate.String() != original.DueDate.String() {
+		diffs = append(diffs, "due date")
+	}
+	if moved.IsAllDay != original.IsAllDay {
+		diffs = append(diffs, "all day")
+	}
+	if moved.Priority != original.Priority {
+		diffs = append(diffs, "priority")
+	}
+	if moved.Status != original.Status {
+		diffs = append(diffs, "status")
+	}
+	if moved.RepeatFlag != original.RepeatFlag {
+		diffs = append(diffs, "repeat")
+	}
+	if !sameStrings(moved.Reminders, original.Reminders) {
+		diffs = append(diffs, "reminders")
+	}
+	if !sameStrings(moved.Tags, original.Tags) {
+		diffs = append(diffs, "tags")
+	}
+	if !sameItems(moved.Items, original.Items) {
+		diffs = append(diffs, "subtasks")
+	}
+	return diffs
+}
+
+// sameStrings 不考虑顺序比较两个字符串列表
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		counts[s]--
+		if counts[s] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sameItems 按 SortOrder 比较子任务的标题和状态
+func sameItems(a, b []TaskItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = SortedItems(a), SortedItems(b)
+	for i := range a {
+		if a[i].Title != b[i].Title || a[i].Status != b[i].Status {
+			return false
+		}
+	}
+	return true
+}
+
+// SortedItems 返回按 SortOrder 排序的子任务副本
+func SortedItems(items []TaskItem) []TaskItem {
+	sorted := make([]TaskItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].SortOrder < sorted[j].SortOrder })
+	return sorted
+}
+
+// taskUpdatePayload 更新任务的请求体
+// 外层字段覆盖 Task 中同名的 omitempty 字段，使清空的内容、子任务以及零值的优先级、
+// 全天标记和状态也会被发送；时间字段与创建时相同，只发送有值或显式清除的时间
+type taskUpdatePayload struct {
+	taskPayload
+	Content    string     `json:"content"`
+	Items      []TaskItem `json:"items"`
+	Reminders  []string   `json:"reminders"`
+	RepeatFlag string     `json:"repeatFlag"`
+	Tags       []string   `json:"tags"`
+	Priority   int        `json:"priority"`
+	IsAllDay   bool       `json:"isAllDay"`
+	Status     int        `json:"status"`
+}
+
+// newTaskUpdatePayload 根据任务构建更新请求体
+func newTaskUpdatePayload(task Task) taskUpdatePayload {
+	payload := taskUpdatePayload{
+		taskPayload: newTaskPayload(task),
+		Content:     task.Content,
+		Items:       task.Items,
+		Reminders:   task.Reminders,
+		RepeatFlag:  task.RepeatFlag,
+		Tags:        task.Tags,
+		Priority:    task.Priority,
+		IsAllDay:    task.IsAllDay,
+		Status:      task.Status,
+	}
+	// 发送空数组以删除全部子任务、提醒和标签
+	if payload.Items == nil {
+		payload.Items = []TaskItem{}
+	}
+	if payload.Reminders == nil {
+		payload.Reminders = []string{}
+	}
+	if payload.Tags == nil {
+		payload.Tags = []string{}
+	}
+	return payload
+}
+
+// UpdateTask 更新任务，task 应为完整的任务数据
 func (c *TickTickClient) UpdateTask(task Task) (*Task, error) {
-	body, err := c.makeRequest("POST", "/task/"+task.ID, task)
+	defer c.invalidateProjects(task.ProjectID)
+	before := c.snapshotTask(task.ProjectID, task.ID)
+	body, err := c.makeRequest("POST", "/task/"+task.ID, newTaskUpdatePayload(task))
 	if err != nil {
 		return nil, err
 	}
 	var updatedTask Task
-
-	if err := json.Unmarshal(body, &task); err != nil {
+	if err := json.Unmarshal(body, &updatedTask); err != nil {
 		return nil, fmt.Errorf("error unmarshalling updated task: %v", err)
 	}
+	c.record(MutationUpdate, task.ProjectID, task.ID, before, &updatedTask)
 	return &updatedTask, nil
 }
 
 // CompletedTask 完成任务
 func (c *TickTickClient) CompletedTask(projectID, taskID string) error {
+	defer c.invalidateProjects(projectID)
+	before := c.snapshotTask(projectID, taskID)
 	_, err := c.makeRequest("POST", "/project/"+projectID+"/task/"+taskID+"/complete", "")
 	if err != nil {
 		return err
 	}
+	c.record(MutationComplete, projectID, taskID, before, nil)
 	return nil
 }
 
 // DeleteTask 删除任务
 func (c *TickTickClient) DeleteTask(projectID, taskID string) error {
+	defer c.invalidateProjects(projectID)
+	before := c.snapshotTask(projectID, taskID)
 	_, err := c.makeRequest("DELETE", "/project/"+projectID+"/task/"+taskID, "")
 	if err != nil {
 		return err
 	}
+	c.record(MutationDelete, projectID, taskID, before, nil)
 	return nil
 }
 
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskUpdatePayload(t *testing.T) {
+	due := NewTime(time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name  string
+		task  Task
+		field string
+		want  any
+	}{
+		{"priority cleared", Task{Priority: 0}, "priority", 0.0},
+		{"priority set", Task{Priority: 5}, "priority", 5.0},
+		{"switched to a timed date", Task{DueDate: due, IsAllDay: false}, "isAllDay", false},
+		{"all day", Task{DueDate: due, IsAllDay: true}, "isAllDay", true},
+		{"reopened", Task{Status: TaskStatusNormal}, "status", 0.0},
+		{"completed", Task{Status: TaskStatusCompleted}, "status", 2.0},
+		{"content cleared", Task{}, "content", ""},
+		{"reminders cleared", Task{}, "reminders", []any{}},
+		{"tags cleared", Task{}, "tags", []any{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(newTaskUpdatePayload(tt.task))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := fields[tt.field]
+			if !ok {
+				t.Fatalf("%s not sent: %s", tt.field, data)
+			}
+			if gotJSON, _ := json.Marshal(got); string(gotJSON) != mustJSON(t, tt.want) {
+				t.Errorf("%s = %s, want %s", tt.field, gotJSON, mustJSON(t, tt.want))
+			}
+		})
+	}
+}
+
+func TestTaskUpdatePayloadDates(t *testing.T) {
+	// 未设置的时间不发送，显式清除的时间发送 null
+	data, err := json.Marshal(newTaskUpdatePayload(Task{DueDate: ClearTime()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	if v, ok := fields["dueDate"]; !ok || v != nil {
+		t.Errorf("dueDate = %v (sent %v), want null", v, ok)
+	}
+	if _, ok := fields["startDate"]; ok {
+		t.Errorf("unset startDate sent: %s", data)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTaskUpdatePayloadReopen(t *testing.T) {
+	// 撤销完成：以完成前的数据覆盖已完成的任务，状态和完成时间都需要显式发送
+	before := Task{ID: "t1", Title: "Report", Status: TaskStatusNormal}
+	current := before
+	current.Status = TaskStatusCompleted
+	current.CompletedTime = NewTime(time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC))
+
+	reopened := before
+	reopened.ClearRemovedDates(current)
+	data, err := json.Marshal(newTaskUpdatePayload(reopened))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	if fields["status"] != 0.0 {
+		t.Errorf("status = %v, want 0: %s", fields["status"], data)
+	}
+	if v, ok := fields["completedTime"]; !ok || v != nil {
+		t.Errorf("completedTime = %v (sent %v), want null", v, ok)
+	}
+}
+
+func TestMoveCopy(t *testing.T) {
+	task := Task{
+		ID: "t1", ProjectID: "p1", Title: "Report", ColumnID: "col1", SortOrder: 42, Priority: 3,
+		Items: []TaskItem{{ID: "i1", Title: "outline"}},
+	}
+	tests := []struct {
+		to        string
+		projectID string
+	}{
+		{"p2", "p2"},
+		{InboxProjectID, ""},
+	}
+	for _, tt := range tests {
+		copied := moveCopy(task, tt.to)
+		if copied.ID != "" || copied.ProjectID != tt.projectID {
+			t.Errorf("moveCopy(%s): id %q, project %q", tt.to, copied.ID, copied.ProjectID)
+		}
+		// 原项目的看板列和排序不带到目标项目
+		if copied.ColumnID != "" || copied.SortOrder != 0 {
+			t.Errorf("moveCopy(%s) kept column %q, sort order %d", tt.to, copied.ColumnID, copied.SortOrder)
+		}
+		if copied.Priority != 3 || len(copied.Items) != 1 || copied.Items[0].ID != "" || copied.Items[0].Title != "outline" {
+			t.Errorf("moveCopy(%s) = %+v", tt.to, copied)
+		}
+	}
+	if task.Items[0].ID != "i1" {
+		t.Error("moveCopy modified the original subtasks")
+	}
+}
+
+func TestMoveDifferences(t *testing.T) {
+	original := Task{
+		Title: "Report", Priority: 3, Status: TaskStatusCompleted,
+		Tags: []string{"a", "b"}, Items: []TaskItem{{Title: "x", SortOrder: 1}, {Title: "y", SortOrder: 2}},
+	}
+	tests := []struct {
+		name   string
+		change func(*Task)
+		want   string
+	}{
+		{"same", func(*Task) {}, ""},
+		{"tag order ignored", func(t *Task) { t.Tags = []string{"b", "a"} }, ""},
+		{"column and sort order ignored", func(t *Task) { t.ColumnID, t.SortOrder = "c2", 7 }, ""},
+		{"status", func(t *Task) { t.Status = TaskStatusNormal }, "status"},
+		{"priority", func(t *Task) { t.Priority = 0 }, "priority"},
+		{"subtasks", func(t *Task) { t.Items = t.Items[:1] }, "subtasks"},
+	}
+	for _, tt := range tests {
+		moved := original
+		moved.Items = append([]TaskItem(nil), original.Items...)
+		tt.change(&moved)
+		if got := strings.Join(moveDifferences(original, moved), ","); got != tt.want {
+			t.Errorf("%s: moveDifferences = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateTimeLayout TickTick API 使用的时间格式
+const DateTimeLayout = "2006-01-02T15:04:05-0700"
+
+// Time 表示 TickTick API 中的时间戳
+// 序列化为 2006-01-02T15:04:05-0700 格式；请求体中零值不发送，只有 ClearTime 标记的时间发送 null
+type Time struct {
+	time.Time
+	// clear 为 true 时表示调用方要清除服务器上的时间
+	clear bool
+}
+
+// NewTime 由 time.Time 创建 TickTick 时间戳
+func NewTime(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// ClearTime 返回表示清除的零值时间，更新任务时发送 null 以删除服务器上的时间
+func ClearTime() Time {
+	return Time{clear: true}
+}
+
+// payload 返回请求体中的取值：有值或显式清除时返回该时间，否则返回 nil 表示不发送
+func (t Time) payload() *Time {
+	if t.IsZero() && !t.clear {
+		return nil
+	}
+	return &t
+}
+
+// ParseDateTime 解析 TickTick API 的时间字符串，兼容带毫秒和 RFC 3339 格式
+func ParseDateTime(value string) (time.Time, error) {
+	t, err := time.Parse(DateTimeLayout, value)
+	if err == nil {
+		return t, nil
+	}
+	if t, rfcErr := time.Parse(time.RFC3339, value); rfcErr == nil {
+		return t, nil
+	}
+	return time.Time{}, err
+}
+
+// String 以 API 格式输出，零值输出空字符串
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(DateTimeLayout)
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，null 和空字符串解析为零值
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid time %s: %v", data, err)
+	}
+	if value == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := ParseDateTime(value)
+	if err != nil {
+		return fmt.Errorf("invalid time %q: %v", value, err)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// InZone 按任务的时区和全天标记转换时间
+// 全天任务的日期以任务时区的零点表示，应在任务时区中取日期；
+// 非全天任务转换到 loc（通常为用户时区）显示
+func (t Time) InZone(task Task, loc *time.Location) time.Time {
+	if task.IsAllDay {
+		return t.In(task.Location(loc))
+	}
+	return t.In(loc)
+}
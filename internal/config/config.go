@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"dida/internal/errors"
@@ -18,6 +21,42 @@ type Config struct {
 
 	// 日志配置
 	Log LogConfig `json:"log"`
+
+	// 工具访问策略配置
+	Policy PolicyConfig `json:"policy"`
+
+	// DataDir 本地镜像、审计日志等文件的目录，这些文件的相对路径相对于此目录
+	DataDir string `json:"data_dir"`
+
+	// 用户偏好配置
+	User UserConfig `json:"user"`
+
+	// 任务模板配置
+	Templates TemplateConfig `json:"templates"`
+
+	// 本地镜像配置
+	Mirror MirrorConfig `json:"mirror"`
+
+	// 同步配置
+	Sync SyncConfig `json:"sync"`
+
+	// 离线队列配置
+	Outbox OutboxConfig `json:"outbox"`
+
+	// 内存缓存配置
+	Cache CacheConfig `json:"cache"`
+
+	// 修改日志配置
+	Journal JournalConfig `json:"journal"`
+
+	// 审计日志配置
+	Audit AuditConfig `json:"audit"`
+
+	// iCalendar 订阅配置
+	Feed FeedConfig `json:"feed"`
+
+	// invalidEnv 无法解析的环境变量，由 Validate 报错
+	invalidEnv []string
 }
 
 // TickTickConfig TickTick API 配置
@@ -46,10 +85,148 @@ type LogConfig struct {
 	FilePath string `json:"file_path"`
 }
 
+// PolicyConfig 工具访问策略配置
+type PolicyConfig struct {
+	// ReadOnly 为 true 时只注册不会修改数据的工具
+	ReadOnly bool `json:"read_only"`
+	// AllowTools 允许注册的工具名，支持 glob 模式（如 get_*），为空表示全部允许
+	AllowTools []string `json:"allow_tools"`
+	// DenyTools 禁止注册的工具名，支持 glob 模式，优先级高于 AllowTools
+	DenyTools []string `json:"deny_tools"`
+	// AllowedProjects 允许操作的项目 ID，为空表示不限制
+	AllowedProjects []string `json:"allowed_projects"`
+}
+
+// UserConfig 用户偏好配置
+type UserConfig struct {
+	// TimeZone 用户所在时区（IANA 名称，如 Asia/Shanghai），为空时使用系统时区
+	TimeZone string `json:"time_zone"`
+}
+
+// Location 返回用户时区，未配置时返回系统时区
+func (u UserConfig) Location() (*time.Location, error) {
+	if u.TimeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(u.TimeZone)
+}
+
+// TemplateConfig 任务模板配置
+type TemplateConfig struct {
+	// Dir 存放 YAML/JSON 模板文件的目录
+	Dir string `json:"dir"`
+}
+
+// MirrorConfig 本地 SQLite 镜像配置
+type MirrorConfig struct {
+	// Enabled 为 false 时不使用本地镜像，所有读取都直接请求 API
+	Enabled bool `json:"enabled"`
+	// Path 镜像数据库文件路径
+	Path string `json:"path"`
+	// MaxAge 镜像数据的最大有效期，超过后读取工具回退到 API
+	MaxAge time.Duration `json:"max_age"`
+}
+
+// SyncConfig 同步引擎配置，依赖本地镜像
+type SyncConfig struct {
+	// Interval 后台完整同步的间隔，为 0 时不定期同步，只在查询变更时同步
+	Interval time.Duration `json:"interval"`
+	// ChangeRetention 变更记录的保留时长
+	ChangeRetention time.Duration `json:"change_retention"`
+}
+
+// OutboxConfig 离线队列配置，依赖本地镜像
+type OutboxConfig struct {
+	// Enabled 为 false 时 API 不可达的修改操作直接报错，不排队
+	Enabled bool `json:"enabled"`
+	// RetryInterval 后台重放待处理操作的间隔，为 0 时只在下一次修改操作时重放
+	RetryInterval time.Duration `json:"retry_interval"`
+}
+
+// CacheConfig API 响应内存缓存配置
+type CacheConfig struct {
+	// Enabled 为 false 时所有读取都直接请求 API
+	Enabled bool `json:"enabled"`
+	// MaxEntries 最多缓存的响应数，超过后淘汰最久未使用的
+	MaxEntries int `json:"max_entries"`
+	// ProjectsTTL 项目列表和项目详情的缓存时长
+	ProjectsTTL time.Duration `json:"projects_ttl"`
+	// TasksTTL 项目任务数据和单个任务的缓存时长
+	TasksTTL time.Duration `json:"tasks_ttl"`
+}
+
+// JournalConfig 任务修改日志配置，依赖本地镜像
+type JournalConfig struct {
+	// Enabled 为 true 时记录任务修改前的状态，以便撤销
+	Enabled bool `json:"enabled"`
+	// Retention 修改记录的保留时长
+	Retention time.Duration `json:"retention"`
+}
+
+// AuditConfig 审计日志配置
+type AuditConfig struct {
+	// Enabled 为 true 时记录所有工具调用和修改数据的 API 请求
+	Enabled bool `json:"enabled"`
+	// Path JSON Lines 审计日志文件路径，与 log.txt 分开
+	Path string `json:"path"`
+	// MaxSizeMB 单个文件的大小上限（MB），超过后轮转，0 表示不轮转
+	MaxSizeMB int `json:"max_size_mb"`
+	// MaxBackups 保留的轮转文件数
+	MaxBackups int `json:"max_backups"`
+}
+
+// FeedConfig iCalendar 订阅配置
+type FeedConfig struct {
+	// Addr HTTP 监听地址，如 127.0.0.1:8090，为空时不启动订阅服务
+	Addr string `json:"addr"`
+	// Token 订阅地址中的密钥
+	Token string `json:"-"`
+	// Mode 默认的导出方式：event 或 todo
+	Mode string `json:"mode"`
+}
+
+// minFeedTokenLength 订阅密钥的最小长度
+const minFeedTokenLength = 16
+
+// LoadAuditConfig 从环境变量读取审计日志配置，不需要 API 凭证，供命令行工具使用
+// 无法解析的取值使用默认值
+func LoadAuditConfig() AuditConfig {
+	return loadAuditConfig(&envReader{}, getEnv("TICKTICK_DATA_DIR", DefaultDataDir()))
+}
+
+// loadAuditConfig 使用 env 读取审计日志配置，相对路径相对于 dataDir
+func loadAuditConfig(env *envReader, dataDir string) AuditConfig {
+	return AuditConfig{
+		Enabled:    env.Bool("TICKTICK_AUDIT", false),
+		Path:       dataPath(dataDir, getEnv("TICKTICK_AUDIT_PATH", "audit.jsonl")),
+		MaxSizeMB:  env.Int("TICKTICK_AUDIT_MAX_SIZE_MB", 10),
+		MaxBackups: env.Int("TICKTICK_AUDIT_MAX_BACKUPS", 5),
+	}
+}
+
+// DefaultDataDir 返回默认的数据目录：用户配置目录下的 dida（如 ~/.config/dida），无法确定时为当前目录
+func DefaultDataDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(dir, "dida")
+}
+
+// dataPath 将相对路径解析到数据目录下，绝对路径保持不变
+func dataPath(dataDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dataDir, path)
+}
+
 // LoadConfig 加载配置
 func LoadConfig() (*Config, error) {
 	// 注意：环境变量已在 main.go 中加载，这里不需要重复加载
 
+	env := &envReader{}
+	dataDir := getEnv("TICKTICK_DATA_DIR", DefaultDataDir())
 	config := &Config{
 		TickTick: TickTickConfig{
 			// 只从环境变量读取认证相关的敏感信息
@@ -73,6 +250,49 @@ func LoadConfig() (*Config, error) {
 			Level:    "info",
 			FilePath: "log.txt",
 		},
+		Policy: PolicyConfig{
+			ReadOnly:        env.Bool("TICKTICK_READ_ONLY", false),
+			AllowTools:      getEnvList("TICKTICK_ALLOW_TOOLS"),
+			DenyTools:       getEnvList("TICKTICK_DENY_TOOLS"),
+			AllowedProjects: getEnvList("TICKTICK_ALLOWED_PROJECTS"),
+		},
+		DataDir: dataDir,
+		User: UserConfig{
+			TimeZone: getEnv("TICKTICK_TIMEZONE", ""),
+		},
+		Templates: TemplateConfig{
+			Dir: getEnv("TICKTICK_TEMPLATE_DIR", "templates"),
+		},
+		Mirror: MirrorConfig{
+			Enabled: env.Bool("TICKTICK_MIRROR", false),
+			Path:    dataPath(dataDir, getEnv("TICKTICK_MIRROR_PATH", "ticktick-mirror.db")),
+			MaxAge:  env.Duration("TICKTICK_MIRROR_MAX_AGE", 5*time.Minute),
+		},
+		Sync: SyncConfig{
+			Interval:        env.Duration("TICKTICK_SYNC_INTERVAL", 0),
+			ChangeRetention: env.Duration("TICKTICK_CHANGE_RETENTION", 30*24*time.Hour),
+		},
+		Outbox: OutboxConfig{
+			Enabled:       env.Bool("TICKTICK_OUTBOX", false),
+			RetryInterval: env.Duration("TICKTICK_OUTBOX_RETRY_INTERVAL", time.Minute),
+		},
+		Cache: CacheConfig{
+			Enabled:     env.Bool("TICKTICK_CACHE", true),
+			MaxEntries:  env.Int("TICKTICK_CACHE_MAX_ENTRIES", 500),
+			ProjectsTTL: env.Duration("TICKTICK_CACHE_PROJECTS_TTL", 5*time.Minute),
+			TasksTTL:    env.Duration("TICKTICK_CACHE_TASKS_TTL", time.Minute),
+		},
+		Journal: JournalConfig{
+			Enabled:   env.Bool("TICKTICK_JOURNAL", false),
+			Retention: env.Duration("TICKTICK_JOURNAL_RETENTION", 7*24*time.Hour),
+		},
+		Audit: loadAuditConfig(env, dataDir),
+		Feed: FeedConfig{
+			Addr:  getEnv("TICKTICK_ICS_FEED_ADDR", ""),
+			Token: getEnv("TICKTICK_ICS_FEED_TOKEN", ""),
+			Mode:  getEnv("TICKTICK_ICS_FEED_MODE", "event"),
+		},
+		invalidEnv: env.invalid,
 	}
 
 	// 验证必要的配置
@@ -85,6 +305,11 @@ func LoadConfig() (*Config, error) {
 
 // Validate 验证配置
 func (c *Config) Validate() error {
+	// 无法解析的取值不回退到默认值，避免拼写错误悄悄关闭 TICKTICK_READ_ONLY 等开关
+	if len(c.invalidEnv) > 0 {
+		return errors.Newf(errors.ErrConfigLoad, "invalid environment variables: %s", strings.Join(c.invalidEnv, "; "))
+	}
+
 	// 验证 OAuth2 认证必需的配置
 	if c.TickTick.ClientID == "" {
 		return errors.New(errors.ErrInvalidCredentials, "TICKTICK_CLIENT_ID is required")
@@ -107,6 +332,39 @@ func (c *Config) Validate() error {
 		return errors.New(errors.ErrConfigLoad, "TICKTICK_AUTH_URL is required")
 	}
 
+	if _, err := c.User.Location(); err != nil {
+		return errors.Wrapf(errors.ErrConfigLoad, err, "invalid TICKTICK_TIMEZONE %q", c.User.TimeZone)
+	}
+
+	if c.Mirror.Enabled && c.Mirror.MaxAge < 0 {
+		return errors.New(errors.ErrConfigLoad, "TICKTICK_MIRROR_MAX_AGE must not be negative")
+	}
+
+	if c.Sync.Interval < 0 {
+		return errors.New(errors.ErrConfigLoad, "TICKTICK_SYNC_INTERVAL must not be negative")
+	}
+
+	if c.Outbox.RetryInterval < 0 {
+		return errors.New(errors.ErrConfigLoad, "TICKTICK_OUTBOX_RETRY_INTERVAL must not be negative")
+	}
+
+	if c.Cache.Enabled && (c.Cache.MaxEntries < 0 || c.Cache.ProjectsTTL < 0 || c.Cache.TasksTTL < 0) {
+		return errors.New(errors.ErrConfigLoad, "TICKTICK_CACHE_* settings must not be negative")
+	}
+
+	if c.Audit.Enabled && (c.Audit.MaxSizeMB < 0 || c.Audit.MaxBackups < 0) {
+		return errors.New(errors.ErrConfigLoad, "TICKTICK_AUDIT_MAX_SIZE_MB and TICKTICK_AUDIT_MAX_BACKUPS must not be negative")
+	}
+
+	if c.Feed.Addr != "" {
+		if len(c.Feed.Token) < minFeedTokenLength {
+			return errors.Newf(errors.ErrConfigLoad, "TICKTICK_ICS_FEED_TOKEN must be at least %d characters when TICKTICK_ICS_FEED_ADDR is set", minFeedTokenLength)
+		}
+		if c.Feed.Mode != "event" && c.Feed.Mode != "todo" {
+			return errors.New(errors.ErrConfigLoad, "TICKTICK_ICS_FEED_MODE must be event or todo")
+		}
+	}
+
 	// 注意：AccessToken 不再强制要求，因为可以通过 OAuth2 流程获取
 	// 如果没有 AccessToken，应用程序会引导用户完成 OAuth2 授权流程
 
@@ -121,22 +379,65 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// getEnvInt 获取整数类型的环境变量
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
+// envReader 读取带类型的环境变量，记录无法解析的取值
+type envReader struct {
+	invalid []string
+}
+
+// reject 记录无法解析的环境变量
+func (r *envReader) reject(key, value, expected string) {
+	r.invalid = append(r.invalid, fmt.Sprintf("%s=%q (expected %s)", key, value, expected))
+}
+
+// Int 获取整数类型的环境变量
+func (r *envReader) Int(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
 	}
-	return defaultValue
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		r.reject(key, value, "an integer")
+		return defaultValue
+	}
+	return intValue
 }
 
-// getEnvDuration 获取时间间隔类型的环境变量
-func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
+// Duration 获取时间间隔类型的环境变量，如 90s、15m、24h
+func (r *envReader) Duration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		r.reject(key, value, `a duration such as "15m" or "24h"`)
+		return defaultValue
+	}
+	return duration
+}
+
+// Bool 获取布尔类型的环境变量，接受 strconv.ParseBool 支持的写法（true/false、1/0 等）
+func (r *envReader) Bool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		r.reject(key, value, "true or false")
+		return defaultValue
+	}
+	return boolValue
+}
+
+// getEnvList 获取逗号分隔的列表类型环境变量，忽略空白项
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
 		}
 	}
-	return defaultValue
+	return list
 }
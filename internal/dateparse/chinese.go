@@ -0,0 +1,57 @@
+package dateparse
+
+import "strconv"
+
+// chineseDigits 中文数字
+var chineseDigits = map[rune]int{
+	'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// parseNumber 解析阿拉伯数字或 99 以内的中文数字，如 "9"、"九"、"十一"、"二十三"
+func parseNumber(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if r != '十' {
+			continue
+		}
+		// 形如 十、十一、二十、二十三
+		if i > 1 || len(runes)-i-1 > 1 {
+			return 0, false
+		}
+		tens, ones := 1, 0
+		if i == 1 {
+			d, ok := chineseDigits[runes[0]]
+			if !ok {
+				return 0, false
+			}
+			tens = d
+		}
+		if i+1 < len(runes) {
+			d, ok := chineseDigits[runes[i+1]]
+			if !ok {
+				return 0, false
+			}
+			ones = d
+		}
+		return tens*10 + ones, true
+	}
+
+	if len(runes) == 1 {
+		d, ok := chineseDigits[runes[0]]
+		return d, ok
+	}
+	return 0, false
+}
+
+// chineseWeekdays 中文星期
+var chineseWeekdays = map[string]int{
+	"一": 1, "二": 2, "三": 3, "四": 4, "五": 5, "六": 6, "日": 0, "天": 0,
+}
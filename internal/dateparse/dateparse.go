@@ -0,0 +1,521 @@
+package dateparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result 日期解析结果
+type Result struct {
+	// Time 解析出的时间，使用基准时间的时区；全天时为当天零点
+	Time time.Time
+	// AllDay 输入中只包含日期、没有具体时刻
+	AllDay bool
+}
+
+// absoluteLayouts 直接接受的标准时间格式
+var absoluteLayouts = []struct {
+	layout string
+	allDay bool
+}{
+	{"2006-01-02T15:04:05-0700", false},
+	{time.RFC3339, false},
+	{"2006-01-02T15:04:05", false},
+	{"2006-01-02T15:04", false},
+	{"2006-01-02", true},
+}
+
+// Parse 解析日期表达式，相对表达式以 now 为基准并使用 now 的时区
+//
+// 支持标准格式（2026-11-01、2026-11-01T15:00:00Z 等）以及中英文自然语言，例如：
+//   - "today"、"tomorrow 3pm"、"next Friday"、"in 3 days"、"in 2 hours"、"Nov 1 9:30"
+//   - "end of month"、"明天下午3点"、"下周一上午九点"、"3天后"、"11月1日"、"二十号"、"月底"、"周五晚上"
+//
+// 只给出日期时结果为全天；只给出时刻且该时刻今天已过时，取明天的这一时刻。
+// 不带 am/pm、冒号或 "at" 的裸数字（如 "monday 9"）无法区分时刻和日期，不予识别。
+// 不带修饰的星期（"friday"、"this friday"、"周五"）表示即将到来的那一天（含今天），
+// "next friday"/"下周五" 表示下一周（以周一为一周开始）的那一天。
+func Parse(input string, now time.Time) (Result, error) {
+	text := strings.TrimSpace(input)
+	if text == "" {
+		return Result{}, fmt.Errorf("empty date")
+	}
+	loc := now.Location()
+
+	for _, f := range absoluteLayouts {
+		if t, err := time.ParseInLocation(f.layout, text, loc); err == nil {
+			return Result{Time: t.In(loc), AllDay: f.allDay}, nil
+		}
+	}
+
+	p := &parser{s: normalize(text), now: now, today: dateOf(now)}
+	for _, rule := range rules {
+		m := rule.re.FindStringSubmatchIndex(p.s)
+		if m == nil {
+			continue
+		}
+		groups := make([]string, len(m)/2)
+		for i := range groups {
+			if m[2*i] >= 0 {
+				groups[i] = p.s[m[2*i]:m[2*i+1]]
+			}
+		}
+		if rule.apply(p, groups) {
+			p.s = p.s[:m[0]] + " " + p.s[m[1]:]
+		}
+	}
+
+	if rest := leftover(p.s); rest != "" {
+		return Result{}, fmt.Errorf("unrecognized date expression %q (could not understand %q)", input, rest)
+	}
+	return p.result(input)
+}
+
+// parser 保存解析过程中识别出的各部分
+type parser struct {
+	s     string
+	now   time.Time
+	today time.Time
+
+	date    time.Time
+	hasDate bool
+
+	hour, minute int
+	hasTime      bool
+
+	// period 时段，如 morning、afternoon、上午、晚上
+	period string
+
+	// exact 由 "in 2 hours" 之类得到的精确时间
+	exact    time.Time
+	hasExact bool
+}
+
+// setDate 记录日期，已有日期时返回 false
+func (p *parser) setDate(d time.Time) bool {
+	if p.hasDate || p.hasExact {
+		return false
+	}
+	p.date, p.hasDate = d, true
+	return true
+}
+
+// setTime 记录时刻，已有时刻时返回 false
+func (p *parser) setTime(hour, minute int) bool {
+	if p.hasTime || p.hasExact || hour > 24 || minute > 59 {
+		return false
+	}
+	p.hour, p.minute, p.hasTime = hour, minute, true
+	return true
+}
+
+// result 组合各部分得到最终结果
+func (p *parser) result(input string) (Result, error) {
+	if p.hasExact {
+		if p.hasDate || p.hasTime || p.period != "" {
+			return Result{}, fmt.Errorf("ambiguous date expression %q", input)
+		}
+		return Result{Time: p.exact}, nil
+	}
+
+	if !p.hasTime && p.period != "" {
+		p.hour, p.hasTime = periodDefaults[p.period], true
+	}
+	if !p.hasDate && !p.hasTime {
+		return Result{}, fmt.Errorf("unrecognized date expression %q", input)
+	}
+	if !p.hasTime {
+		return Result{Time: p.date, AllDay: true}, nil
+	}
+
+	hour := applyPeriod(p.hour, p.period)
+	day := p.date
+	if !p.hasDate {
+		day = p.today
+	}
+	t := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location()).
+		Add(time.Duration(hour)*time.Hour + time.Duration(p.minute)*time.Minute)
+	if !p.hasDate && t.Before(p.now) {
+		// 只有时刻且今天已过，取明天
+		t = t.AddDate(0, 0, 1)
+	}
+	return Result{Time: t}, nil
+}
+
+// 时段分类
+const (
+	periodMorning   = "morning"
+	periodNoon      = "noon"
+	periodAfternoon = "afternoon"
+	periodEvening   = "evening"
+	periodNight     = "night"
+	periodDawn      = "dawn"
+)
+
+// periodWords 中英文时段词
+var periodWords = map[string]string{
+	"morning": periodMorning, "noon": periodNoon, "afternoon": periodAfternoon,
+	"evening": periodEvening, "night": periodNight,
+	"早上": periodMorning, "早晨": periodMorning, "上午": periodMorning,
+	"中午": periodNoon, "下午": periodAfternoon, "傍晚": periodEvening,
+	"晚上": periodNight, "凌晨": periodDawn,
+}
+
+// periodDefaults 只给出时段时的默认时刻
+var periodDefaults = map[string]int{
+	periodMorning: 9, periodNoon: 12, periodAfternoon: 15,
+	periodEvening: 18, periodNight: 20, periodDawn: 0,
+}
+
+// applyPeriod 根据时段将 12 小时制转换为 24 小时制
+// 晚上 12 点是当晚的午夜，返回 24 即第二天 0 点；上午、凌晨 12 点为当天 0 点
+func applyPeriod(hour int, period string) int {
+	switch period {
+	case periodAfternoon, periodEvening, periodNight:
+		if hour < 12 {
+			return hour + 12
+		}
+		if hour == 12 && period != periodAfternoon {
+			return 24
+		}
+	case periodMorning, periodDawn:
+		if hour == 12 {
+			return 0
+		}
+	case periodNoon:
+		if hour >= 1 && hour <= 5 {
+			return hour + 12
+		}
+	}
+	return hour
+}
+
+// rule 一条识别规则，apply 返回 false 表示不接受该匹配
+type rule struct {
+	re    *regexp.Regexp
+	apply func(p *parser, m []string) bool
+}
+
+const (
+	cnNum     = `[0-9零〇一二两三四五六七八九十]{1,3}`
+	enNum     = `\d+|a|an|one|two|three|four|five|six|seven|eight|nine|ten|twelve`
+	enUnit    = `minutes?|mins?|hours?|hrs?|days?|weeks?|months?|years?`
+	cnUnit    = `分钟|小时|钟头|天|日|周|星期|个月|月|年`
+	enMonth   = `january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept|sep|oct|nov|dec`
+	enWeekday = `sunday|monday|tuesday|wednesday|thursday|friday|saturday|sun|mon|tues|tue|wed|thurs|thur|thu|fri|sat`
+)
+
+// rules 按顺序应用的识别规则
+var rules = []rule{
+	// 相对时长：in 2 hours / 3 days later / 3天后 / 半小时后
+	{regexp.MustCompile(`\bin\s+(` + enNum + `)\s+(` + enUnit + `)\b`), func(p *parser, m []string) bool {
+		return p.applyOffset(enNumber(m[1]), m[2])
+	}},
+	{regexp.MustCompile(`\b(` + enNum + `)\s+(` + enUnit + `)\s+(?:later|from now)\b`), func(p *parser, m []string) bool {
+		return p.applyOffset(enNumber(m[1]), m[2])
+	}},
+	{regexp.MustCompile(`半\s*(?:个)?\s*(小时|钟头)(?:后|以后|之后)`), func(p *parser, m []string) bool {
+		return p.applyExact(30 * time.Minute)
+	}},
+	{regexp.MustCompile(`(` + cnNum + `)\s*(?:个)?\s*(` + cnUnit + `)(?:后|以后|之后)`), func(p *parser, m []string) bool {
+		n, ok := parseNumber(m[1])
+		return ok && p.applyOffset(n, m[2])
+	}},
+
+	// 绝对日期：2026-11-01 / 2026/11/01
+	{regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})\b`), func(p *parser, m []string) bool {
+		y, _ := strconv.Atoi(m[1])
+		mo, _ := strconv.Atoi(m[2])
+		d, _ := strconv.Atoi(m[3])
+		return p.setCalendarDate(y, mo, d, true)
+	}},
+	// 中文日期：2026年11月1日 / 11月1号 / 十一月一日
+	{regexp.MustCompile(`(?:(\d{4})\s*年)?\s*(` + cnNum + `)\s*月\s*(` + cnNum + `)\s*[日号]`), func(p *parser, m []string) bool {
+		mo, ok1 := parseNumber(m[2])
+		d, ok2 := parseNumber(m[3])
+		if !ok1 || !ok2 {
+			return false
+		}
+		y, hasYear := p.today.Year(), m[1] != ""
+		if hasYear {
+			y, _ = strconv.Atoi(m[1])
+		}
+		return p.setCalendarDate(y, mo, d, hasYear)
+	}},
+	// 只有日：二十号 / 5日，取本月或之后第一个有这一天的月份
+	{regexp.MustCompile(`(` + cnNum + `)\s*[日号]`), func(p *parser, m []string) bool {
+		d, ok := parseNumber(m[1])
+		return ok && p.setDayOfMonth(d)
+	}},
+	// 月底：end of month / end of the month / 月底 / 月末
+	{regexp.MustCompile(`\bend\s+of\s+(?:the\s+|this\s+)?month\b|(?:这个|本)?月[底末]`), func(p *parser, m []string) bool {
+		return p.setDate(time.Date(p.today.Year(), p.today.Month()+1, 0, 0, 0, 0, 0, p.today.Location()))
+	}},
+	// 英文日期：Nov 1 / November 1st, 2026 / 1 Nov
+	{regexp.MustCompile(`\b(` + enMonth + `)\.?\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+(\d{4}))?\b`), func(p *parser, m []string) bool {
+		d, _ := strconv.Atoi(m[2])
+		return p.setEnglishDate(m[1], d, m[3])
+	}},
+	{regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)?\s+(` + enMonth + `)\b(?:,?\s+(\d{4}))?`), func(p *parser, m []string) bool {
+		d, _ := strconv.Atoi(m[1])
+		return p.setEnglishDate(m[2], d, m[3])
+	}},
+
+	// 相对日期词
+	{regexp.MustCompile(`\b(?:the\s+)?day\s+after\s+tomorrow\b`), func(p *parser, m []string) bool {
+		return p.setDate(p.today.AddDate(0, 0, 2))
+	}},
+	{regexp.MustCompile(`\b(today|tomorrow|yesterday)\b`), func(p *parser, m []string) bool {
+		return p.setDate(p.today.AddDate(0, 0, map[string]int{"today": 0, "tomorrow": 1, "yesterday": -1}[m[1]]))
+	}},
+	{regexp.MustCompile(`(大后天|后天|明天|明日|今天|今日|昨天)`), func(p *parser, m []string) bool {
+		offsets := map[string]int{"大后天": 3, "后天": 2, "明天": 1, "明日": 1, "今天": 0, "今日": 0, "昨天": -1}
+		return p.setDate(p.today.AddDate(0, 0, offsets[m[1]]))
+	}},
+
+	// 星期：下周一 / 这周五 / 周日 / next friday / monday
+	{regexp.MustCompile(`(下下|下|这|本|上)?\s*(?:个)?\s*(?:周|星期|礼拜)([一二三四五六日天])`), func(p *parser, m []string) bool {
+		weeks := map[string]int{"下下": 2, "下": 1, "这": 0, "本": 0, "上": -1}
+		return p.setWeekday(time.Weekday(chineseWeekdays[m[2]]), m[1] != "", weeks[m[1]])
+	}},
+	{regexp.MustCompile(`\b(?:(this|next|last)\s+)?(` + enWeekday + `)\b`), func(p *parser, m []string) bool {
+		// 英文 "this friday" 与不带修饰时相同，指即将到来的那一天
+		weeks := map[string]int{"next": 1, "last": -1}
+		return p.setWeekday(englishWeekday(m[2]), m[1] == "next" || m[1] == "last", weeks[m[1]])
+	}},
+
+	// 周/月/年：next week / 下周 / next month / 下个月 / this weekend / 周末
+	{regexp.MustCompile(`\b(?:this\s+)?weekend\b|(?:这个|这|本)?周末`), func(p *parser, m []string) bool {
+		// 周末当天即指今天，否则为即将到来的周六
+		if p.today.Weekday() == time.Sunday {
+			return p.setDate(p.today)
+		}
+		return p.setWeekday(time.Saturday, false, 0)
+	}},
+	{regexp.MustCompile(`\bnext\s+(week|month|year)\b|(下下周|下周|下个?星期|下个?礼拜|下个?月|明年)`), func(p *parser, m []string) bool {
+		unit := m[1]
+		if unit == "" {
+			unit = map[string]string{"下下周": "week2", "下周": "week", "下星期": "week", "下个星期": "week",
+				"下礼拜": "week", "下个礼拜": "week", "下月": "month", "下个月": "month", "明年": "year"}[m[2]]
+		}
+		switch unit {
+		case "week":
+			return p.setDate(weekStart(p.today).AddDate(0, 0, 7))
+		case "week2":
+			return p.setDate(weekStart(p.today).AddDate(0, 0, 14))
+		case "month":
+			return p.setDate(time.Date(p.today.Year(), p.today.Month()+1, 1, 0, 0, 0, 0, p.today.Location()))
+		case "year":
+			return p.setDate(time.Date(p.today.Year()+1, 1, 1, 0, 0, 0, 0, p.today.Location()))
+		}
+		return false
+	}},
+
+	// 时段
+	{regexp.MustCompile(`\b(morning|noon|afternoon|evening|night)\b|(早上|早晨|上午|中午|下午|傍晚|晚上|凌晨)`), func(p *parser, m []string) bool {
+		if p.period != "" {
+			return false
+		}
+		p.period = periodWords[m[1]+m[2]]
+		return true
+	}},
+	{regexp.MustCompile(`\bmidnight\b`), func(p *parser, m []string) bool {
+		return p.setTime(0, 0)
+	}},
+
+	// 时刻：3pm / 3:30pm / 15:00 / 下午3点半 / 九点十五分 / at 9
+	{regexp.MustCompile(`\b(\d{1,2})(?::(\d{2}))?\s*(am|pm)\b`), func(p *parser, m []string) bool {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour < 1 || hour > 12 {
+			return false
+		}
+		if m[3] == "pm" && hour < 12 {
+			hour += 12
+		} else if m[3] == "am" && hour == 12 {
+			hour = 0
+		}
+		p.period = ""
+		return p.setTime(hour, minute)
+	}},
+	{regexp.MustCompile(`\b(\d{1,2}):(\d{2})\b`), func(p *parser, m []string) bool {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		return p.setTime(hour, minute)
+	}},
+	{regexp.MustCompile(`(` + cnNum + `)\s*[点時时](?:\s*(半|一刻|三刻|(` + cnNum + `)\s*分?))?`), func(p *parser, m []string) bool {
+		hour, ok := parseNumber(m[1])
+		if !ok {
+			return false
+		}
+		minute := 0
+		switch m[2] {
+		case "":
+		case "半":
+			minute = 30
+		case "一刻":
+			minute = 15
+		case "三刻":
+			minute = 45
+		default:
+			if minute, ok = parseNumber(m[3]); !ok {
+				return false
+			}
+		}
+		return p.setTime(hour, minute)
+	}},
+	{regexp.MustCompile(`\bat\s+(\d{1,2})\b`), func(p *parser, m []string) bool {
+		hour, _ := strconv.Atoi(m[1])
+		return p.setTime(hour, 0)
+	}},
+}
+
+// applyOffset 处理 "N 单位后"：时/分得到精确时间，天/周/月/年得到日期
+func (p *parser) applyOffset(n int, unit string) bool {
+	if n <= 0 {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(unit, "min"), unit == "分钟":
+		return p.applyExact(time.Duration(n) * time.Minute)
+	case strings.HasPrefix(unit, "h"), unit == "小时", unit == "钟头":
+		return p.applyExact(time.Duration(n) * time.Hour)
+	case strings.HasPrefix(unit, "day"), unit == "天", unit == "日":
+		return p.setDate(p.today.AddDate(0, 0, n))
+	case strings.HasPrefix(unit, "week"), unit == "周", unit == "星期":
+		return p.setDate(p.today.AddDate(0, 0, 7*n))
+	case strings.HasPrefix(unit, "month"), unit == "个月", unit == "月":
+		return p.setDate(p.today.AddDate(0, n, 0))
+	case strings.HasPrefix(unit, "year"), unit == "年":
+		return p.setDate(p.today.AddDate(n, 0, 0))
+	}
+	return false
+}
+
+// applyExact 记录相对当前时刻的精确时间
+func (p *parser) applyExact(d time.Duration) bool {
+	if p.hasExact || p.hasDate || p.hasTime {
+		return false
+	}
+	p.exact, p.hasExact = p.now.Add(d).Truncate(time.Minute), true
+	return true
+}
+
+// setCalendarDate 记录年月日；未给出年份且日期已过时取明年
+func (p *parser) setCalendarDate(year, month, day int, hasYear bool) bool {
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return false
+	}
+	d := time.Date(year, time.Month(month), day, 0, 0, 0, 0, p.today.Location())
+	if d.Day() != day {
+		// 如 2 月 30 日
+		return false
+	}
+	if !hasYear && d.Before(p.today) {
+		d = d.AddDate(1, 0, 0)
+	}
+	return p.setDate(d)
+}
+
+// setDayOfMonth 记录只给出日的日期：本月这一天未过时取本月（含今天），否则取之后第一个有这一天的月份
+func (p *parser) setDayOfMonth(day int) bool {
+	if day < 1 || day > 31 {
+		return false
+	}
+	for i := 0; i <= 12; i++ {
+		d := time.Date(p.today.Year(), p.today.Month()+time.Month(i), day, 0, 0, 0, 0, p.today.Location())
+		if d.Day() == day && !d.Before(p.today) {
+			return p.setDate(d)
+		}
+	}
+	return false
+}
+
+// setEnglishDate 记录英文月份写法的日期
+func (p *parser) setEnglishDate(month string, day int, year string) bool {
+	mo, ok := englishMonths[month]
+	if !ok {
+		return false
+	}
+	if year == "" {
+		return p.setCalendarDate(p.today.Year(), mo, day, false)
+	}
+	y, _ := strconv.Atoi(year)
+	return p.setCalendarDate(y, mo, day, true)
+}
+
+// setWeekday 记录星期；explicit 为 false 时取即将到来的那一天（含今天），
+// 否则取相对本周偏移 weeks 周的那一天
+func (p *parser) setWeekday(wd time.Weekday, explicit bool, weeks int) bool {
+	if !explicit {
+		diff := (int(wd) - int(p.today.Weekday()) + 7) % 7
+		return p.setDate(p.today.AddDate(0, 0, diff))
+	}
+	return p.setDate(weekStart(p.today).AddDate(0, 0, 7*weeks+(int(wd)+6)%7))
+}
+
+// englishMonths 英文月份
+var englishMonths = map[string]int{
+	"jan": 1, "january": 1, "feb": 2, "february": 2, "mar": 3, "march": 3,
+	"apr": 4, "april": 4, "may": 5, "jun": 6, "june": 6, "jul": 7, "july": 7,
+	"aug": 8, "august": 8, "sep": 9, "sept": 9, "september": 9, "oct": 10, "october": 10,
+	"nov": 11, "november": 11, "dec": 12, "december": 12,
+}
+
+// englishWeekday 将英文星期转换为 time.Weekday
+func englishWeekday(s string) time.Weekday {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.HasPrefix(strings.ToLower(wd.String()), s[:3]) {
+			return wd
+		}
+	}
+	return time.Sunday
+}
+
+// enNumber 解析英文数字
+func enNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	return map[string]int{"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+		"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "twelve": 12}[s]
+}
+
+// normalize 统一大小写、全角符号和简写
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	s = strings.NewReplacer(
+		"，", ",", "：", ":", "　", " ",
+		"今晚", "今天晚上", "明晚", "明天晚上", "今早", "今天早上", "明早", "明天早上",
+		"tonight", "today night",
+	).Replace(s)
+	return s
+}
+
+// fillerWords 识别后允许剩余的连接词
+var fillerWords = map[string]bool{"at": true, "on": true, "the": true, "of": true, "by": true, "的": true}
+
+// leftover 返回未被识别的剩余文本
+func leftover(s string) string {
+	s = strings.NewReplacer(",", " ", "的", " ").Replace(s)
+	var rest []string
+	for _, field := range strings.Fields(s) {
+		if !fillerWords[field] {
+			rest = append(rest, field)
+		}
+	}
+	return strings.Join(rest, " ")
+}
+
+// dateOf 返回当天零点
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// weekStart 返回所在周的周一零点
+func weekStart(d time.Time) time.Time {
+	return dateOf(d).AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+}
@@ -0,0 +1,165 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+// 基准时间：2026-10-14 周三 10:00（UTC+8）
+var (
+	testLoc = time.FixedZone("CST", 8*3600)
+	testNow = time.Date(2026, 10, 14, 10, 0, 0, 0, testLoc)
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   string // 时区 UTC+8，全天时只有日期
+		allDay bool
+	}{
+		// 标准格式
+		{"2026-11-01", "2026-11-01", true},
+		{"2026-11-01T15:00:00Z", "2026-11-01 23:00", false},
+		{"2026-11-01T15:00", "2026-11-01 15:00", false},
+
+		// 英文
+		{"today", "2026-10-14", true},
+		{"tomorrow 3pm", "2026-10-15 15:00", false},
+		{"Tomorrow at 9:30", "2026-10-15 09:30", false},
+		{"the day after tomorrow", "2026-10-16", true},
+		{"friday", "2026-10-16", true},
+		{"this friday", "2026-10-16", true},
+		{"wednesday", "2026-10-14", true},
+		{"next friday", "2026-10-23", true},
+		{"next monday 9am", "2026-10-19 09:00", false},
+		{"monday at 9", "2026-10-19 09:00", false},
+		{"in 3 days", "2026-10-17", true},
+		{"in 2 hours", "2026-10-14 12:00", false},
+		{"30 minutes from now", "2026-10-14 10:30", false},
+		{"next week", "2026-10-19", true},
+		{"next month", "2026-11-01", true},
+		{"next year", "2027-01-01", true},
+		{"weekend", "2026-10-17", true},
+		{"Nov 1 9:30", "2026-11-01 09:30", false},
+		{"1st March", "2027-03-01", true},
+		{"November 1st, 2026", "2026-11-01", true},
+		{"tonight", "2026-10-14 20:00", false},
+		{"friday evening", "2026-10-16 18:00", false},
+		{"9am", "2026-10-15 09:00", false},
+		{"15:00", "2026-10-14 15:00", false},
+		{"12am", "2026-10-15 00:00", false},
+		{"end of month", "2026-10-31", true},
+		{"end of the month 5pm", "2026-10-31 17:00", false},
+
+		// 中文
+		{"今天", "2026-10-14", true},
+		{"明天下午3点", "2026-10-15 15:00", false},
+		{"明早", "2026-10-15 09:00", false},
+		{"后天晚上八点半", "2026-10-16 20:30", false},
+		{"下周一上午九点", "2026-10-19 09:00", false},
+		{"周五", "2026-10-16", true},
+		{"这周一", "2026-10-12", true},
+		{"下下周三", "2026-10-28", true},
+		{"3天后", "2026-10-17", true},
+		{"两小时后", "2026-10-14 12:00", false},
+		{"半小时后", "2026-10-14 10:30", false},
+		{"11月1日", "2026-11-01", true},
+		{"十一月一号下午两点", "2026-11-01 14:00", false},
+		{"2027年1月5日", "2027-01-05", true},
+		{"1月5日", "2027-01-05", true},
+		{"二十号", "2026-10-20", true},
+		{"14号", "2026-10-14", true},
+		{"10号", "2026-11-10", true},
+		{"31号", "2026-10-31", true},
+		{"月底", "2026-10-31", true},
+		{"周日", "2026-10-18", true},
+		{"下个月", "2026-11-01", true},
+		{"中午十二点一刻", "2026-10-14 12:15", false},
+		{"凌晨", "2026-10-15 00:00", false},
+		// 晚上 12 点是当晚的午夜，上午 12 点是 0 点
+		{"晚上12点", "2026-10-15 00:00", false},
+		{"明天晚上12点", "2026-10-16 00:00", false},
+		{"晚上十二点半", "2026-10-15 00:30", false},
+		{"明天上午12点", "2026-10-15 00:00", false},
+		{"凌晨12点", "2026-10-15 00:00", false},
+		{"下午12点", "2026-10-14 12:00", false},
+		{"tomorrow night 12:00", "2026-10-16 00:00", false},
+		{"tomorrow morning 12:30", "2026-10-15 00:30", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := Parse(tt.input, testNow)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			layout := "2006-01-02 15:04"
+			if tt.allDay {
+				layout = "2006-01-02"
+			}
+			if got := result.Time.In(testLoc).Format(layout); got != tt.want || result.AllDay != tt.allDay {
+				t.Errorf("Parse(%q) = %s (all day %v), want %s (all day %v)", tt.input, got, result.AllDay, tt.want, tt.allDay)
+			}
+		})
+	}
+}
+
+func TestParseDayOfMonthSkipsShortMonths(t *testing.T) {
+	// 11 月没有 31 号，取下一个有 31 号的月份
+	now := time.Date(2026, 11, 5, 10, 0, 0, 0, testLoc)
+	result, err := Parse("31号", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Time.Format("2006-01-02"); got != "2026-12-31" {
+		t.Errorf("got %s, want 2026-12-31", got)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		input  string
+		reason string
+	}{
+		{"", "empty"},
+		{"someday", "unknown word"},
+		{"monday 9", "a bare number could be an hour or a day; use 9am, 9:00 or at 9"},
+		{"tomorrow yesterday", "two dates"},
+		{"in 2 hours tomorrow", "an exact offset cannot be combined with a date"},
+		{"2月30日", "no such day"},
+		{"32号", "no such day"},
+		{"25:00", "no such hour"},
+		{"13pm", "no such hour"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if result, err := Parse(tt.input, testNow); err == nil {
+				t.Errorf("Parse(%q) = %v, want an error (%s)", tt.input, result.Time, tt.reason)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+		ok    bool
+	}{
+		{"9", 9, true},
+		{"九", 9, true},
+		{"两", 2, true},
+		{"十", 10, true},
+		{"十一", 11, true},
+		{"二十", 20, true},
+		{"二十三", 23, true},
+		{"", 0, false},
+		{"一二三", 0, false},
+		{"十十", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseNumber(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseNumber(%q) = %d, %v, want %d, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
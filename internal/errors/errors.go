@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 )
 
@@ -38,9 +39,10 @@ const (
 
 // AppError 应用程序错误结构
 type AppError struct {
-	Code    ErrorCode `json:"code"`
-	Message string    `json:"message"`
-	Cause   error     `json:"cause,omitempty"`
+	Code       ErrorCode `json:"code"`
+	Message    string    `json:"message"`
+	StatusCode int       `json:"status_code,omitempty"` // API 返回的 HTTP 状态码
+	Cause      error     `json:"cause,omitempty"`
 }
 
 // Error 实现error接口
@@ -105,3 +107,24 @@ func GetCode(err error) ErrorCode {
 	}
 	return ""
 }
+
+// GetStatusCode 获取 API 错误的 HTTP 状态码，非 API 错误返回 0
+func GetStatusCode(err error) int {
+	if appErr, ok := err.(*AppError); ok {
+		return appErr.StatusCode
+	}
+	return 0
+}
+
+// IsUnavailable 判断错误是否表示 API 暂时不可达：请求未能发出，或服务返回 502/503/504
+func IsUnavailable(err error) bool {
+	var appErr *AppError
+	if !stderrors.As(err, &appErr) {
+		return false
+	}
+	switch appErr.StatusCode {
+	case 502, 503, 504:
+		return true
+	}
+	return appErr.Code == ErrAPIRequest && appErr.StatusCode == 0
+}
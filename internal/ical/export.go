@@ -0,0 +1,263 @@
+package ical
+
+import (
+	"dida/internal/client"
+	"dida/internal/recurrence"
+	"dida/internal/reminder"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 导出方式
+const (
+	// ModeTodo 所有任务导出为 VTODO
+	ModeTodo = "todo"
+	// ModeEvent 有日期的任务导出为 VEVENT，没有日期的任务被跳过
+	ModeEvent = "event"
+)
+
+// ProdID 导出的日历的 PRODID
+const ProdID = "-//dida//TickTick MCP Server//EN"
+
+// UIDSuffix 导出任务的 UID 后缀，UID 为 任务ID@ticktick.com
+const UIDSuffix = "@ticktick.com"
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	// Name 日历名称（X-WR-CALNAME）
+	Name string
+	// Mode ModeTodo 或 ModeEvent，默认 ModeTodo
+	Mode string
+	// Location 任务没有时区时用于确定全天任务日期的时区
+	Location *time.Location
+	// Stamp DTSTAMP 的时间，零值表示当前时间
+	Stamp time.Time
+}
+
+// NewCalendar 创建空的 VCALENDAR 组件
+func NewCalendar(name string) Component {
+	cal := Component{Name: "VCALENDAR"}
+	cal.Add("VERSION", "2.0")
+	cal.Add("PRODID", ProdID)
+	cal.Add("CALSCALE", "GREGORIAN")
+	cal.Add("METHOD", "PUBLISH")
+	cal.AddText("X-WR-CALNAME", name)
+	return cal
+}
+
+// Export 将任务转换为日历，返回日历和因没有日期而跳过的任务数（仅 ModeEvent）
+func Export(tasks []client.Task, opts ExportOptions) (Component, int) {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.Stamp.IsZero() {
+		opts.Stamp = time.Now()
+	}
+	cal := NewCalendar(opts.Name)
+	skipped := 0
+	for _, task := range tasks {
+		if opts.Mode == ModeEvent {
+			event, ok := taskEvent(task, opts)
+			if !ok {
+				skipped++
+				continue
+			}
+			cal.Children = append(cal.Children, event)
+			continue
+		}
+		cal.Children = append(cal.Children, taskTodo(task, opts))
+	}
+	return cal, skipped
+}
+
+// taskTodo 将任务转换为 VTODO
+func taskTodo(task client.Task, opts ExportOptions) Component {
+	todo := Component{Name: "VTODO"}
+	addCommon(&todo, task, opts)
+
+	// 开始时间与到期时间相同时只输出 DUE，RFC 5545 要求 DUE 晚于 DTSTART
+	hasStart := !task.StartDate.IsZero() && (task.DueDate.IsZero() || task.DueDate.After(task.StartDate.Time))
+	if hasStart {
+		addDate(&todo, "DTSTART", task, task.StartDate, opts)
+	}
+	if !task.DueDate.IsZero() {
+		addDate(&todo, "DUE", task, task.DueDate, opts)
+	}
+	if priority := Priority(task.Priority); priority > 0 {
+		todo.Add("PRIORITY", fmt.Sprint(priority))
+	}
+	if task.Status == client.TaskStatusCompleted {
+		todo.Add("STATUS", "COMPLETED")
+		todo.Add("PERCENT-COMPLETE", "100")
+		if !task.CompletedTime.IsZero() {
+			todo.Add("COMPLETED", FormatDateTime(task.CompletedTime.Time))
+		}
+	} else {
+		todo.Add("STATUS", "NEEDS-ACTION")
+	}
+	addRecurrence(&todo, task, opts, hasStart || !task.DueDate.IsZero())
+	// 提醒的基准时刻为开始时间（见 reminder.Anchor）；没有输出 DTSTART 时开始时间为空或与到期时间相同，提醒相对于 DUE
+	related := ""
+	if !hasStart {
+		related = "RELATED=END"
+	}
+	addAlarms(&todo, task, related)
+	return todo
+}
+
+// taskEvent 将有日期的任务转换为 VEVENT，没有日期时返回 false
+func taskEvent(task client.Task, opts ExportOptions) (Component, bool) {
+	start, end := task.StartDate, task.DueDate
+	if start.IsZero() {
+		start = end
+	}
+	if start.IsZero() {
+		return Component{}, false
+	}
+	event := Component{Name: "VEVENT"}
+	addCommon(&event, task, opts)
+	addDate(&event, "DTSTART", task, start, opts)
+	if task.IsAllDay {
+		// 全天任务的结束日期不包含在内，取到期日的下一天
+		last := start
+		if end.After(start.Time) {
+			last = end
+		}
+		event.Add("DTEND", FormatDate(allDayDate(task, last, opts).AddDate(0, 0, 1)), "VALUE=DATE")
+	} else if end.After(start.Time) {
+		addDate(&event, "DTEND", task, end, opts)
+	}
+	event.Add("TRANSP", "TRANSPARENT")
+	addRecurrence(&event, task, opts, true)
+	// DTSTART 即提醒的基准时刻
+	addAlarms(&event, task, "")
+	return event, true
+}
+
+// addCommon 添加 VTODO 和 VEVENT 共有的属性
+func addCommon(c *Component, task client.Task, opts ExportOptions) {
+	c.Add("UID", EscapeText(task.ID+UIDSuffix))
+	c.Add("DTSTAMP", FormatDateTime(opts.Stamp))
+	c.AddText("SUMMARY", task.Title)
+	c.AddText("DESCRIPTION", Description(task))
+	if len(task.Tags) > 0 {
+		tags := make([]string, len(task.Tags))
+		for i, tag := range task.Tags {
+			tags[i] = EscapeText(tag)
+		}
+		c.Add("CATEGORIES", strings.Join(tags, ","))
+	}
+}
+
+// addDate 添加日期属性：全天任务输出 DATE；其余任务有时区时输出带 TZID 的当地时间，
+// 使重复规则按任务时区展开、跨夏令时不偏移，没有时区时输出 UTC 时间
+// TZID 直接引用 IANA 时区名，不输出 VTIMEZONE
+func addDate(c *Component, name string, task client.Task, t client.Time, opts ExportOptions) {
+	if task.IsAllDay {
+		c.Add(name, FormatDate(allDayDate(task, t, opts)), "VALUE=DATE")
+		return
+	}
+	if loc := task.Location(nil); loc != nil {
+		c.Add(name, t.In(loc).Format(localDateTimeLayout), "TZID="+loc.String())
+		return
+	}
+	c.Add(name, FormatDateTime(t.Time))
+}
+
+// allDayDate 在任务时区中取全天任务的日期
+func allDayDate(task client.Task, t client.Time, opts ExportOptions) time.Time {
+	local := t.InZone(task, opts.Location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// addRecurrence 将 repeatFlag 转换为 RRULE；无法识别的规则（如按指定日期重复）被忽略
+// RRULE 需要起始时间，没有日期的任务不输出；全天任务的 DTSTART 为 DATE，UNTIL 也按 RFC 5545 输出为 DATE
+func addRecurrence(c *Component, task client.Task, opts ExportOptions, dated bool) {
+	if task.RepeatFlag == "" || !dated {
+		return
+	}
+	rule, err := recurrence.Parse(task.RepeatFlag)
+	if err != nil {
+		return
+	}
+	var parts []string
+	for _, part := range strings.Split(strings.TrimPrefix(rule.String(), "RRULE:"), ";") {
+		// TT_ 开头的是 TickTick 私有参数
+		if strings.HasPrefix(part, "TT_") {
+			continue
+		}
+		if strings.HasPrefix(part, "UNTIL=") && task.IsAllDay {
+			part = "UNTIL=" + FormatDate(untilDate(rule.Until, task, opts))
+		}
+		parts = append(parts, part)
+	}
+	c.Add("RRULE", strings.Join(parts, ";"))
+}
+
+// untilDate 取全天任务 UNTIL 的日期：只写了日期的 UNTIL 解析为 UTC 当天的最后一秒，直接取该日期，
+// 带时间的按任务时区取日期
+func untilDate(until time.Time, task client.Task, opts ExportOptions) time.Time {
+	utc := until.UTC()
+	if utc.Hour() == 23 && utc.Minute() == 59 && utc.Second() == 59 {
+		return time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	local := until.In(task.Location(opts.Location))
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// addAlarms 将提醒转换为 VALARM，related 为 TRIGGER 的 RELATED 参数
+func addAlarms(c *Component, task client.Task, related string) {
+	for _, trigger := range task.Reminders {
+		offset, err := reminder.ParseTrigger(trigger)
+		if err != nil {
+			continue
+		}
+		alarm := Component{Name: "VALARM"}
+		alarm.Add("ACTION", "DISPLAY")
+		alarm.AddText("DESCRIPTION", task.Title)
+		value := strings.TrimPrefix(reminder.FormatTrigger(offset), "TRIGGER:")
+		if related != "" {
+			alarm.Add("TRIGGER", value, related)
+		} else {
+			alarm.Add("TRIGGER", value)
+		}
+		c.Children = append(c.Children, alarm)
+	}
+}
+
+// Description 任务的描述：正文后附子任务清单
+func Description(task client.Task) string {
+	text := task.Content
+	if text == "" {
+		text = task.Desc
+	}
+	if len(task.Items) == 0 {
+		return text
+	}
+	lines := make([]string, 0, len(task.Items))
+	for _, item := range task.Items {
+		mark := " "
+		if item.Status == client.ItemStatusCompleted {
+			mark = "x"
+		}
+		lines = append(lines, fmt.Sprintf("- [%s] %s", mark, item.Title))
+	}
+	if text != "" {
+		text += "\n\n"
+	}
+	return text + strings.Join(lines, "\n")
+}
+
+// Priority 将 TickTick 优先级（0 无、1 低、3 中、5 高）转换为 iCalendar 优先级（1 最高、9 最低、0 未定义）
+func Priority(priority int) int {
+	switch priority {
+	case 5:
+		return 1
+	case 3:
+		return 5
+	case 1:
+		return 9
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package ical
+
+import (
+	"dida/internal/client"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportLoc = time.FixedZone("CST", 8*3600)
+
+func exportTasks(t *testing.T, tasks []client.Task, mode string) (Component, int, []ImportedTask) {
+	t.Helper()
+	cal, skipped := Export(tasks, ExportOptions{Name: "Work", Mode: mode, Location: exportLoc,
+		Stamp: time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)})
+	var b strings.Builder
+	if err := cal.Encode(&b); err != nil {
+		t.Fatal(err)
+	}
+	calendars, err := Parse(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("Parse(exported): %v", err)
+	}
+	return calendars[0], skipped, Tasks(calendars, ImportOptions{Location: exportLoc})
+}
+
+func TestExportEvents(t *testing.T) {
+	day := client.NewTime(time.Date(2026, 10, 31, 16, 0, 0, 0, time.UTC))
+	tasks := []client.Task{
+		{ID: "e1", Title: "Trip", IsAllDay: true, StartDate: day, DueDate: client.NewTime(day.AddDate(0, 0, 2))},
+		{ID: "e2", Title: "No date"},
+	}
+	cal, skipped, _ := exportTasks(t, tasks, ModeEvent)
+	if skipped != 1 || len(cal.Children) != 1 || cal.Children[0].Name != "VEVENT" {
+		t.Fatalf("skipped %d, children %+v", skipped, cal.Children)
+	}
+	event := cal.Children[0]
+	start, _ := event.Get("DTSTART")
+	end, _ := event.Get("DTEND")
+	// 全天事件的 DTEND 不包含在内
+	if start.Value != "20261101" || end.Value != "20261104" {
+		t.Errorf("DTSTART %s, DTEND %s", start.Value, end.Value)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	for _, ticktick := range []int{0, 1, 3, 5} {
+		if got := FromPriority(Priority(ticktick)); got != ticktick {
+			t.Errorf("FromPriority(Priority(%d)) = %d", ticktick, got)
+		}
+	}
+}
+
+func TestExportDatesAndRecurrence(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	timed := client.NewTime(time.Date(2026, 10, 26, 9, 0, 0, 0, newYork))
+	allDay := client.NewTime(time.Date(2026, 10, 25, 16, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name  string
+		task  client.Task
+		start string
+		rrule string
+	}{
+		{
+			"timed task with a time zone",
+			client.Task{TimeZone: "America/New_York", StartDate: timed, RepeatFlag: "RRULE:FREQ=WEEKLY;UNTIL=20261231T140000Z"},
+			"DTSTART;TZID=America/New_York:20261026T090000",
+			"FREQ=WEEKLY;INTERVAL=1;UNTIL=20261231T140000Z",
+		},
+		{
+			"timed task without a time zone",
+			client.Task{StartDate: timed, RepeatFlag: "RRULE:FREQ=DAILY"},
+			"DTSTART:20261026T130000Z",
+			"FREQ=DAILY;INTERVAL=1",
+		},
+		{
+			"all-day task with a date UNTIL",
+			client.Task{IsAllDay: true, TimeZone: "Asia/Shanghai", StartDate: allDay, RepeatFlag: "RRULE:FREQ=WEEKLY;UNTIL=20261231"},
+			"DTSTART;VALUE=DATE:20261026",
+			"FREQ=WEEKLY;INTERVAL=1;UNTIL=20261231",
+		},
+		{
+			"all-day task with a date-time UNTIL",
+			client.Task{IsAllDay: true, TimeZone: "Asia/Shanghai", StartDate: allDay, RepeatFlag: "RRULE:FREQ=WEEKLY;UNTIL=20261231T160000Z"},
+			"DTSTART;VALUE=DATE:20261026",
+			"FREQ=WEEKLY;INTERVAL=1;UNTIL=20270101",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.task.ID, tt.task.Title = "r1", "Repeat"
+			cal, _, imported := exportTasks(t, []client.Task{tt.task}, ModeTodo)
+			var b strings.Builder
+			cal.Children[0].Encode(&b)
+			text := b.String()
+			if !strings.Contains(text, tt.start+"\r\n") {
+				t.Errorf("missing %s in\n%s", tt.start, text)
+			}
+			if rrule := cal.Children[0].Text("RRULE"); rrule != tt.rrule {
+				t.Errorf("RRULE = %s, want %s", rrule, tt.rrule)
+			}
+			// 导入后的开始时间不变
+			if !tt.task.IsAllDay && !imported[0].Task.StartDate.Equal(tt.task.StartDate.Time) {
+				t.Errorf("imported start %v, want %v", imported[0].Task.StartDate, tt.task.StartDate)
+			}
+		})
+	}
+}
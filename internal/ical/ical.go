@@ -0,0 +1,104 @@
+// Package ical 生成 RFC 5545 iCalendar 数据，将 TickTick 任务转换为 VTODO 或 VEVENT
+package ical
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 日期和时间的格式
+const (
+	dateLayout          = "20060102"
+	dateTimeLayout      = "20060102T150405Z"
+	localDateTimeLayout = "20060102T150405"
+)
+
+// maxLineOctets 内容行折行前的最大字节数
+const maxLineOctets = 75
+
+// Property 一条内容行，如 DUE;VALUE=DATE:20261101
+type Property struct {
+	Name string
+	// Params 参数，形如 VALUE=DATE
+	Params []string
+	// Value 已按值类型转义的值
+	Value string
+}
+
+// Component 日历组件，如 VCALENDAR、VTODO、VALARM
+type Component struct {
+	Name       string
+	Properties []Property
+	Children   []Component
+}
+
+// Add 追加一条属性
+func (c *Component) Add(name, value string, params ...string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+}
+
+// AddText 追加一条文本属性，值会被转义；空值不添加
+func (c *Component) AddText(name, value string, params ...string) {
+	if value == "" {
+		return
+	}
+	c.Add(name, EscapeText(value), params...)
+}
+
+// Encode 以 CRLF 换行和 75 字节折行输出组件
+func (c Component) Encode(w io.Writer) error {
+	var b strings.Builder
+	c.encode(&b)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (c Component) encode(b *strings.Builder) {
+	writeLine(b, "BEGIN:"+c.Name)
+	for _, p := range c.Properties {
+		line := p.Name
+		for _, param := range p.Params {
+			line += ";" + param
+		}
+		writeLine(b, line+":"+p.Value)
+	}
+	for _, child := range c.Children {
+		child.encode(b)
+	}
+	writeLine(b, "END:"+c.Name)
+}
+
+// writeLine 输出一行，超过 75 字节时折行，续行以空格开头，不在多字节字符中间断开
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// 续行开头的空格也计入长度
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line + "\r\n")
+}
+
+// EscapeText 按 TEXT 值类型转义反斜杠、分号、逗号和换行
+func EscapeText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+// FormatDate 格式化 DATE 值
+func FormatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+// FormatDateTime 格式化 UTC 的 DATE-TIME 值
+func FormatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
@@ -0,0 +1,68 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain", "plain"},
+		{"a,b;c", `a\,b\;c`},
+		{`back\slash`, `back\\slash`},
+		{"line1\nline2", `line1\nline2`},
+		{"crlf\r\nline", `crlf\nline`},
+	}
+	for _, tt := range tests {
+		got := EscapeText(tt.value)
+		if got != tt.want {
+			t.Errorf("EscapeText(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+		if back := UnescapeText(got); back != strings.ReplaceAll(tt.value, "\r\n", "\n") {
+			t.Errorf("UnescapeText(%q) = %q", got, back)
+		}
+	}
+	if got := UnescapeText(`a\Nb\,c\`); got != "a\nb,c\\" {
+		t.Errorf("UnescapeText = %q", got)
+	}
+}
+
+func TestEncodeFolding(t *testing.T) {
+	tests := []string{
+		strings.Repeat("a", 74),
+		strings.Repeat("a", 200),
+		strings.Repeat("任务", 60),
+		"混合 mixed " + strings.Repeat("é日", 40),
+	}
+	for _, summary := range tests {
+		c := Component{Name: "VTODO"}
+		c.AddText("SUMMARY", summary)
+		var b strings.Builder
+		if err := c.Encode(&b); err != nil {
+			t.Fatal(err)
+		}
+		text := b.String()
+		if !strings.HasSuffix(text, "\r\n") {
+			t.Errorf("output does not end with CRLF: %q", text)
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(text, "\r\n"), "\r\n") {
+			if len(line) > maxLineOctets {
+				t.Errorf("line of %d octets: %q", len(line), line)
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("line splits a multi-byte character: %q", line)
+			}
+		}
+		parsed, err := Parse(strings.NewReader(text))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if got := parsed[0].Text("SUMMARY"); got != summary {
+			t.Errorf("unfolded SUMMARY = %q, want %q", got, summary)
+		}
+	}
+}
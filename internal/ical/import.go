@@ -0,0 +1,335 @@
+package ical
+
+import (
+	"crypto/sha1"
+	"dida/internal/client"
+	"dida/internal/recurrence"
+	"dida/internal/reminder"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uidMarker 导入任务的正文中记录原 UID 的行前缀，用于重复导入时去重
+const uidMarker = "ics-uid: "
+
+// checklistPattern 描述末尾的子任务清单行，如 "- [ ] 标题"、"- [x] 标题"
+var checklistPattern = regexp.MustCompile(`^\s*[-*]\s+\[([ xX])\]\s+(.+)$`)
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	// Location 浮动时间、无法识别的 TZID 和全天日期使用的时区
+	Location *time.Location
+	// TimeZone 写入任务的时区名称，为空时不设置
+	TimeZone string
+}
+
+// ImportedTask 由一个 VTODO 转换出的任务
+type ImportedTask struct {
+	// UID VTODO 的 UID，没有 UID 时由标题和日期生成
+	UID  string
+	Task client.Task
+	// Completed STATUS 为 COMPLETED
+	Completed bool
+	// Cancelled STATUS 为 CANCELLED
+	Cancelled bool
+	// Warnings 无法转换而被忽略的内容
+	Warnings []string
+}
+
+// Tasks 将日历中的 VTODO 转换为任务
+// RELATED-TO 指向同一文件中另一个 VTODO 的条目作为其子任务（检查项）；描述末尾的 "- [ ] 标题" 清单也转换为子任务
+func Tasks(calendars []Component, opts ImportOptions) []ImportedTask {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	var todos []Component
+	for _, cal := range calendars {
+		todos = append(todos, collect(cal, "VTODO")...)
+	}
+
+	// parents 同一文件中各条目的父条目 UID，父条目不在文件中的条目作为顶层任务
+	parents := make(map[string]string, len(todos))
+	for _, todo := range todos {
+		if uid := todo.Text("UID"); uid != "" {
+			if _, ok := parents[uid]; !ok {
+				parents[uid] = parentUID(todo)
+			}
+		}
+	}
+	var imported []ImportedTask
+	index := make(map[string]int)
+	children := make(map[string][]Component)
+	for _, todo := range todos {
+		parent := parentUID(todo)
+		cycle := relationCycle(todo.Text("UID"), parents)
+		if _, ok := parents[parent]; ok && cycle == nil {
+			children[parent] = append(children[parent], todo)
+			continue
+		}
+		item := convertTodo(todo, opts)
+		// 指向自身或形成环的 RELATED-TO 无法确定父任务，作为顶层任务导入
+		switch {
+		case len(cycle) == 1:
+			item.Warnings = append(item.Warnings, "RELATED-TO points to the task itself, imported as a top-level task")
+		case len(cycle) > 1:
+			item.Warnings = append(item.Warnings, fmt.Sprintf("RELATED-TO forms a cycle (%s), imported as a top-level task",
+				strings.Join(append(cycle, cycle[0]), " -> ")))
+		}
+		index[item.UID] = len(imported)
+		imported = append(imported, item)
+	}
+
+	// 子任务按文件中的顺序追加到父任务；多层嵌套时挂到最近的顶层任务
+	// 重复的 UID 也可能让关系成环，attached 保证每个 UID 的子任务只追加一次
+	attached := make(map[string]bool)
+	var attach func(parentIndex int, uid string)
+	attach = func(parentIndex int, uid string) {
+		if attached[uid] {
+			return
+		}
+		attached[uid] = true
+		for _, child := range children[uid] {
+			task := &imported[parentIndex].Task
+			item := client.TaskItem{Title: child.Text("SUMMARY"), SortOrder: int64(len(task.Items))}
+			if strings.EqualFold(child.Text("STATUS"), "COMPLETED") {
+				item.Status = client.ItemStatusCompleted
+			}
+			task.Items = append(task.Items, item)
+			attach(parentIndex, child.Text("UID"))
+		}
+	}
+	for uid, i := range index {
+		attach(i, uid)
+	}
+	return imported
+}
+
+// relationCycle 沿 parents 中的父条目关系向上查找，uid 处于环中时按关系顺序返回环上的 UID，否则返回 nil
+// 只指向环而不在环上的条目返回 nil，它们挂到环上条目导入后的顶层任务下
+func relationCycle(uid string, parents map[string]string) []string {
+	if uid == "" {
+		return nil
+	}
+	path := []string{uid}
+	seen := map[string]bool{uid: true}
+	for current := parents[uid]; current != ""; current = parents[current] {
+		if current == uid {
+			return path
+		}
+		if seen[current] {
+			return nil
+		}
+		if _, ok := parents[current]; !ok {
+			return nil
+		}
+		seen[current] = true
+		path = append(path, current)
+	}
+	return nil
+}
+
+// collect 递归收集指定名称的组件
+func collect(c Component, name string) []Component {
+	var found []Component
+	for _, child := range c.Children {
+		if child.Name == name {
+			found = append(found, child)
+			continue
+		}
+		found = append(found, collect(child, name)...)
+	}
+	return found
+}
+
+// parentUID 返回 RELATED-TO（RELTYPE 为空或 PARENT）指向的父条目 UID
+func parentUID(todo Component) string {
+	for _, p := range todo.All("RELATED-TO") {
+		if reltype := p.Param("RELTYPE"); reltype == "" || strings.EqualFold(reltype, "PARENT") {
+			return UnescapeText(p.Value)
+		}
+	}
+	return ""
+}
+
+// convertTodo 将一个 VTODO 转换为任务
+func convertTodo(todo Component, opts ImportOptions) ImportedTask {
+	item := ImportedTask{UID: todo.Text("UID")}
+	task := &item.Task
+	warn := func(format string, args ...any) {
+		item.Warnings = append(item.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	task.Title = strings.TrimSpace(todo.Text("SUMMARY"))
+	if task.Title == "" {
+		task.Title = "(untitled)"
+		warn("missing SUMMARY")
+	}
+	description, items := splitChecklist(todo.Text("DESCRIPTION"))
+	task.Items = items
+
+	allDay := true
+	var start, due time.Time
+	for _, field := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"DTSTART", &start},
+		{"DUE", &due},
+	} {
+		p, ok := todo.Get(field.name)
+		if !ok {
+			continue
+		}
+		t, dateOnly, err := ParseTime(p, opts.Location)
+		if err != nil {
+			warn("invalid %s %q", field.name, p.Value)
+			continue
+		}
+		*field.target = t
+		allDay = allDay && dateOnly
+	}
+	if !start.IsZero() || !due.IsZero() {
+		task.StartDate, task.DueDate = client.NewTime(start), client.NewTime(due)
+		task.IsAllDay = allDay
+		task.TimeZone = opts.TimeZone
+	}
+
+	if p, ok := todo.Get("PRIORITY"); ok {
+		if priority, err := strconv.Atoi(strings.TrimSpace(p.Value)); err == nil {
+			task.Priority = FromPriority(priority)
+		}
+	}
+	for _, p := range todo.All("CATEGORIES") {
+		for _, tag := range splitList(p.Value) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				task.Tags = append(task.Tags, tag)
+			}
+		}
+	}
+
+	if p, ok := todo.Get("RRULE"); ok {
+		rule, err := recurrence.Parse("RRULE:" + p.Value)
+		switch {
+		case err != nil:
+			warn("unsupported RRULE %q: %v", p.Value, err)
+		case start.IsZero() && due.IsZero():
+			warn("RRULE ignored because the task has no date")
+		default:
+			task.RepeatFlag = rule.String()
+		}
+	}
+
+	for _, alarm := range collect(todo, "VALARM") {
+		trigger, err := alarmTrigger(alarm, start, due)
+		if err != nil {
+			warn("%v", err)
+			continue
+		}
+		task.Reminders = append(task.Reminders, trigger)
+	}
+
+	switch strings.ToUpper(todo.Text("STATUS")) {
+	case "COMPLETED":
+		item.Completed = true
+	case "CANCELLED":
+		item.Cancelled = true
+	}
+	if item.UID == "" {
+		item.UID = generatedUID(task.Title, todo)
+	}
+	task.Content = WithUID(description, item.UID)
+	return item
+}
+
+// alarmTrigger 将 VALARM 的 TRIGGER 转换为相对任务开始时间（没有开始时间时为到期时间）的 TickTick 提醒
+func alarmTrigger(alarm Component, start, due time.Time) (string, error) {
+	p, ok := alarm.Get("TRIGGER")
+	if !ok {
+		return "", fmt.Errorf("VALARM without TRIGGER ignored")
+	}
+	anchor := reminder.Anchor(start, due)
+	if anchor.IsZero() {
+		return "", fmt.Errorf("reminder ignored because the task has no date")
+	}
+	if strings.EqualFold(p.Param("VALUE"), "DATE-TIME") {
+		at, _, err := ParseTime(p, anchor.Location())
+		if err != nil {
+			return "", fmt.Errorf("invalid TRIGGER %q", p.Value)
+		}
+		return reminder.FormatTrigger(at.Sub(anchor).Truncate(time.Minute)), nil
+	}
+	offset, err := reminder.ParseTrigger(p.Value)
+	if err != nil {
+		return "", err
+	}
+	// 相对结束时间的提醒换算为相对开始时间
+	if strings.EqualFold(p.Param("RELATED"), "END") && !start.IsZero() && !due.IsZero() {
+		offset += due.Sub(start)
+	}
+	return reminder.FormatTrigger(offset), nil
+}
+
+// splitChecklist 拆出描述末尾连续的清单行作为子任务
+func splitChecklist(description string) (string, []client.TaskItem) {
+	lines := strings.Split(strings.TrimRight(description, "\n "), "\n")
+	first := len(lines)
+	for first > 0 && checklistPattern.MatchString(lines[first-1]) {
+		first--
+	}
+	var items []client.TaskItem
+	for i, line := range lines[first:] {
+		m := checklistPattern.FindStringSubmatch(line)
+		item := client.TaskItem{Title: strings.TrimSpace(m[2]), SortOrder: int64(i)}
+		if m[1] != " " {
+			item.Status = client.ItemStatusCompleted
+		}
+		items = append(items, item)
+	}
+	return strings.TrimSpace(strings.Join(lines[:first], "\n")), items
+}
+
+// generatedUID 为没有 UID 的 VTODO 由标题和日期生成稳定的 UID
+func generatedUID(title string, todo Component) string {
+	key := title
+	for _, name := range []string{"DTSTART", "DUE"} {
+		if p, ok := todo.Get(name); ok {
+			key += "|" + p.Value
+		}
+	}
+	return fmt.Sprintf("%x@import", sha1.Sum([]byte(key)))
+}
+
+// WithUID 在正文末尾追加记录 UID 的行
+func WithUID(content, uid string) string {
+	line := uidMarker + uid
+	if content == "" {
+		return line
+	}
+	return content + "\n\n" + line
+}
+
+// ContentUID 返回正文中记录的 UID，没有时返回空字符串
+func ContentUID(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if uid, ok := strings.CutPrefix(strings.TrimSpace(line), uidMarker); ok {
+			return strings.TrimSpace(uid)
+		}
+	}
+	return ""
+}
+
+// FromPriority 将 iCalendar 优先级（1 最高、9 最低、0 未定义）转换为 TickTick 优先级
+func FromPriority(priority int) int {
+	switch {
+	case priority >= 1 && priority <= 4:
+		return 5
+	case priority == 5:
+		return 3
+	case priority >= 6 && priority <= 9:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,218 @@
+package ical
+
+import (
+	"dida/internal/client"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseTasks 解析日历文本并转换其中的 VTODO
+func parseTasks(t *testing.T, text string) []ImportedTask {
+	t.Helper()
+	calendars, err := Parse(strings.NewReader(strings.ReplaceAll(text, "\n", "\r\n")))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return Tasks(calendars, ImportOptions{Location: time.UTC})
+}
+
+// todo 生成一个 VTODO，parent 不为空时带 RELATED-TO
+func todo(uid, summary, parent string) string {
+	text := "BEGIN:VTODO\nUID:" + uid + "\nSUMMARY:" + summary + "\n"
+	if parent != "" {
+		text += "RELATED-TO:" + parent + "\n"
+	}
+	return text + "END:VTODO\n"
+}
+
+func calendar(todos ...string) string {
+	return "BEGIN:VCALENDAR\nVERSION:2.0\n" + strings.Join(todos, "") + "END:VCALENDAR\n"
+}
+
+func TestTasksRelatedTo(t *testing.T) {
+	tests := []struct {
+		name  string
+		todos []string
+		// want 顶层任务的标题及其子任务标题
+		want map[string][]string
+		// warned 带有 RELATED-TO 警告的任务
+		warned []string
+	}{
+		{
+			name:  "parent and nested children",
+			todos: []string{todo("a", "A", ""), todo("b", "B", "a"), todo("c", "C", "b")},
+			want:  map[string][]string{"A": {"B", "C"}},
+		},
+		{
+			name:  "parent outside the file",
+			todos: []string{todo("a", "A", "missing")},
+			want:  map[string][]string{"A": nil},
+		},
+		{
+			name:   "self reference",
+			todos:  []string{todo("a", "A", "a"), todo("b", "B", "a")},
+			want:   map[string][]string{"A": {"B"}},
+			warned: []string{"A"},
+		},
+		{
+			name:   "two-task cycle",
+			todos:  []string{todo("a", "A", "b"), todo("b", "B", "a")},
+			want:   map[string][]string{"A": nil, "B": nil},
+			warned: []string{"A", "B"},
+		},
+		{
+			name:   "child of a cycle",
+			todos:  []string{todo("a", "A", "c"), todo("b", "B", "a"), todo("c", "C", "b"), todo("d", "D", "a")},
+			want:   map[string][]string{"A": {"D"}, "B": nil, "C": nil},
+			warned: []string{"A", "B", "C"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imported := parseTasks(t, calendar(tt.todos...))
+			got := make(map[string][]string)
+			var warned []string
+			for _, item := range imported {
+				var items []string
+				for _, sub := range item.Task.Items {
+					items = append(items, sub.Title)
+				}
+				got[item.Task.Title] = items
+				for _, warning := range item.Warnings {
+					if strings.Contains(warning, "RELATED-TO") {
+						warned = append(warned, item.Task.Title)
+					}
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got tasks %v, want %v", got, tt.want)
+			}
+			for title, items := range tt.want {
+				if strings.Join(got[title], ",") != strings.Join(items, ",") {
+					t.Errorf("task %s: got subtasks %v, want %v", title, got[title], items)
+				}
+			}
+			if strings.Join(warned, ",") != strings.Join(tt.warned, ",") {
+				t.Errorf("got warnings on %v, want %v", warned, tt.warned)
+			}
+		})
+	}
+}
+
+func TestTasksConvert(t *testing.T) {
+	imported := parseTasks(t, calendar(
+		"BEGIN:VTODO\nUID:x1\nSUMMARY:Plan\nDESCRIPTION:Notes\\n\\n- [ ] one\\n- [x] two\n"+
+			"DTSTART:20261101T010000Z\nDUE:20261101T030000Z\nPRIORITY:2\nSTATUS:CANCELLED\n"+
+			"BEGIN:VALARM\nTRIGGER;RELATED=END:-PT30M\nEND:VALARM\n"+
+			"BEGIN:VALARM\nTRIGGER;VALUE=DATE-TIME:20261101T004500Z\nEND:VALARM\n"+
+			"BEGIN:VALARM\nACTION:DISPLAY\nEND:VALARM\n"+
+			"END:VTODO\n",
+		"BEGIN:VTODO\nSUMMARY:No date\nRRULE:FREQ=DAILY\nEND:VTODO\n",
+		"BEGIN:VTODO\nUID:x3\nDUE;VALUE=DATE:20261105\nRRULE:FREQ=HOURLY\nEND:VTODO\n",
+	))
+	if len(imported) != 3 {
+		t.Fatalf("imported %d tasks", len(imported))
+	}
+
+	plan := imported[0]
+	if plan.Task.Priority != 5 || !plan.Cancelled || plan.Completed {
+		t.Errorf("priority %d, cancelled %v, completed %v", plan.Task.Priority, plan.Cancelled, plan.Completed)
+	}
+	if plan.Task.Content != "Notes\n\n"+uidMarker+"x1" || ContentUID(plan.Task.Content) != "x1" {
+		t.Errorf("content = %q", plan.Task.Content)
+	}
+	if len(plan.Task.Items) != 2 || plan.Task.Items[0].Title != "one" || plan.Task.Items[1].Status != client.ItemStatusCompleted {
+		t.Errorf("items = %+v", plan.Task.Items)
+	}
+	// 相对结束时间的 -30m 即开始后 1.5 小时；绝对时间的提醒换算为相对开始时间
+	if got := strings.Join(plan.Task.Reminders, ","); got != "TRIGGER:PT1H30M,TRIGGER:-PT15M" {
+		t.Errorf("reminders = %s", got)
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], "without TRIGGER") {
+		t.Errorf("warnings = %q", plan.Warnings)
+	}
+
+	noDate := imported[1]
+	if !strings.HasSuffix(noDate.UID, "@import") || noDate.Task.RepeatFlag != "" {
+		t.Errorf("task without UID = %+v", noDate)
+	}
+	if len(noDate.Warnings) != 1 || !strings.Contains(noDate.Warnings[0], "no date") {
+		t.Errorf("warnings = %q", noDate.Warnings)
+	}
+	// 生成的 UID 在重复导入时保持不变
+	again := parseTasks(t, calendar("BEGIN:VTODO\nSUMMARY:No date\nRRULE:FREQ=DAILY\nEND:VTODO\n"))
+	if again[0].UID != noDate.UID {
+		t.Errorf("generated UID changed: %s != %s", again[0].UID, noDate.UID)
+	}
+
+	untitled := imported[2]
+	if untitled.Task.Title != "(untitled)" || !untitled.Task.IsAllDay || untitled.Task.RepeatFlag != "" {
+		t.Errorf("untitled task = %+v", untitled.Task)
+	}
+	if len(untitled.Warnings) != 2 {
+		t.Errorf("warnings = %q", untitled.Warnings)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	start := time.Date(2026, 11, 1, 9, 0, 0, 0, exportLoc)
+	tasks := []client.Task{
+		{
+			ID: "t1", Title: "Report; draft, v2", Content: "line 1\nline 2", Priority: 5,
+			StartDate: client.NewTime(start), DueDate: client.NewTime(start.Add(2 * time.Hour)),
+			Tags: []string{"work", "a,b"}, Reminders: []string{"TRIGGER:-PT15M"},
+			RepeatFlag: "RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=MO;TT_SKIP=HOLIDAY",
+			Items:      []client.TaskItem{{Title: "outline"}, {Title: "review", Status: client.ItemStatusCompleted}},
+		},
+		{
+			ID: "t2", Title: "Holiday", IsAllDay: true, Priority: 1,
+			DueDate:   client.NewTime(time.Date(2026, 10, 31, 16, 0, 0, 0, time.UTC)),
+			Reminders: []string{"TRIGGER:-PT15H"},
+		},
+		{ID: "t3", Title: "Someday", Status: client.TaskStatusCompleted},
+	}
+
+	cal, _, imported := exportTasks(t, tasks, ModeTodo)
+	if got := cal.Text("X-WR-CALNAME"); got != "Work" {
+		t.Errorf("X-WR-CALNAME = %q", got)
+	}
+	if len(imported) != len(tasks) {
+		t.Fatalf("imported %d tasks, want %d", len(imported), len(tasks))
+	}
+
+	first := imported[0]
+	if first.UID != "t1"+UIDSuffix || first.Task.Title != tasks[0].Title || first.Task.Priority != 5 {
+		t.Errorf("first task = %+v", first)
+	}
+	if !first.Task.StartDate.Equal(start) || !first.Task.DueDate.Equal(start.Add(2*time.Hour)) || first.Task.IsAllDay {
+		t.Errorf("first task dates = %v - %v", first.Task.StartDate, first.Task.DueDate)
+	}
+	if want := "line 1\nline 2\n\n" + uidMarker + "t1" + UIDSuffix; first.Task.Content != want {
+		t.Errorf("content = %q, want %q", first.Task.Content, want)
+	}
+	if strings.Join(first.Task.Tags, "|") != "work|a,b" {
+		t.Errorf("tags = %q", first.Task.Tags)
+	}
+	// TickTick 私有的 TT_ 参数不导出
+	if first.Task.RepeatFlag != "RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=MO" {
+		t.Errorf("repeat = %q", first.Task.RepeatFlag)
+	}
+	if strings.Join(first.Task.Reminders, ",") != "TRIGGER:-PT15M" {
+		t.Errorf("reminders = %q", first.Task.Reminders)
+	}
+	if len(first.Task.Items) != 2 || first.Task.Items[1].Status != client.ItemStatusCompleted {
+		t.Errorf("items = %+v", first.Task.Items)
+	}
+
+	holiday := imported[1].Task
+	if !holiday.IsAllDay || holiday.DueDate.In(exportLoc).Format("2006-01-02 15:04") != "2026-11-01 00:00" {
+		t.Errorf("all-day task = %v (all day %v)", holiday.DueDate, holiday.IsAllDay)
+	}
+	if strings.Join(holiday.Reminders, ",") != "TRIGGER:-PT15H" {
+		t.Errorf("all-day reminders = %q", holiday.Reminders)
+	}
+	if !imported[2].Completed || !imported[2].Task.DueDate.IsZero() {
+		t.Errorf("completed task = %+v", imported[2])
+	}
+}
@@ -0,0 +1,194 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Parse 解析 iCalendar 数据，返回顶层组件（通常为一个 VCALENDAR）
+// 折行被展开，属性名转为大写，值保持原样（TEXT 值需用 UnescapeText 还原）
+func Parse(r io.Reader) ([]Component, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	// 以空格或制表符开头的行是上一行的续行
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	var roots []Component
+	var stack []*Component
+	for i, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		switch prop.Name {
+		case "BEGIN":
+			stack = append(stack, &Component{Name: strings.ToUpper(prop.Value)})
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, prop.Value)
+			}
+			done := *stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				roots = append(roots, done)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, done)
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property %s outside of a component", i+1, prop.Name)
+			}
+			stack[len(stack)-1].Properties = append(stack[len(stack)-1].Properties, prop)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no iCalendar data found")
+	}
+	return roots, nil
+}
+
+// parseLine 解析内容行 NAME;PARAM=VALUE:value，引号内的冒号和分号不作分隔
+func parseLine(line string) (Property, error) {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				parts = append(parts, line[start:i])
+				start = i + 1
+			}
+		case ':':
+			if !quoted {
+				parts = append(parts, line[start:i])
+				name := strings.ToUpper(strings.TrimSpace(parts[0]))
+				if name == "" {
+					return Property{}, fmt.Errorf("missing property name")
+				}
+				return Property{Name: name, Params: parts[1:], Value: line[i+1:]}, nil
+			}
+		}
+	}
+	return Property{}, fmt.Errorf("invalid content line %q", line)
+}
+
+// Param 返回参数值（去掉引号），参数名不区分大小写
+func (p Property) Param(name string) string {
+	for _, param := range p.Params {
+		key, value, ok := strings.Cut(param, "=")
+		if ok && strings.EqualFold(key, name) {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// Get 返回第一个指定名称的属性
+func (c Component) Get(name string) (Property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// All 返回所有指定名称的属性
+func (c Component) All(name string) []Property {
+	var props []Property
+	for _, p := range c.Properties {
+		if p.Name == name {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// Text 返回第一个指定名称的属性转义还原后的值，不存在时返回空字符串
+func (c Component) Text(name string) string {
+	p, ok := c.Get(name)
+	if !ok {
+		return ""
+	}
+	return UnescapeText(p.Value)
+}
+
+// UnescapeText 还原 TEXT 值中的转义字符
+func UnescapeText(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// splitList 拆分以未转义逗号分隔的多值属性，如 CATEGORIES
+func splitList(value string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, UnescapeText(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, UnescapeText(value[start:]))
+}
+
+// ParseTime 解析 DATE 或 DATE-TIME 属性值
+// UTC 时间以 Z 结尾；带 TZID 参数时按该时区解析，无法识别的时区和浮动时间按 loc 解析；DATE 值返回 loc 中当天零点且 allDay 为 true
+func ParseTime(p Property, loc *time.Location) (t time.Time, allDay bool, err error) {
+	value := strings.TrimSpace(p.Value)
+	if strings.EqualFold(p.Param("VALUE"), "DATE") || len(value) == len(dateLayout) {
+		t, err = time.ParseInLocation(dateLayout, value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(dateTimeLayout, value)
+		return t, false, err
+	}
+	zone := loc
+	if tzid := p.Param("TZID"); tzid != "" {
+		if named, err := time.LoadLocation(tzid); err == nil {
+			zone = named
+		}
+	}
+	t, err = time.ParseInLocation(strings.TrimSuffix(dateTimeLayout, "Z"), value, zone)
+	return t, false, err
+}
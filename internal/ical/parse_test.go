@@ -0,0 +1,100 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	data := "\uFEFFBEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:1\r\n" +
+		"SUMMARY:Long\r\n" +
+		"  title\r\n" +
+		"\tcontinued\r\n" +
+		"DUE;TZID=\"Asia/Shanghai\";VALUE=DATE-TIME:20261101T090000\r\n" +
+		"x-custom;param=\"a:b;c\":value:with:colons\r\n" +
+		"CATEGORIES:work,home\\,garden\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"TRIGGER:-PT15M\r\n" +
+		"END:VALARM\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+	calendars, err := Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calendars) != 1 || calendars[0].Name != "VCALENDAR" || len(calendars[0].Children) != 1 {
+		t.Fatalf("unexpected components: %+v", calendars)
+	}
+	todo := calendars[0].Children[0]
+	if got := todo.Text("SUMMARY"); got != "Long titlecontinued" {
+		t.Errorf("SUMMARY = %q", got)
+	}
+	due, _ := todo.Get("DUE")
+	if due.Param("tzid") != "Asia/Shanghai" || due.Param("VALUE") != "DATE-TIME" {
+		t.Errorf("DUE params = %v", due.Params)
+	}
+	custom, ok := todo.Get("X-CUSTOM")
+	if !ok || custom.Value != "value:with:colons" || custom.Param("PARAM") != "a:b;c" {
+		t.Errorf("X-CUSTOM = %+v", custom)
+	}
+	categories, _ := todo.Get("CATEGORIES")
+	if got := splitList(categories.Value); strings.Join(got, "|") != "work|home,garden" {
+		t.Errorf("CATEGORIES = %q", got)
+	}
+	if len(todo.Children) != 1 || todo.Children[0].Name != "VALARM" {
+		t.Errorf("VALARM not nested: %+v", todo.Children)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"", "no iCalendar data"},
+		{"SUMMARY:x\n", "outside of a component"},
+		{"BEGIN:VCALENDAR\nBEGIN:VTODO\nEND:VCALENDAR\n", "unexpected END:VCALENDAR"},
+		{"BEGIN:VCALENDAR\n", "missing END:VCALENDAR"},
+		{"BEGIN:VCALENDAR\nno colon here\nEND:VCALENDAR\n", "invalid content line"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	tests := []struct {
+		prop   Property
+		want   string
+		allDay bool
+	}{
+		{Property{Value: "20261101"}, "2026-11-01T00:00:00+08:00", true},
+		{Property{Params: []string{"VALUE=DATE"}, Value: "20261101"}, "2026-11-01T00:00:00+08:00", true},
+		{Property{Value: "20261101T090000Z"}, "2026-11-01T09:00:00Z", false},
+		{Property{Value: "20261101T090000"}, "2026-11-01T09:00:00+08:00", false},
+		{Property{Params: []string{"TZID=UTC"}, Value: "20261101T090000"}, "2026-11-01T09:00:00Z", false},
+		// 无法识别的时区按 loc 解析
+		{Property{Params: []string{"TZID=Nowhere/City"}, Value: "20261101T090000"}, "2026-11-01T09:00:00+08:00", false},
+	}
+	for _, tt := range tests {
+		got, allDay, err := ParseTime(tt.prop, loc)
+		if err != nil {
+			t.Errorf("ParseTime(%+v): %v", tt.prop, err)
+			continue
+		}
+		if got.Format(time.RFC3339) != tt.want || allDay != tt.allDay {
+			t.Errorf("ParseTime(%+v) = %s, %v, want %s, %v", tt.prop, got.Format(time.RFC3339), allDay, tt.want, tt.allDay)
+		}
+	}
+	if _, _, err := ParseTime(Property{Value: "2026-11-01"}, loc); err == nil {
+		t.Error("ParseTime accepted an invalid value")
+	}
+}
@@ -0,0 +1,138 @@
+package mirror
+
+import (
+	"dida/internal/client"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Action 修改日志中的一次任务修改
+type Action struct {
+	ID        int64
+	CreatedAt time.Time
+	// Kind 修改类型，取值见 client.Mutation* 常量
+	Kind      string
+	ProjectID string
+	TaskID    string
+	Title     string
+	// Before 修改前的任务，创建时为 nil
+	Before *client.Task
+	// After 修改后的任务，完成和删除时为 nil
+	After *client.Task
+	// UndoneAt 撤销时间，未撤销时为零值
+	UndoneAt time.Time
+	// UndoNote 撤销结果说明，如重新创建的任务 ID
+	UndoNote string
+}
+
+const actionColumns = `id, created_at, kind, project_id, task_id, title, before, after, undone_at, undo_note`
+
+// RecordAction 写入一条修改记录，并回填 ID 和时间
+func (m *Mirror) RecordAction(action *Action) error {
+	before, err := marshalTask(action.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalTask(action.After)
+	if err != nil {
+		return err
+	}
+	if action.CreatedAt.IsZero() {
+		action.CreatedAt = time.Now()
+	}
+	res, err := m.db.Exec(`INSERT INTO actions (created_at, kind, project_id, task_id, title, before, after)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		action.CreatedAt.UnixMilli(), action.Kind, action.ProjectID, action.TaskID, action.Title, before, after)
+	if err != nil {
+		return err
+	}
+	action.ID, err = res.LastInsertId()
+	return err
+}
+
+// RecentActions 返回最近的 limit 条修改记录，最新的在前，limit <= 0 表示不限制
+func (m *Mirror) RecentActions(limit int) ([]Action, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	return m.queryActions(`ORDER BY id DESC LIMIT ?`, limit)
+}
+
+// ActionByID 按 ID 查找修改记录，不存在时返回 ErrNotFound
+func (m *Mirror) ActionByID(id int64) (*Action, error) {
+	actions, err := m.queryActions(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) == 0 {
+		return nil, ErrNotFound
+	}
+	return &actions[0], nil
+}
+
+// MarkUndone 将修改记录标记为已撤销
+func (m *Mirror) MarkUndone(id int64, note string) error {
+	_, err := m.db.Exec(`UPDATE actions SET undone_at = ?, undo_note = ? WHERE id = ?`, time.Now().UnixMilli(), note, id)
+	return err
+}
+
+// PruneActions 删除 before 之前的修改记录
+func (m *Mirror) PruneActions(before time.Time) error {
+	_, err := m.db.Exec(`DELETE FROM actions WHERE created_at < ?`, before.UnixMilli())
+	return err
+}
+
+func (m *Mirror) queryActions(where string, args ...any) ([]Action, error) {
+	rows, err := m.db.Query(`SELECT `+actionColumns+` FROM actions `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []Action
+	for rows.Next() {
+		var (
+			a                   Action
+			before, after       string
+			createdAt, undoneAt int64
+		)
+		err := rows.Scan(&a.ID, &createdAt, &a.Kind, &a.ProjectID, &a.TaskID, &a.Title, &before, &after, &undoneAt, &a.UndoNote)
+		if err != nil {
+			return nil, err
+		}
+		if a.Before, err = unmarshalTask(before); err != nil {
+			return nil, fmt.Errorf("action %d: %v", a.ID, err)
+		}
+		if a.After, err = unmarshalTask(after); err != nil {
+			return nil, fmt.Errorf("action %d: %v", a.ID, err)
+		}
+		a.CreatedAt = time.UnixMilli(createdAt)
+		if undoneAt != 0 {
+			a.UndoneAt = time.UnixMilli(undoneAt)
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+// marshalTask 将任务编码为 JSON，nil 编码为空字符串
+func marshalTask(task *client.Task) (string, error) {
+	if task == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(task)
+	return string(data), err
+}
+
+// unmarshalTask 解码 marshalTask 的结果
+func unmarshalTask(data string) (*client.Task, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var task client.Task
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
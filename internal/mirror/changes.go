@@ -0,0 +1,190 @@
+package mirror
+
+import (
+	"database/sql"
+	"dida/internal/client"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// snapshotScope 最近一次完整同步的范围
+const snapshotScope = "snapshot"
+
+// 变更类型
+const (
+	ChangeTaskCreated    = "task_created"
+	ChangeTaskUpdated    = "task_updated"
+	ChangeTaskCompleted  = "task_completed"
+	ChangeTaskDeleted    = "task_deleted"
+	ChangeTaskMoved      = "task_moved"
+	ChangeProjectCreated = "project_created"
+	ChangeProjectRenamed = "project_renamed"
+	ChangeProjectDeleted = "project_deleted"
+)
+
+// Change 同步时检测到的一条变更
+type Change struct {
+	ID          int64
+	DetectedAt  time.Time
+	Kind        string
+	ProjectID   string
+	ProjectName string
+	TaskID      string
+	Title       string
+	// Details 补充说明，如更新的字段或项目的旧名称
+	Details string
+}
+
+// Snapshot 最近一次完整同步时的项目和任务
+type Snapshot struct {
+	// Projects 项目 ID 到名称的映射，不含收集箱
+	Projects map[string]string
+	// Tasks 任务 ID 到任务的映射
+	Tasks map[string]SnapshotTask
+	// SyncedAt 最近一次完整同步的时间，从未同步时为零值
+	SyncedAt time.Time
+}
+
+// SnapshotTask 快照中的任务及其所在项目的键
+type SnapshotTask struct {
+	ProjectKey string
+	Task       client.Task
+}
+
+// LoadSnapshot 读取最近一次完整同步的快照
+func (m *Mirror) LoadSnapshot() (*Snapshot, error) {
+	syncedAt, err := m.syncedAt(snapshotScope)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{
+		Projects: make(map[string]string),
+		Tasks:    make(map[string]SnapshotTask),
+		SyncedAt: syncedAt,
+	}
+
+	rows, err := m.db.Query(`SELECT id, name FROM snapshot_projects`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		snapshot.Projects[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = m.db.Query(`SELECT id, project_key, data FROM snapshot_tasks`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, key, data string
+		if err := rows.Scan(&id, &key, &data); err != nil {
+			return nil, err
+		}
+		var task client.Task
+		if err := json.Unmarshal([]byte(data), &task); err != nil {
+			return nil, fmt.Errorf("snapshot task %s: %v", id, err)
+		}
+		snapshot.Tasks[id] = SnapshotTask{ProjectKey: key, Task: task}
+	}
+	return snapshot, rows.Err()
+}
+
+// SaveSnapshot 在一个事务中替换快照并记录变更
+// tasks 为本次成功拉取的项目（以项目键索引）的任务，未拉取到的项目保留原快照；
+// 已不在 projects 中的项目的任务从快照中删除
+func (m *Mirror) SaveSnapshot(projects []client.Project, tasks map[string][]client.Task, changes []Change, syncedAt time.Time) error {
+	return m.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM snapshot_projects`); err != nil {
+			return err
+		}
+		for _, p := range projects {
+			if _, err := tx.Exec(`INSERT INTO snapshot_projects (id, name) VALUES (?, ?)`, p.ID, p.Name); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM snapshot_tasks WHERE project_key NOT IN (SELECT id FROM snapshot_projects) AND project_key != ?`, client.InboxProjectID); err != nil {
+			return err
+		}
+
+		for key, list := range tasks {
+			if _, err := tx.Exec(`DELETE FROM snapshot_tasks WHERE project_key = ?`, key); err != nil {
+				return err
+			}
+			for _, task := range list {
+				data, err := json.Marshal(task)
+				if err != nil {
+					return err
+				}
+				// 任务可能从尚未拉取的项目移动过来，以新位置为准
+				_, err = tx.Exec(`INSERT INTO snapshot_tasks (id, project_key, data) VALUES (?, ?, ?)
+					ON CONFLICT (id) DO UPDATE SET project_key = excluded.project_key, data = excluded.data`,
+					task.ID, key, string(data))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, c := range changes {
+			_, err := tx.Exec(`INSERT INTO changes (detected_at, kind, project_id, project_name, task_id, title, details)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				syncedAt.UnixMilli(), c.Kind, c.ProjectID, c.ProjectName, c.TaskID, c.Title, c.Details)
+			if err != nil {
+				return err
+			}
+		}
+		return setSynced(tx, snapshotScope, syncedAt)
+	})
+}
+
+// ChangesSince 返回 since 之后检测到的最近 limit 条变更，按时间先后排列，limit <= 0 表示不限制
+func (m *Mirror) ChangesSince(since time.Time, limit int) ([]Change, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := m.db.Query(`SELECT id, detected_at, kind, project_id, project_name, task_id, title, details
+		FROM changes WHERE detected_at > ? ORDER BY detected_at DESC, id DESC LIMIT ?`, since.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []Change
+	for rows.Next() {
+		var (
+			c      Change
+			millis int64
+		)
+		if err := rows.Scan(&c.ID, &millis, &c.Kind, &c.ProjectID, &c.ProjectName, &c.TaskID, &c.Title, &c.Details); err != nil {
+			return nil, err
+		}
+		c.DetectedAt = time.UnixMilli(millis)
+		changes = append(changes, c)
+	}
+	for i, j := 0, len(changes)-1; i < j; i, j = i+1, j-1 {
+		changes[i], changes[j] = changes[j], changes[i]
+	}
+	return changes, rows.Err()
+}
+
+// PruneChanges 删除 before 之前检测到的变更
+func (m *Mirror) PruneChanges(before time.Time) error {
+	_, err := m.db.Exec(`DELETE FROM changes WHERE detected_at < ?`, before.UnixMilli())
+	return err
+}
+
+// LastSync 返回最近一次完整同步的时间，从未同步时返回零值
+func (m *Mirror) LastSync() (time.Time, error) {
+	return m.syncedAt(snapshotScope)
+}
@@ -0,0 +1,188 @@
+package mirror
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migration 一次结构变更，version 从 1 开始依次递增
+type migration struct {
+	version int
+	stmts   []string
+}
+
+// migrations 按顺序执行的结构变更；已发布的条目不能修改，只能追加
+var migrations = []migration{
+	{
+		version: 1,
+		stmts: []string{
+			`CREATE TABLE projects (
+				id         TEXT PRIMARY KEY,
+				name       TEXT NOT NULL,
+				color      TEXT NOT NULL DEFAULT '',
+				view_mode  TEXT NOT NULL DEFAULT '',
+				kind       TEXT NOT NULL DEFAULT '',
+				sort_order INTEGER NOT NULL DEFAULT 0,
+				position   INTEGER NOT NULL
+			)`,
+			`CREATE TABLE tasks (
+				id             TEXT PRIMARY KEY,
+				project_key    TEXT NOT NULL,
+				project_id     TEXT NOT NULL,
+				title          TEXT NOT NULL,
+				content        TEXT NOT NULL DEFAULT '',
+				description    TEXT NOT NULL DEFAULT '',
+				is_all_day     INTEGER NOT NULL DEFAULT 0,
+				start_date     TEXT NOT NULL DEFAULT '',
+				due_date       TEXT NOT NULL DEFAULT '',
+				time_zone      TEXT NOT NULL DEFAULT '',
+				reminders      TEXT NOT NULL DEFAULT '[]',
+				repeat_flag    TEXT NOT NULL DEFAULT '',
+				priority       INTEGER NOT NULL DEFAULT 0,
+				status         INTEGER NOT NULL DEFAULT 0,
+				completed_time TEXT NOT NULL DEFAULT '',
+				sort_order     INTEGER NOT NULL DEFAULT 0,
+				column_id      TEXT NOT NULL DEFAULT '',
+				tags           TEXT NOT NULL DEFAULT '[]',
+				position       INTEGER NOT NULL
+			)`,
+			`CREATE INDEX tasks_project_key ON tasks (project_key, position)`,
+			`CREATE TABLE subtasks (
+				task_id        TEXT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+				id             TEXT NOT NULL,
+				title          TEXT NOT NULL,
+				status         INTEGER NOT NULL DEFAULT 0,
+				sort_order     INTEGER NOT NULL DEFAULT 0,
+				start_date     TEXT NOT NULL DEFAULT '',
+				is_all_day     INTEGER NOT NULL DEFAULT 0,
+				time_zone      TEXT NOT NULL DEFAULT '',
+				completed_time TEXT NOT NULL DEFAULT '',
+				position       INTEGER NOT NULL,
+				PRIMARY KEY (task_id, position)
+			)`,
+			`CREATE TABLE columns (
+				id          TEXT PRIMARY KEY,
+				project_key TEXT NOT NULL,
+				project_id  TEXT NOT NULL,
+				name        TEXT NOT NULL,
+				sort_order  INTEGER NOT NULL DEFAULT 0
+			)`,
+			`CREATE INDEX columns_project_key ON columns (project_key)`,
+			`CREATE TABLE sync_state (
+				scope     TEXT PRIMARY KEY,
+				synced_at INTEGER NOT NULL
+			)`,
+		},
+	},
+	{
+		// 同步引擎的快照和变更记录；快照只在完整同步时更新，不受按需读取影响
+		version: 2,
+		stmts: []string{
+			`CREATE TABLE snapshot_projects (
+				id   TEXT PRIMARY KEY,
+				name TEXT NOT NULL
+			)`,
+			`CREATE TABLE snapshot_tasks (
+				id          TEXT PRIMARY KEY,
+				project_key TEXT NOT NULL,
+				data        TEXT NOT NULL
+			)`,
+			`CREATE TABLE changes (
+				id           INTEGER PRIMARY KEY AUTOINCREMENT,
+				detected_at  INTEGER NOT NULL,
+				kind         TEXT NOT NULL,
+				project_id   TEXT NOT NULL DEFAULT '',
+				project_name TEXT NOT NULL DEFAULT '',
+				task_id      TEXT NOT NULL DEFAULT '',
+				title        TEXT NOT NULL DEFAULT '',
+				details      TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE INDEX changes_detected_at ON changes (detected_at)`,
+		},
+	},
+	{
+		// 离线写入队列
+		version: 3,
+		stmts: []string{
+			`CREATE TABLE outbox (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				idempotency_key TEXT NOT NULL UNIQUE,
+				operation       TEXT NOT NULL,
+				project_id      TEXT NOT NULL DEFAULT '',
+				task_id         TEXT NOT NULL DEFAULT '',
+				args            TEXT NOT NULL,
+				base            TEXT NOT NULL DEFAULT '',
+				status          TEXT NOT NULL,
+				attempts        INTEGER NOT NULL DEFAULT 0,
+				last_error      TEXT NOT NULL DEFAULT '',
+				result          TEXT NOT NULL DEFAULT '',
+				created_at      INTEGER NOT NULL,
+				updated_at      INTEGER NOT NULL
+			)`,
+			`CREATE INDEX outbox_status ON outbox (status, id)`,
+		},
+	},
+	{
+		// 任务修改日志，用于撤销
+		version: 4,
+		stmts: []string{
+			`CREATE TABLE actions (
+				id         INTEGER PRIMARY KEY AUTOINCREMENT,
+				created_at INTEGER NOT NULL,
+				kind       TEXT NOT NULL,
+				project_id TEXT NOT NULL DEFAULT '',
+				task_id    TEXT NOT NULL DEFAULT '',
+				title      TEXT NOT NULL DEFAULT '',
+				before     TEXT NOT NULL DEFAULT '',
+				after      TEXT NOT NULL DEFAULT '',
+				undone_at  INTEGER NOT NULL DEFAULT 0,
+				undo_note  TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE INDEX actions_created ON actions (created_at)`,
+		},
+	},
+	{
+		// 记录排队前直接发送请求的时间，用于查找响应丢失时已创建的任务
+		version: 5,
+		stmts: []string{
+			`ALTER TABLE outbox ADD COLUMN sent_at INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+}
+
+// migrate 将数据库结构升级到最新版本，当前版本记录在 PRAGMA user_version 中
+func migrate(db *sql.DB) error {
+	var current int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %v", err)
+	}
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return fmt.Errorf("mirror schema version %d is newer than supported version %d", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		for _, stmt := range m.stmts {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d: %v", m.version, err)
+			}
+		}
+		// PRAGMA 不支持参数绑定，version 为内部常量
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, m.version)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %v", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %v", m.version, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+// Package mirror 在本地 SQLite 数据库中镜像 TickTick 的项目、任务、子任务和看板列
+//
+// 镜像按范围记录同步时间：项目列表为一个范围，每个项目的任务和列为一个范围。
+// 读取时同时返回同步时间，由调用方根据自己的新鲜度要求决定是否使用镜像数据。
+package mirror
+
+import (
+	"database/sql"
+	"dida/internal/client"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrNotFound 镜像中没有对应的数据
+var ErrNotFound = errors.New("not found in mirror")
+
+// projectsScope 项目列表的同步范围
+const projectsScope = "projects"
+
+// Mirror 本地 SQLite 镜像
+type Mirror struct {
+	db *sql.DB
+}
+
+// Open 打开（必要时创建）镜像数据库并执行结构迁移
+func Open(path string) (*Mirror, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create mirror directory: %v", err)
+		}
+	}
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open mirror %s: %v", path, err)
+	}
+	// SQLite 同一时间只允许一个写入者，使用单连接避免 SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate mirror %s: %v", path, err)
+	}
+	return &Mirror{db: db}, nil
+}
+
+// Close 关闭数据库
+func (m *Mirror) Close() error {
+	return m.db.Close()
+}
+
+// ProjectKey 返回项目在镜像中的键，收集箱的各种 ID 统一为 "inbox"
+func ProjectKey(projectID string) string {
+	if client.IsInbox(projectID) {
+		return client.InboxProjectID
+	}
+	return projectID
+}
+
+func projectScope(projectID string) string {
+	return "project:" + ProjectKey(projectID)
+}
+
+// SaveProjects 替换镜像中的项目列表，并删除已不存在的项目的任务和列
+func (m *Mirror) SaveProjects(projects []client.Project, syncedAt time.Time) error {
+	return m.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM projects`); err != nil {
+			return err
+		}
+		for i, p := range projects {
+			_, err := tx.Exec(`INSERT INTO projects (id, name, color, view_mode, kind, sort_order, position)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				p.ID, p.Name, p.Color, p.ViewMode, p.Kind, p.SortOrder, i)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 已删除项目的任务、列和同步记录一并清除
+		if _, err := tx.Exec(`DELETE FROM tasks WHERE project_key NOT IN (SELECT id FROM projects) AND project_key != ?`, client.InboxProjectID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM columns WHERE project_key NOT IN (SELECT id FROM projects) AND project_key != ?`, client.InboxProjectID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM sync_state WHERE scope LIKE 'project:%'
+			AND substr(scope, 9) NOT IN (SELECT id FROM projects) AND scope != ?`, projectScope(client.InboxProjectID)); err != nil {
+			return err
+		}
+		return setSynced(tx, projectsScope, syncedAt)
+	})
+}
+
+// Projects 返回镜像中的项目列表及其同步时间，从未同步时同步时间为零值
+func (m *Mirror) Projects() ([]client.Project, time.Time, error) {
+	syncedAt, err := m.syncedAt(projectsScope)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	rows, err := m.db.Query(`SELECT id, name, color, view_mode, kind, sort_order FROM projects ORDER BY position`)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	var projects []client.Project
+	for rows.Next() {
+		var p client.Project
+		if err := rows.Scan(&p.ID, &p.Name, &p.Color, &p.ViewMode, &p.Kind, &p.SortOrder); err != nil {
+			return nil, time.Time{}, err
+		}
+		projects = append(projects, p)
+	}
+	return projects, syncedAt, rows.Err()
+}
+
+// SaveProjectData 替换镜像中一个项目的全部任务、子任务和看板列
+func (m *Mirror) SaveProjectData(projectID string, data *client.ProjectData, syncedAt time.Time) error {
+	key := ProjectKey(projectID)
+	return m.withTx(func(tx *sql.Tx) error {
+		// 子任务通过外键级联删除
+		if _, err := tx.Exec(`DELETE FROM tasks WHERE project_key = ?`, key); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM columns WHERE project_key = ?`, key); err != nil {
+			return err
+		}
+		for i, task := range data.Tasks {
+			if err := insertTask(tx, key, i, task); err != nil {
+				return fmt.Errorf("save task %s: %v", task.ID, err)
+			}
+		}
+		for _, column := range data.Columns {
+			_, err := tx.Exec(`INSERT INTO columns (id, project_key, project_id, name, sort_order) VALUES (?, ?, ?, ?, ?)`,
+				column.ID, key, column.ProjectID, column.Name, column.SortOrder)
+			if err != nil {
+				return fmt.Errorf("save column %s: %v", column.ID, err)
+			}
+		}
+		return setSynced(tx, projectScope(key), syncedAt)
+	})
+}
+
+// ProjectData 返回镜像中一个项目的数据及其同步时间，项目从未同步时返回 ErrNotFound
+func (m *Mirror) ProjectData(projectID string) (*client.ProjectData, time.Time, error) {
+	key := ProjectKey(projectID)
+	syncedAt, err := m.syncedAt(projectScope(key))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if syncedAt.IsZero() {
+		return nil, time.Time{}, ErrNotFound
+	}
+
+	data := &client.ProjectData{}
+	if key == client.InboxProjectID {
+		data.Project = client.InboxProject()
+	} else {
+		err := m.db.QueryRow(`SELECT id, name, color, view_mode, kind, sort_order FROM projects WHERE id = ?`, key).
+			Scan(&data.Project.ID, &data.Project.Name, &data.Project.Color, &data.Project.ViewMode, &data.Project.Kind, &data.Project.SortOrder)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, time.Time{}, err
+		}
+	}
+
+	if data.Tasks, err = m.queryTasks(`WHERE project_key = ? ORDER BY position`, key); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	rows, err := m.db.Query(`SELECT id, project_id, name, sort_order FROM columns WHERE project_key = ? ORDER BY sort_order`, key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c client.Column
+		if err := rows.Scan(&c.ID, &c.ProjectID, &c.Name, &c.SortOrder); err != nil {
+			return nil, time.Time{}, err
+		}
+		data.Columns = append(data.Columns, c)
+	}
+	return data, syncedAt, rows.Err()
+}
+
+// Task 返回镜像中的单个任务及其所在项目的同步时间，不存在时返回 ErrNotFound
+func (m *Mirror) Task(projectID, taskID string) (*client.Task, time.Time, error) {
+	key := ProjectKey(projectID)
+	syncedAt, err := m.syncedAt(projectScope(key))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if syncedAt.IsZero() {
+		return nil, time.Time{}, ErrNotFound
+	}
+	tasks, err := m.queryTasks(`WHERE project_key = ? AND id = ?`, key, taskID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(tasks) == 0 {
+		return nil, time.Time{}, ErrNotFound
+	}
+	return &tasks[0], syncedAt, nil
+}
+
+// Invalidate 清除项目列表和各项目的同步时间，使镜像数据视为过期；数据本身保留
+func (m *Mirror) Invalidate() error {
+	_, err := m.db.Exec(`DELETE FROM sync_state WHERE scope = ? OR scope LIKE 'project:%'`, projectsScope)
+	return err
+}
+
+// withTx 在事务中执行 fn，fn 返回错误时回滚
+func (m *Mirror) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func setSynced(tx *sql.Tx, scope string, syncedAt time.Time) error {
+	_, err := tx.Exec(`INSERT INTO sync_state (scope, synced_at) VALUES (?, ?)
+		ON CONFLICT (scope) DO UPDATE SET synced_at = excluded.synced_at`, scope, syncedAt.UnixMilli())
+	return err
+}
+
+// syncedAt 返回范围的同步时间，从未同步时返回零值
+func (m *Mirror) syncedAt(scope string) (time.Time, error) {
+	var millis int64
+	err := m.db.QueryRow(`SELECT synced_at FROM sync_state WHERE scope = ?`, scope).Scan(&millis)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(millis), nil
+}
+
+func insertTask(tx *sql.Tx, key string, position int, task client.Task) error {
+	reminders, err := json.Marshal(nonNil(task.Reminders))
+	if err != nil {
+		return err
+	}
+	tags, err := json.Marshal(nonNil(task.Tags))
+	if err != nil {
+		return err
+	}
+	// 任务可能在两次同步之间移动到了其他项目，先删除旧记录
+	if _, err := tx.Exec(`DELETE FROM tasks WHERE id = ?`, task.ID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO tasks (id, project_key, project_id, title, content, description, is_all_day,
+			start_date, due_date, time_zone, reminders, repeat_flag, priority, status, completed_time,
+			sort_order, column_id, tags, position)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		task.ID, key, task.ProjectID, task.Title, task.Content, task.Desc, task.IsAllDay,
+		task.StartDate.String(), task.DueDate.String(), task.TimeZone, string(reminders), task.RepeatFlag,
+		task.Priority, task.Status, task.CompletedTime.String(), task.SortOrder, task.ColumnID, string(tags), position)
+	if err != nil {
+		return err
+	}
+	for i, item := range task.Items {
+		_, err := tx.Exec(`INSERT INTO subtasks (task_id, id, title, status, sort_order, start_date, is_all_day,
+				time_zone, completed_time, position)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			task.ID, item.ID, item.Title, item.Status, item.SortOrder, item.StartDate.String(), item.IsAllDay,
+			item.TimeZone, item.CompletedTime.String(), i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryTasks 按条件查询任务并附带子任务，where 以 WHERE 开头
+func (m *Mirror) queryTasks(where string, args ...any) ([]client.Task, error) {
+	rows, err := m.db.Query(`SELECT id, project_id, title, content, description, is_all_day, start_date, due_date,
+		time_zone, reminders, repeat_flag, priority, status, completed_time, sort_order, column_id, tags
+		FROM tasks `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []client.Task
+	index := make(map[string]int)
+	for rows.Next() {
+		var (
+			task                  client.Task
+			start, due, completed string
+			reminders, tags       string
+		)
+		err := rows.Scan(&task.ID, &task.ProjectID, &task.Title, &task.Content, &task.Desc, &task.IsAllDay,
+			&start, &due, &task.TimeZone, &reminders, &task.RepeatFlag, &task.Priority, &task.Status,
+			&completed, &task.SortOrder, &task.ColumnID, &tags)
+		if err != nil {
+			return nil, err
+		}
+		if task.StartDate, err = parseTime(start); err != nil {
+			return nil, err
+		}
+		if task.DueDate, err = parseTime(due); err != nil {
+			return nil, err
+		}
+		if task.CompletedTime, err = parseTime(completed); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(reminders), &task.Reminders); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(tags), &task.Tags); err != nil {
+			return nil, err
+		}
+		// 与 API 返回保持一致：没有提醒和标签时为 nil
+		if len(task.Reminders) == 0 {
+			task.Reminders = nil
+		}
+		if len(task.Tags) == 0 {
+			task.Tags = nil
+		}
+		index[task.ID] = len(tasks)
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(tasks) == 0 {
+		return tasks, nil
+	}
+	if err := m.attachSubtasks(tasks, index, where, args...); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// attachSubtasks 为任务填充子任务，index 为任务 ID 到下标的映射，where 与查询任务时相同
+func (m *Mirror) attachSubtasks(tasks []client.Task, index map[string]int, where string, args ...any) error {
+	rows, err := m.db.Query(`SELECT task_id, id, title, status, sort_order, start_date, is_all_day, time_zone, completed_time
+		FROM subtasks WHERE task_id IN (SELECT id FROM tasks `+where+`) ORDER BY task_id, position`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			taskID, start, completed string
+			item                     client.TaskItem
+		)
+		err := rows.Scan(&taskID, &item.ID, &item.Title, &item.Status, &item.SortOrder, &start, &item.IsAllDay,
+			&item.TimeZone, &completed)
+		if err != nil {
+			return err
+		}
+		i, ok := index[taskID]
+		if !ok {
+			continue
+		}
+		if item.StartDate, err = parseTime(start); err != nil {
+			return err
+		}
+		if item.CompletedTime, err = parseTime(completed); err != nil {
+			return err
+		}
+		tasks[i].Items = append(tasks[i].Items, item)
+	}
+	return rows.Err()
+}
+
+func parseTime(value string) (client.Time, error) {
+	if value == "" {
+		return client.Time{}, nil
+	}
+	t, err := client.ParseDateTime(value)
+	if err != nil {
+		return client.Time{}, err
+	}
+	return client.NewTime(t), nil
+}
+
+// nonNil 将 nil 切片转换为空切片，使其序列化为 []
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
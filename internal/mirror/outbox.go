@@ -0,0 +1,199 @@
+package mirror
+
+import (
+	"dida/internal/client"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 离线队列条目的状态
+const (
+	OutboxPending  = "pending"  // 等待重放
+	OutboxDone     = "done"     // 已成功应用
+	OutboxConflict = "conflict" // 服务器上的任务在排队期间被修改，未应用
+	OutboxFailed   = "failed"   // 服务器拒绝了请求，不再重试
+)
+
+// OutboxEntry 离线队列中的一次修改操作
+type OutboxEntry struct {
+	ID int64
+	// Key 幂等键，同一个键只会排队一次
+	Key       string
+	Operation string
+	ProjectID string
+	TaskID    string
+	// Args 工具参数，日期已转换为绝对时间
+	Args map[string]any
+	// Base 排队时本地镜像中的任务副本，用于重放前检测冲突，没有副本时为 nil
+	Base      *client.Task
+	Status    string
+	Attempts  int
+	LastError string
+	// Result 应用成功或失败后的结果说明
+	Result string
+	// SentAt 排队前直接发送请求的时间，请求可能已经到达服务器；排队前未发送时为零值
+	SentAt    time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+const outboxColumns = `id, idempotency_key, operation, project_id, task_id, args, base, status,
+	attempts, last_error, result, sent_at, created_at, updated_at`
+
+// Enqueue 将操作加入队列；幂等键已存在时不重复加入，用已有条目填充 entry 并返回 true
+// entry.Status 为空时条目为待重放，也可以直接以 OutboxDone 记录已应用的操作
+func (m *Mirror) Enqueue(entry *OutboxEntry) (bool, error) {
+	existing, err := m.OutboxByKey(entry.Key)
+	if err == nil {
+		*entry = *existing
+		return true, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+
+	args, err := json.Marshal(entry.Args)
+	if err != nil {
+		return false, err
+	}
+	var base []byte
+	if entry.Base != nil {
+		if base, err = json.Marshal(entry.Base); err != nil {
+			return false, err
+		}
+	}
+	now := time.Now()
+	if entry.Status == "" {
+		entry.Status = OutboxPending
+	}
+	var sentAt int64
+	if !entry.SentAt.IsZero() {
+		sentAt = entry.SentAt.UnixMilli()
+	}
+	entry.CreatedAt, entry.UpdatedAt = now, now
+	res, err := m.db.Exec(`INSERT INTO outbox (idempotency_key, operation, project_id, task_id, args, base, status,
+			attempts, last_error, result, sent_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Key, entry.Operation, entry.ProjectID, entry.TaskID, string(args), string(base), entry.Status,
+		entry.Attempts, entry.LastError, entry.Result, sentAt, now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	entry.ID, err = res.LastInsertId()
+	return false, err
+}
+
+// OutboxByKey 按幂等键查找条目，不存在时返回 ErrNotFound
+func (m *Mirror) OutboxByKey(key string) (*OutboxEntry, error) {
+	entries, err := m.queryOutbox(`WHERE idempotency_key = ?`, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+	return &entries[0], nil
+}
+
+// OutboxByID 按 ID 查找条目，不存在时返回 ErrNotFound
+func (m *Mirror) OutboxByID(id int64) (*OutboxEntry, error) {
+	entries, err := m.queryOutbox(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+	return &entries[0], nil
+}
+
+// ListOutbox 按排队顺序列出指定状态的条目，不指定状态时列出全部
+func (m *Mirror) ListOutbox(statuses ...string) ([]OutboxEntry, error) {
+	if len(statuses) == 0 {
+		return m.queryOutbox(`ORDER BY id`)
+	}
+	args := make([]any, len(statuses))
+	for i, status := range statuses {
+		args[i] = status
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	return m.queryOutbox(`WHERE status IN (`+placeholders+`) ORDER BY id`, args...)
+}
+
+// UpdateOutbox 保存条目的状态、重试次数、错误和结果
+func (m *Mirror) UpdateOutbox(entry *OutboxEntry) error {
+	entry.UpdatedAt = time.Now()
+	_, err := m.db.Exec(`UPDATE outbox SET status = ?, attempts = ?, last_error = ?, result = ?, updated_at = ? WHERE id = ?`,
+		entry.Status, entry.Attempts, entry.LastError, entry.Result, entry.UpdatedAt.UnixMilli(), entry.ID)
+	return err
+}
+
+// DeleteOutbox 删除条目，不存在时返回 ErrNotFound
+func (m *Mirror) DeleteOutbox(id int64) error {
+	res, err := m.db.Exec(`DELETE FROM outbox WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+// PruneOutbox 删除 before 之前完成的条目；未完成、冲突和失败的条目保留到被处理
+func (m *Mirror) PruneOutbox(before time.Time) error {
+	_, err := m.db.Exec(`DELETE FROM outbox WHERE status = ? AND updated_at < ?`, OutboxDone, before.UnixMilli())
+	return err
+}
+
+func (m *Mirror) queryOutbox(where string, args ...any) ([]OutboxEntry, error) {
+	rows, err := m.db.Query(`SELECT `+outboxColumns+` FROM outbox `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []OutboxEntry
+	for rows.Next() {
+		var (
+			e                            OutboxEntry
+			rawArgs, rawBase             string
+			sentAt, createdAt, updatedAt int64
+		)
+		err := rows.Scan(&e.ID, &e.Key, &e.Operation, &e.ProjectID, &e.TaskID, &rawArgs, &rawBase, &e.Status,
+			&e.Attempts, &e.LastError, &e.Result, &sentAt, &createdAt, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(rawArgs), &e.Args); err != nil {
+			return nil, fmt.Errorf("outbox entry %d: %v", e.ID, err)
+		}
+		if rawBase != "" {
+			e.Base = &client.Task{}
+			if err := json.Unmarshal([]byte(rawBase), e.Base); err != nil {
+				return nil, fmt.Errorf("outbox entry %d: %v", e.ID, err)
+			}
+		}
+		if sentAt != 0 {
+			e.SentAt = time.UnixMilli(sentAt)
+		}
+		e.CreatedAt, e.UpdatedAt = time.UnixMilli(createdAt), time.UnixMilli(updatedAt)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// CachedTask 返回镜像中的任务副本，不论是否过期；不存在时返回 ErrNotFound
+func (m *Mirror) CachedTask(projectID, taskID string) (*client.Task, error) {
+	tasks, err := m.queryTasks(`WHERE project_key = ? AND id = ?`, ProjectKey(projectID), taskID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, ErrNotFound
+	}
+	return &tasks[0], nil
+}
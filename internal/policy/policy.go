@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"path"
+	"strings"
+
+	"dida/internal/config"
+)
+
+// Policy 决定哪些工具可以注册、哪些项目可以被操作
+type Policy struct {
+	readOnly   bool
+	allowTools []string
+	denyTools  []string
+	projects   map[string]struct{}
+}
+
+// New 根据配置创建访问策略
+func New(cfg config.PolicyConfig) *Policy {
+	p := &Policy{
+		readOnly:   cfg.ReadOnly,
+		allowTools: cfg.AllowTools,
+		denyTools:  cfg.DenyTools,
+	}
+	if len(cfg.AllowedProjects) > 0 {
+		p.projects = make(map[string]struct{}, len(cfg.AllowedProjects))
+		for _, id := range cfg.AllowedProjects {
+			p.projects[id] = struct{}{}
+		}
+	}
+	return p
+}
+
+// ReadOnly 是否处于只读模式
+func (p *Policy) ReadOnly() bool {
+	return p.readOnly
+}
+
+// AllowTool 判断工具是否允许注册
+// readOnly 表示该工具本身不会修改任何数据
+func (p *Policy) AllowTool(name string, readOnly bool) bool {
+	if p.readOnly && !readOnly {
+		return false
+	}
+	// 拒绝列表优先
+	if matchAny(p.denyTools, name) {
+		return false
+	}
+	if len(p.allowTools) > 0 && !matchAny(p.allowTools, name) {
+		return false
+	}
+	return true
+}
+
+// RestrictsProjects 是否配置了项目白名单
+func (p *Policy) RestrictsProjects() bool {
+	return len(p.projects) > 0
+}
+
+// AllowProject 判断项目是否允许被操作
+func (p *Policy) AllowProject(projectID string) bool {
+	if !p.RestrictsProjects() {
+		return true
+	}
+	_, ok := p.projects[projectID]
+	return ok
+}
+
+// matchAny 判断名称是否匹配任一 glob 模式
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if strings.EqualFold(pattern, name) {
+			return true
+		}
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
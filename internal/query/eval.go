@@ -0,0 +1,315 @@
+package query
+
+import (
+	"dida/internal/client"
+	"dida/internal/dateparse"
+	"dida/internal/tags"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// subject 求值对象：任务及其所属项目名称
+type subject struct {
+	task        client.Task
+	projectName string
+}
+
+// node 查询语法树节点
+type node interface {
+	match(s *subject) bool
+	String() string
+}
+
+// Match 判断任务是否满足查询条件，projectName 用于 project: 条件
+func (q *Query) Match(task client.Task, projectName string) bool {
+	if q.root == nil {
+		return true
+	}
+	return q.root.match(&subject{task: task, projectName: projectName})
+}
+
+// Apply 过滤、排序并截断任务列表，projectNames 为项目 ID 到名称的映射
+func (q *Query) Apply(tasks []client.Task, projectNames map[string]string) []client.Task {
+	var matched []client.Task
+	for _, task := range tasks {
+		if q.Match(task, projectNames[task.ProjectID]) {
+			matched = append(matched, task)
+		}
+	}
+	Sort(matched, q.Sort)
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched
+}
+
+type andNode []node
+
+func (n andNode) match(s *subject) bool {
+	for _, child := range n {
+		if !child.match(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n andNode) String() string { return joinNodes(n, " AND ") }
+
+type orNode []node
+
+func (n orNode) match(s *subject) bool {
+	for _, child := range n {
+		if child.match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n orNode) String() string { return joinNodes(n, " OR ") }
+
+type notNode struct{ inner node }
+
+func (n notNode) match(s *subject) bool { return !n.inner.match(s) }
+
+func (n notNode) String() string { return "NOT " + n.inner.String() }
+
+func joinNodes(nodes []node, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, child := range nodes {
+		parts[i] = child.String()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// condition 单个条件，desc 用于 String
+type condition struct {
+	desc string
+	fn   func(s *subject) bool
+}
+
+func (c condition) match(s *subject) bool { return c.fn(s) }
+
+func (c condition) String() string { return c.desc }
+
+// newCondition 根据字段名和值构造条件，key 为空表示全文匹配
+func newCondition(key, value string, now time.Time) (node, error) {
+	desc := key + ":" + value
+	if strings.ContainsAny(value, " ()") {
+		desc = fmt.Sprintf("%s:%q", key, value)
+	}
+	if value == "" {
+		return nil, fmt.Errorf("missing value for %s:", key)
+	}
+
+	switch key {
+	case "":
+		text := strings.ToLower(value)
+		return condition{fmt.Sprintf("%q", value), func(s *subject) bool {
+			return containsText(s.task, text)
+		}}, nil
+	case "title":
+		text := strings.ToLower(value)
+		return condition{desc, func(s *subject) bool {
+			return strings.Contains(strings.ToLower(s.task.Title), text)
+		}}, nil
+	case "content":
+		text := strings.ToLower(value)
+		return condition{desc, func(s *subject) bool {
+			return strings.Contains(strings.ToLower(s.task.Content), text) ||
+				strings.Contains(strings.ToLower(s.task.Desc), text)
+		}}, nil
+	case "priority":
+		return priorityCondition(desc, value)
+	case "due", "start":
+		return dateCondition(desc, key, value, now)
+	case "project":
+		return condition{desc, func(s *subject) bool {
+			return s.task.ProjectID == value || strings.EqualFold(s.projectName, value)
+		}}, nil
+	case "tag":
+		return condition{desc, func(s *subject) bool {
+			return tags.Contains(s.task.Tags, value)
+		}}, nil
+	case "status":
+		var completed bool
+		switch strings.ToLower(value) {
+		case "open", "active", "todo":
+		case "completed", "done":
+			completed = true
+		default:
+			return nil, fmt.Errorf("invalid status %q: use open or completed", value)
+		}
+		return condition{desc, func(s *subject) bool {
+			return (s.task.Status == client.TaskStatusCompleted) == completed
+		}}, nil
+	case "has":
+		return hasCondition(desc, value)
+	case "repeat":
+		yes, err := parseYesNo(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repeat %q: %v", value, err)
+		}
+		return condition{desc, func(s *subject) bool {
+			return (s.task.RepeatFlag != "") == yes
+		}}, nil
+	}
+	return nil, fmt.Errorf("unknown field %q", key)
+}
+
+// containsText 判断标题、内容或子任务标题中是否包含文本（text 已转为小写）
+func containsText(task client.Task, text string) bool {
+	if strings.Contains(strings.ToLower(task.Title), text) ||
+		strings.Contains(strings.ToLower(task.Content), text) ||
+		strings.Contains(strings.ToLower(task.Desc), text) {
+		return true
+	}
+	for _, item := range task.Items {
+		if strings.Contains(strings.ToLower(item.Title), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// priorityLevels 优先级名称与数值的对应关系
+var priorityLevels = map[string]int{
+	"none": 0, "low": 1, "medium": 3, "med": 3, "high": 5,
+	"0": 0, "1": 1, "3": 3, "5": 5,
+}
+
+func priorityCondition(desc, value string) (node, error) {
+	op, rest := splitOperator(value)
+	level, ok := priorityLevels[strings.ToLower(rest)]
+	if !ok {
+		return nil, fmt.Errorf("invalid priority %q: use none, low, medium or high", rest)
+	}
+	return condition{desc, func(s *subject) bool {
+		return compareInt(s.task.Priority, level, op)
+	}}, nil
+}
+
+func dateCondition(desc, field, value string, now time.Time) (node, error) {
+	get := func(task client.Task) client.Time {
+		if field == "start" {
+			return task.StartDate
+		}
+		return task.DueDate
+	}
+	today := civilDate(now)
+
+	switch strings.ToLower(value) {
+	case "none":
+		return condition{desc, func(s *subject) bool { return get(s.task).IsZero() }}, nil
+	case "any":
+		return condition{desc, func(s *subject) bool { return !get(s.task).IsZero() }}, nil
+	case "overdue":
+		return condition{desc, func(s *subject) bool {
+			t := get(s.task)
+			if t.IsZero() || s.task.Status == client.TaskStatusCompleted {
+				return false
+			}
+			if s.task.IsAllDay {
+				return civilDate(t.InZone(s.task, now.Location())).Before(today)
+			}
+			return t.Before(now)
+		}}, nil
+	}
+
+	op, rest := splitOperator(value)
+	result, err := dateparse.Parse(rest, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s date: %v", field, err)
+	}
+	target := result.Time
+	return condition{desc, func(s *subject) bool {
+		t := get(s.task)
+		if t.IsZero() {
+			return false
+		}
+		local := t.InZone(s.task, now.Location())
+		// 只给出日期或比较相等时按自然日比较，否则按精确时间比较
+		if result.AllDay || op == "=" || op == "!=" {
+			return compareInt(dayNumber(civilDate(local)), dayNumber(civilDate(target)), op)
+		}
+		return compareInt(local.Compare(target), 0, op)
+	}}, nil
+}
+
+func hasCondition(desc, value string) (node, error) {
+	var fn func(task client.Task) bool
+	switch strings.ToLower(value) {
+	case "subtasks", "subtask", "items", "checklist":
+		fn = func(task client.Task) bool { return len(task.Items) > 0 }
+	case "reminders", "reminder":
+		fn = func(task client.Task) bool { return len(task.Reminders) > 0 }
+	case "repeat", "recurrence":
+		fn = func(task client.Task) bool { return task.RepeatFlag != "" }
+	case "due":
+		fn = func(task client.Task) bool { return !task.DueDate.IsZero() }
+	case "start":
+		fn = func(task client.Task) bool { return !task.StartDate.IsZero() }
+	case "date":
+		fn = func(task client.Task) bool { return !task.DueDate.IsZero() || !task.StartDate.IsZero() }
+	case "content", "notes":
+		fn = func(task client.Task) bool { return task.Content != "" || task.Desc != "" }
+	case "tags", "tag":
+		fn = func(task client.Task) bool { return len(task.Tags) > 0 }
+	default:
+		return nil, fmt.Errorf("invalid has:%s: use subtasks, reminders, repeat, due, start, date, content or tags", value)
+	}
+	return condition{desc, func(s *subject) bool { return fn(s.task) }}, nil
+}
+
+// splitOperator 拆分值前面的比较运算符，默认为 =
+func splitOperator(value string) (string, string) {
+	for _, op := range []string{"<=", ">=", "!=", "<", ">", "="} {
+		if strings.HasPrefix(value, op) {
+			return op, strings.TrimSpace(value[len(op):])
+		}
+	}
+	return "=", value
+}
+
+func compareInt(a, b int, op string) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "!=":
+		return a != b
+	default:
+		return a == b
+	}
+}
+
+func parseYesNo(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "y", "true":
+		return true, nil
+	case "no", "n", "false":
+		return false, nil
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b, nil
+	}
+	return false, fmt.Errorf("use yes or no")
+}
+
+// civilDate 取时间的年月日，以 UTC 零点表示
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dayNumber 将自然日转换为可比较的天数
+func dayNumber(day time.Time) int {
+	return int(day.Unix() / 86400)
+}
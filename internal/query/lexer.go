@@ -0,0 +1,123 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenWord   tokenKind = iota // 普通词或 key:value
+	tokenLParen                  // (
+	tokenRParen                  // )
+	tokenAnd                     // AND
+	tokenOr                      // OR
+	tokenNot                     // NOT 或 - 前缀
+)
+
+// token 词法单元
+// 对 key:value 形式，Key 为小写的键，Value 为值；普通词的 Key 为空
+type token struct {
+	kind  tokenKind
+	key   string
+	value string
+	pos   int
+}
+
+// lex 将查询字符串拆分为词法单元
+// 支持双引号短语（"weekly report"、due:<"next friday"）、括号、AND/OR/NOT 以及 -term 取反
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, pos: i})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')':
+			tokens = append(tokens, token{kind: tokenNot, pos: i})
+			i++
+		default:
+			start := i
+			word, quoted, next, err := readWord(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			i = next
+			if quoted && runes[start] == '"' {
+				// 以引号开头的整体作为普通文本
+				tokens = append(tokens, token{kind: tokenWord, value: word, pos: start})
+				continue
+			}
+			tokens = append(tokens, classify(word, start))
+		}
+	}
+	return tokens, nil
+}
+
+// readWord 读取一个词，词中的双引号部分可以包含空格和括号
+func readWord(runes []rune, i int) (word string, quoted bool, next int, err error) {
+	var b strings.Builder
+	start := i
+	for i < len(runes) {
+		r := runes[i]
+		if r == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end >= len(runes) {
+				return "", false, 0, fmt.Errorf("unterminated quote at position %d", i+1)
+			}
+			quoted = true
+			b.WriteString(string(runes[i+1 : end]))
+			i = end + 1
+			continue
+		}
+		if unicode.IsSpace(r) || r == '(' || r == ')' {
+			break
+		}
+		b.WriteRune(r)
+		i++
+	}
+	if i == start {
+		return "", false, 0, fmt.Errorf("unexpected character %q at position %d", runes[i], i+1)
+	}
+	return b.String(), quoted, i, nil
+}
+
+// classify 识别关键字和 key:value
+func classify(word string, pos int) token {
+	switch word {
+	case "AND", "and", "&&":
+		return token{kind: tokenAnd, pos: pos}
+	case "OR", "or", "||":
+		return token{kind: tokenOr, pos: pos}
+	case "NOT", "not":
+		return token{kind: tokenNot, pos: pos}
+	}
+	if key, value, ok := strings.Cut(word, ":"); ok && key != "" && isKey(key) {
+		return token{kind: tokenWord, key: strings.ToLower(key), value: value, pos: pos}
+	}
+	return token{kind: tokenWord, value: word, pos: pos}
+}
+
+// isKey 判断冒号前的部分是否像一个字段名（仅字母），避免把 "10:30" 之类的文本当成字段
+func isKey(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
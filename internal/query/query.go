@@ -0,0 +1,219 @@
+// Package query 实现任务搜索的查询语言
+//
+// 查询由若干条件组成，条件之间默认为 AND，可使用 AND/OR/NOT、-取反和括号：
+//
+//	report priority:high due:<2026-11-01 (project:Work OR project:Home) -status:completed
+//
+// 支持的条件见 Parse 的说明。解析结果 Query 可以对任意 client.Task 求值，
+// 供 MCP 工具和命令行共用。
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLimit 未指定 limit 时返回的最大任务数
+const defaultLimit = 50
+
+// Query 解析后的查询
+type Query struct {
+	root  node
+	Sort  []SortKey
+	Limit int
+}
+
+// Parse 解析查询字符串，日期条件以 now 为基准并使用 now 的时区
+//
+// 支持的条件：
+//   - 文本：report、"weekly report"，匹配标题、内容和子任务标题（不区分大小写）
+//   - title:xxx、content:xxx：只匹配标题或内容
+//   - priority:high，也支持 none/low/medium/high、0/1/3/5 以及比较 priority:>=medium
+//   - due:<2026-11-01、due:today、due:<="next friday"、due:none、due:any、due:overdue；start: 同理
+//   - project:Work：项目名称（不区分大小写）或项目 ID
+//   - tag:urgent 或 tag:#urgent：带有该标签
+//   - status:open / status:completed
+//   - has:subtasks、has:reminders、has:repeat、has:due、has:start、has:content、has:tags
+//   - repeat:yes / repeat:no
+//   - sort:due、sort:-priority（- 表示倒序，多个字段用逗号分隔）；limit:20
+//
+// 空查询匹配所有任务。
+func Parse(input string, now time.Time) (*Query, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query{Limit: defaultLimit}
+	filtered := tokens[:0]
+	for _, tok := range tokens {
+		switch {
+		case tok.kind == tokenWord && tok.key == "sort":
+			keys, err := ParseSort(tok.value)
+			if err != nil {
+				return nil, err
+			}
+			q.Sort = keys
+		case tok.kind == tokenWord && tok.key == "limit":
+			limit, err := strconv.Atoi(tok.value)
+			if err != nil || limit < 1 {
+				return nil, fmt.Errorf("invalid limit %q: must be a positive number", tok.value)
+			}
+			q.Limit = limit
+		default:
+			filtered = append(filtered, tok)
+		}
+	}
+
+	p := &parser{tokens: filtered, now: now}
+	if len(filtered) > 0 {
+		root, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.tokens) {
+			return nil, fmt.Errorf("unexpected %s at position %d", p.describe(p.tokens[p.pos]), p.tokens[p.pos].pos+1)
+		}
+		q.root = root
+	}
+	return q, nil
+}
+
+// parser 递归下降解析器
+//
+//	or    := and ("OR" and)*
+//	and   := unary (["AND"] unary)*
+//	unary := ("NOT" | "-") unary | "(" or ")" | term
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []node{left}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokenOr {
+			break
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return orNode(nodes), nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []node{left}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokenOr || tok.kind == tokenRParen {
+			break
+		}
+		if tok.kind == tokenAnd {
+			p.pos++
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return andNode(nodes), nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+	p.pos++
+
+	switch tok.kind {
+	case tokenNot:
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	case tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokenRParen {
+			return nil, fmt.Errorf("missing ')' for '(' at position %d", tok.pos+1)
+		}
+		p.pos++
+		return inner, nil
+	case tokenWord:
+		return newCondition(tok.key, tok.value, p.now)
+	default:
+		return nil, fmt.Errorf("unexpected %s at position %d", p.describe(tok), tok.pos+1)
+	}
+}
+
+// describe 描述词法单元，用于错误信息
+func (p *parser) describe(tok token) string {
+	switch tok.kind {
+	case tokenLParen:
+		return "'('"
+	case tokenRParen:
+		return "')'"
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	}
+	if tok.key != "" {
+		return fmt.Sprintf("%q", tok.key+":"+tok.value)
+	}
+	return fmt.Sprintf("%q", tok.value)
+}
+
+// String 返回查询的规范化描述
+func (q *Query) String() string {
+	parts := make([]string, 0, 3)
+	if q.root != nil {
+		parts = append(parts, q.root.String())
+	}
+	if len(q.Sort) > 0 {
+		keys := make([]string, len(q.Sort))
+		for i, key := range q.Sort {
+			keys[i] = key.String()
+		}
+		parts = append(parts, "sort:"+strings.Join(keys, ","))
+	}
+	parts = append(parts, fmt.Sprintf("limit:%d", q.Limit))
+	return strings.Join(parts, " ")
+}
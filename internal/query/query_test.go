@@ -0,0 +1,163 @@
+package query
+
+import (
+	"dida/internal/client"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 基准时间：2026-10-14 周三 10:00（UTC+8）
+var (
+	testLoc = time.FixedZone("CST", 8*3600)
+	testNow = time.Date(2026, 10, 14, 10, 0, 0, 0, testLoc)
+)
+
+func at(day, hour int) client.Time {
+	return client.NewTime(time.Date(2026, 10, day, hour, 0, 0, 0, testLoc))
+}
+
+// testTasks 查询测试使用的任务，ID 为单个字母
+var testTasks = []client.Task{
+	{ID: "a", ProjectID: "p1", Title: "Weekly report", Priority: 5, DueDate: at(13, 18), Tags: []string{"work"}},
+	{ID: "b", ProjectID: "p1", Title: "Buy milk", Priority: 1, DueDate: at(14, 0), IsAllDay: true,
+		Items: []client.TaskItem{{Title: "oat milk"}}},
+	{ID: "c", ProjectID: "p2", Title: "Gym", Priority: 3, StartDate: at(16, 7), DueDate: at(16, 8),
+		RepeatFlag: "RRULE:FREQ=WEEKLY;INTERVAL=1", Reminders: []string{"TRIGGER:PT0S"}},
+	{ID: "d", ProjectID: "p2", Title: "Call mom", Content: "about the trip", Tags: []string{"Family"}},
+	{ID: "e", ProjectID: "p1", Title: "Old report", Priority: 0, DueDate: at(1, 9), Status: client.TaskStatusCompleted},
+}
+
+var testProjects = map[string]string{"p1": "Work", "p2": "Home"}
+
+func TestQueryMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "abcde"},
+		{"report", "ae"},
+		{`"weekly report"`, "a"},
+		{"OAT", "b"},
+		{"title:milk", "b"},
+		{"content:trip", "d"},
+		{"priority:high", "a"},
+		{"priority:>=medium", "ac"},
+		{"priority:<low", "de"},
+		{"priority:!=none", "abc"},
+		{"due:today", "b"},
+		{"due:<today", "ae"},
+		{"due:<=2026-10-14", "abe"},
+		{"due:overdue", "a"},
+		{"due:none", "d"},
+		{"start:any", "c"},
+		{`due:>"friday 7:30"`, "c"},
+		{"project:work", "abe"},
+		{"project:p2", "cd"},
+		{"tag:#family", "d"},
+		{"status:completed", "e"},
+		{"-status:completed", "abcd"},
+		{"has:subtasks", "b"},
+		{"has:reminders", "c"},
+		{"has:content", "d"},
+		{"repeat:yes", "c"},
+		{"report OR milk", "abe"},
+		{"project:Work AND NOT report", "b"},
+		{"(project:Home OR priority:high) -has:repeat", "ad"},
+		{"report status:open", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query, testNow)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			var got string
+			for _, task := range testTasks {
+				if q.Match(task, testProjects[task.ProjectID]) {
+					got += task.ID
+				}
+			}
+			if got != tt.want {
+				t.Errorf("%q matched %q, want %q (parsed as %s)", tt.query, got, tt.want, q)
+			}
+		})
+	}
+}
+
+func TestQueryApply(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// 没有日期的任务总是排在最后
+		{"sort:due", "eabcd"},
+		{"sort:-due", "cbaed"},
+		{"sort:-priority,title", "acbde"},
+		{"sort:title limit:2", "bd"},
+		{"status:open sort:due limit:1", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query, testNow)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			tasks := append([]client.Task(nil), testTasks...)
+			var got string
+			for _, task := range q.Apply(tasks, testProjects) {
+				got += task.ID
+			}
+			if got != tt.want {
+				t.Errorf("%q returned %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryString(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "limit:50"},
+		{"report priority:high", `("report" AND priority:high) limit:50`},
+		{"a OR b -c", `("a" OR ("b" AND NOT "c")) limit:50`},
+		{`title:"weekly report" sort:due,-priority limit:5`, `title:"weekly report" sort:due,-priority limit:5`},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query, testNow)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		if got := q.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"priority:urgent", "invalid priority"},
+		{"due:someday", "invalid due date"},
+		{"status:maybe", "invalid status"},
+		{"has:wings", "invalid has:wings"},
+		{"color:red", "unknown field"},
+		{"sort:size", "invalid sort field"},
+		{"limit:0", "invalid limit"},
+		{"(report", "missing ')'"},
+		{"report)", "unexpected ')'"},
+		{"report OR", "unexpected end"},
+		{"title:", "missing value"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query, testNow)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.query, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package query
+
+import (
+	"dida/internal/client"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortKey 排序字段，Desc 为 true 表示倒序
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// String 返回 due 或 -due 形式
+func (k SortKey) String() string {
+	if k.Desc {
+		return "-" + k.Field
+	}
+	return k.Field
+}
+
+// sortFields 支持的排序字段
+var sortFields = map[string]func(a, b client.Task) int{
+	"due":      func(a, b client.Task) int { return compareTime(a.DueDate, b.DueDate) },
+	"start":    func(a, b client.Task) int { return compareTime(a.StartDate, b.StartDate) },
+	"priority": func(a, b client.Task) int { return a.Priority - b.Priority },
+	"title": func(a, b client.Task) int {
+		return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	},
+}
+
+// ParseSort 解析逗号分隔的排序字段，如 "due,-priority"
+func ParseSort(value string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		key := SortKey{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := sortFields[key.Field]; !ok {
+			return nil, fmt.Errorf("invalid sort field %q: use due, start, priority or title", key.Field)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Sort 按排序字段对任务稳定排序；没有日期的任务总是排在最后
+func Sort(tasks []client.Task, keys []SortKey) {
+	if len(keys) == 0 {
+		return
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		for _, key := range keys {
+			a, b := tasks[i], tasks[j]
+			if missing := missingDate(key.Field, a, b); missing != 0 {
+				return missing < 0
+			}
+			c := sortFields[key.Field](a, b)
+			if key.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// missingDate 对日期字段，有日期的任务排在没有日期的任务之前
+func missingDate(field string, a, b client.Task) int {
+	var ta, tb client.Time
+	switch field {
+	case "due":
+		ta, tb = a.DueDate, b.DueDate
+	case "start":
+		ta, tb = a.StartDate, b.StartDate
+	default:
+		return 0
+	}
+	switch {
+	case ta.IsZero() == tb.IsZero():
+		return 0
+	case tb.IsZero():
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareTime(a, b client.Time) int {
+	return a.Compare(b.Time)
+}
@@ -0,0 +1,223 @@
+package recurrence
+
+import (
+	"sort"
+	"time"
+)
+
+// maxPeriods 计算重复时最多展开的周期数，防止规则永远匹配不到日期时死循环
+const maxPeriods = 5000
+
+// Next 返回不早于 after 的 n 次重复时间
+// start 为首次发生时间（即任务的开始或到期时间），结果保留 start 的时刻和时区；
+// 与 RFC 5545 的 DTSTART 相同，start 即使不满足规则也是第一次发生，并计入 COUNT
+func (r *Rule) Next(start, after time.Time, n int) []time.Time {
+	var result []time.Time
+	if n <= 0 {
+		return result
+	}
+
+	seen := 0
+	// add 处理依次发生的一次重复，返回 false 表示不再需要后续的重复
+	add := func(t time.Time) bool {
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		seen++
+		if r.Count > 0 && seen > r.Count {
+			return false
+		}
+		if !t.Before(after) {
+			result = append(result, t)
+		}
+		return len(result) < n
+	}
+
+	if !add(start) {
+		return result
+	}
+	for period := 0; period < maxPeriods; period++ {
+		for _, t := range r.candidates(start, period) {
+			if !t.After(start) {
+				continue
+			}
+			if !add(t) {
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// candidates 返回第 period 个周期内满足规则的全部日期（已排序）
+func (r *Rule) candidates(start time.Time, period int) []time.Time {
+	step := period * r.Interval
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		day := dateOf(start).AddDate(0, 0, step)
+		if r.matchesDay(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		// 以周一为一周的开始
+		offset := (int(start.Weekday()) + 6) % 7
+		weekStart := dateOf(start).AddDate(0, 0, -offset+7*step)
+		weekdays := r.ByDay
+		if len(weekdays) == 0 {
+			weekdays = []WeekdayNum{{Weekday: start.Weekday()}}
+		}
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			for _, wd := range weekdays {
+				if day.Weekday() == wd.Weekday && r.matchesMonth(day) {
+					days = append(days, day)
+				}
+			}
+		}
+	case Monthly:
+		month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location()).AddDate(0, step, 0)
+		if r.matchesMonth(month) {
+			days = r.expandMonth(month, start)
+		}
+	case Yearly:
+		year := start.Year() + step
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []int{int(start.Month())}
+		}
+		for _, m := range months {
+			month := time.Date(year, time.Month(m), 1, 0, 0, 0, 0, start.Location())
+			days = append(days, r.expandMonth(month, start)...)
+		}
+	}
+
+	result := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		result = append(result, time.Date(day.Year(), day.Month(), day.Day(),
+			start.Hour(), start.Minute(), start.Second(), 0, start.Location()))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	// BYDAY 中重复的星期会得到相同的日期
+	unique := result[:0]
+	for i, t := range result {
+		if i == 0 || !t.Equal(result[i-1]) {
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+// expandMonth 展开某月内满足 BYMONTHDAY / BYDAY 的日期
+// 两者同时给出时取交集（RFC 5545），如 BYDAY=FR;BYMONTHDAY=13 只匹配 13 号星期五
+func (r *Rule) expandMonth(month, start time.Time) []time.Time {
+	lastDay := month.AddDate(0, 1, -1).Day()
+	var days []time.Time
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		// 默认与首次发生的日期相同，该月没有这一天时跳过
+		if start.Day() <= lastDay {
+			days = append(days, month.AddDate(0, 0, start.Day()-1))
+		}
+		return days
+	}
+
+	var monthDays map[int]bool
+	if len(r.ByMonthDay) > 0 {
+		monthDays = make(map[int]bool)
+		for _, md := range r.ByMonthDay {
+			day := md
+			if md < 0 {
+				day = lastDay + md + 1
+			}
+			if day >= 1 && day <= lastDay {
+				monthDays[day] = true
+			}
+		}
+	}
+
+	if len(r.ByDay) == 0 {
+		for day := 1; day <= lastDay; day++ {
+			if monthDays[day] {
+				days = append(days, month.AddDate(0, 0, day-1))
+			}
+		}
+		return days
+	}
+
+	for _, wd := range r.ByDay {
+		var matches []time.Time
+		for d := 1; d <= lastDay; d++ {
+			day := month.AddDate(0, 0, d-1)
+			if day.Weekday() == wd.Weekday {
+				matches = append(matches, day)
+			}
+		}
+		switch {
+		case wd.N > 0 && wd.N <= len(matches):
+			matches = matches[wd.N-1 : wd.N]
+		case wd.N < 0 && -wd.N <= len(matches):
+			matches = matches[len(matches)+wd.N : len(matches)+wd.N+1]
+		case wd.N != 0:
+			matches = nil
+		}
+		for _, day := range matches {
+			if monthDays == nil || monthDays[day.Day()] {
+				days = append(days, day)
+			}
+		}
+	}
+	return days
+}
+
+// matchesDay 判断按天重复的日期是否满足 BYDAY / BYMONTHDAY / BYMONTH 过滤条件
+func (r *Rule) matchesDay(day time.Time) bool {
+	if !r.matchesMonth(day) {
+		return false
+	}
+	if len(r.ByDay) > 0 {
+		found := false
+		for _, wd := range r.ByDay {
+			if wd.Weekday == day.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.ByMonthDay) > 0 {
+		lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		found := false
+		for _, md := range r.ByMonthDay {
+			if md == day.Day() || (md < 0 && lastDay+md+1 == day.Day()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesMonth 判断日期是否满足 BYMONTH 过滤条件
+func (r *Rule) matchesMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 || r.Freq == Yearly {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if time.Month(m) == day.Month() {
+			return true
+		}
+	}
+	return false
+}
+
+// dateOf 返回当天零点
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
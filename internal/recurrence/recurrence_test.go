@@ -0,0 +1,126 @@
+package recurrence
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromText(t *testing.T) {
+	tests := []struct {
+		text     string
+		want     string
+		describe string
+	}{
+		{"daily", "RRULE:FREQ=DAILY;INTERVAL=1", "daily"},
+		{"every 3 days", "RRULE:FREQ=DAILY;INTERVAL=3", "every 3 days"},
+		{"every other week", "RRULE:FREQ=WEEKLY;INTERVAL=2", "every 2 weeks"},
+		{"every weekday", "RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TU,WE,TH,FR", "every weekday"},
+		{"Every Weekend", "RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=SA,SU", "every weekend"},
+		{"every 2 weeks on Mon,Thu", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "every 2 weeks on Mon, Thu"},
+		{"every monday and friday", "RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,FR", "weekly on Mon, Fri"},
+		{"monthly", "RRULE:FREQ=MONTHLY;INTERVAL=1", "monthly"},
+		{"monthly on the 15th", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15", "monthly on the 15th"},
+		{"every month on the last day", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=-1", "monthly on the last day"},
+		{"monthly on last Friday", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYDAY=-1FR", "monthly on the last Friday"},
+		{"monthly on the 2nd tuesday", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYDAY=2TU", "monthly on the 2nd Tuesday"},
+		{"every year on Mar 15", "RRULE:FREQ=YEARLY;INTERVAL=1;BYMONTHDAY=15;BYMONTH=3", "yearly on Mar 15"},
+		{"weekly for 5 times", "RRULE:FREQ=WEEKLY;INTERVAL=1;COUNT=5", "weekly, 5 times"},
+		{"daily until 2026-12-31", "RRULE:FREQ=DAILY;INTERVAL=1;UNTIL=20261231T235959Z", "daily, until 2026-12-31"},
+		// 原始 RRULE 原样接受，未识别的部分保留
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO;WKST=SU", "RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=MO;WKST=SU", "weekly on Mon"},
+		{"freq=monthly;bymonthday=1,-1", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=-1,1", "monthly on the last day, the 1st"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			rule, err := FromText(tt.text)
+			if err != nil {
+				t.Fatalf("FromText(%q): %v", tt.text, err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("FromText(%q) = %s, want %s", tt.text, got, tt.want)
+			}
+			if got := rule.Describe(); got != tt.describe {
+				t.Errorf("Describe() = %q, want %q", got, tt.describe)
+			}
+			// String 的输出可以再次解析为相同的规则
+			again, err := Parse(rule.String())
+			if err != nil || again.String() != rule.String() {
+				t.Errorf("Parse(%s) = %v, %v", rule.String(), again, err)
+			}
+		})
+	}
+}
+
+func TestFromTextRejects(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"sometimes",
+		"every 2 weeks on funday",
+		"daily on monday",
+		"monthly on the 32nd",
+		"every year on 15 March",
+		"RRULE:FREQ=HOURLY",
+		"RRULE:INTERVAL=2",
+		"RRULE:FREQ=DAILY;INTERVAL=0",
+		"RRULE:FREQ=DAILY;COUNT=3;UNTIL=20261231",
+		"RRULE:FREQ=WEEKLY;BYDAY=2MO",
+		"RRULE:FREQ=MONTHLY;BYMONTHDAY=0",
+		"RRULE:FREQ=MONTHLY;BYDAY=XX",
+	} {
+		if rule, err := FromText(text); err == nil {
+			t.Errorf("FromText(%q) = %s, want an error", text, rule)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	// 2026-01-31 周六 09:00
+	start := time.Date(2026, 1, 31, 9, 0, 0, 0, loc)
+	later := start.Add(time.Minute)
+	tests := []struct {
+		rule  string
+		after time.Time
+		n     int
+		want  []string
+	}{
+		{"RRULE:FREQ=DAILY;INTERVAL=2", start, 3, []string{"2026-01-31", "2026-02-02", "2026-02-04"}},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,WE", later, 3, []string{"2026-02-02", "2026-02-04", "2026-02-09"}},
+		// 没有 31 号的月份跳过
+		{"RRULE:FREQ=MONTHLY", start, 3, []string{"2026-01-31", "2026-03-31", "2026-05-31"}},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=-1", start, 3, []string{"2026-01-31", "2026-02-28", "2026-03-31"}},
+		{"RRULE:FREQ=MONTHLY;BYDAY=-1FR", later, 2, []string{"2026-02-27", "2026-03-27"}},
+		// BYDAY 与 BYMONTHDAY 取交集：13 号星期五
+		{"RRULE:FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", later, 3, []string{"2026-02-13", "2026-03-13", "2026-11-13"}},
+		{"RRULE:FREQ=MONTHLY;BYDAY=1MO,-1MO;BYMONTHDAY=1,2,3,4,5,6,7", later, 3, []string{"2026-02-02", "2026-03-02", "2026-04-06"}},
+		// BYDAY 中重复的星期不产生重复的日期
+		{"RRULE:FREQ=MONTHLY;BYDAY=FR,FR,-1FR", later, 5, []string{"2026-02-06", "2026-02-13", "2026-02-20", "2026-02-27", "2026-03-06"}},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,MO", later, 2, []string{"2026-02-02", "2026-02-09"}},
+		{"RRULE:FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", later, 2, []string{"2028-02-29", "2032-02-29"}},
+		{"RRULE:FREQ=DAILY;COUNT=3", start, 5, []string{"2026-01-31", "2026-02-01", "2026-02-02"}},
+		// COUNT 从 start 开始计数，after 之前的次数也算在内
+		{"RRULE:FREQ=DAILY;COUNT=3", start.AddDate(0, 0, 2), 5, []string{"2026-02-02"}},
+		// 不满足规则的 start 也是第一次发生，并计入 COUNT
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO;COUNT=3", start, 5, []string{"2026-01-31", "2026-02-02", "2026-02-09"}},
+		{"RRULE:FREQ=WEEKLY;UNTIL=20260214", start, 5, []string{"2026-01-31", "2026-02-07", "2026-02-14"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, occurrence := range rule.Next(start, tt.after, tt.n) {
+				if occurrence.Hour() != 9 || occurrence.Location() != loc {
+					t.Errorf("occurrence %v does not keep the start time of day", occurrence)
+				}
+				got = append(got, occurrence.Format("2006-01-02"))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
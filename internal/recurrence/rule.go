@@ -0,0 +1,233 @@
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rrulePrefix TickTick repeatFlag 使用的前缀
+const rrulePrefix = "RRULE:"
+
+// Frequency 重复频率
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// WeekdayNum 表示 BYDAY 中的一项，如 MO、2TU、-1FR
+// N 为 0 表示该月/该周内所有的这一天
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule 表示一条 RFC 5545 RRULE
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	Count      int
+	Until      time.Time
+
+	// extra 保存未解析的部分（如 WKST、TickTick 扩展的 TT_SKIP），输出时原样保留
+	extra []string
+}
+
+// weekdayCodes RRULE 中的星期缩写
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Parse 解析 RRULE 字符串，可带或不带 "RRULE:" 前缀
+func Parse(value string) (*Rule, error) {
+	text := strings.TrimSpace(value)
+	if len(text) >= len(rrulePrefix) && strings.EqualFold(text[:len(rrulePrefix)], rrulePrefix) {
+		text = text[len(rrulePrefix):]
+	}
+	if text == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(text, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		val = strings.ToUpper(strings.TrimSpace(val))
+
+		var err error
+		switch key {
+		case "FREQ":
+			rule.Freq = Frequency(val)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+		case "UNTIL":
+			rule.Until, err = parseUntil(val)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31)
+		case "BYMONTH":
+			rule.ByMonth, err = parseIntList(val, 1, 12)
+		default:
+			rule.extra = append(rule.extra, key+"="+val)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in rule %q: %v", key, value, err)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Validate 校验规则是否完整且受支持
+func (r *Rule) Validate() error {
+	switch r.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return fmt.Errorf("recurrence rule is missing FREQ")
+	default:
+		return fmt.Errorf("unsupported recurrence frequency %q", r.Freq)
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("recurrence INTERVAL must be positive")
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("recurrence COUNT must be positive")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("recurrence rule cannot have both COUNT and UNTIL")
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return fmt.Errorf("ordinal weekdays like %s are only valid for monthly or yearly rules", d)
+		}
+		if d.N < -5 || d.N > 5 {
+			return fmt.Errorf("weekday ordinal %d out of range", d.N)
+		}
+	}
+	for _, day := range r.ByMonthDay {
+		if day == 0 {
+			return fmt.Errorf("BYMONTHDAY cannot be 0")
+		}
+	}
+	return nil
+}
+
+// String 输出带 "RRULE:" 前缀的规则，即 TickTick repeatFlag 的格式
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq), "INTERVAL=" + strconv.Itoa(r.Interval)}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	parts = append(parts, r.extra...)
+	return rrulePrefix + strings.Join(parts, ";")
+}
+
+// String 输出 BYDAY 中的一项，如 MO、-1FR
+func (d WeekdayNum) String() string {
+	code := strings.ToUpper(d.Weekday.String()[:2])
+	if d.N == 0 {
+		return code
+	}
+	return strconv.Itoa(d.N) + code
+}
+
+// parseByDay 解析 BYDAY 列表
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		weekday, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			if n, err = strconv.Atoi(prefix); err != nil || n == 0 {
+				return nil, fmt.Errorf("invalid weekday ordinal %q", item)
+			}
+		}
+		days = append(days, WeekdayNum{N: n, Weekday: weekday})
+	}
+	return days, nil
+}
+
+// parseIntList 解析逗号分隔的整数列表并校验范围
+func parseIntList(value string, min, max int) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		if n < min || n > max {
+			return nil, fmt.Errorf("%d out of range [%d, %d]", n, min, max)
+		}
+		list = append(list, n)
+	}
+	sort.Ints(list)
+	return list, nil
+}
+
+// parseUntil 解析 UNTIL，支持日期和 UTC 日期时间两种形式
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// 只有日期时包含当天全天
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func joinInts(values []int) string {
+	items := make([]string, len(values))
+	for i, v := range values {
+		items[i] = strconv.Itoa(v)
+	}
+	return strings.Join(items, ",")
+}
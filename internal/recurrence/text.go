@@ -0,0 +1,340 @@
+package recurrence
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekdayNames 星期的英文写法
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// monthNames 月份的英文写法
+var monthNames = map[string]int{
+	"jan": 1, "january": 1, "feb": 2, "february": 2, "mar": 3, "march": 3,
+	"apr": 4, "april": 4, "may": 5, "jun": 6, "june": 6, "jul": 7, "july": 7,
+	"aug": 8, "august": 8, "sep": 9, "sept": 9, "september": 9, "oct": 10, "october": 10,
+	"nov": 11, "november": 11, "dec": 12, "december": 12,
+}
+
+// ordinalWords 序数词
+var ordinalWords = map[string]int{
+	"first": 1, "1st": 1, "second": 2, "2nd": 2, "third": 3, "3rd": 3,
+	"fourth": 4, "4th": 4, "fifth": 5, "5th": 5, "last": -1,
+}
+
+var (
+	// everyPattern 匹配 "every 2 weeks"、"every month"、"daily" 等频率写法
+	everyPattern = regexp.MustCompile(`^(?:every\s+(?:(\d+)\s+|other\s+)?(day|week|month|year)s?|(daily|weekly|monthly|yearly|annually))\b\s*(.*)$`)
+	// countPattern 匹配结尾的 "for 5 times" / "5 times"
+	countPattern = regexp.MustCompile(`\s*,?\s*(?:for\s+)?(\d+)\s+times?$`)
+	// untilPattern 匹配结尾的 "until 2026-12-31"
+	untilPattern = regexp.MustCompile(`\s*,?\s*until\s+(\d{4}-\d{2}-\d{2})$`)
+	// monthDayPattern 匹配 "the 15th" / "day 15"
+	monthDayPattern = regexp.MustCompile(`^(?:the\s+)?(?:day\s+)?(\d{1,2})(?:st|nd|rd|th)?$`)
+	// dateInYearPattern 匹配 "mar 15" / "march 15th"
+	dateInYearPattern = regexp.MustCompile(`^([a-z]+)\s+(\d{1,2})(?:st|nd|rd|th)?$`)
+)
+
+// FromText 将友好的重复描述转换为规则，也接受原始 RRULE
+//
+// 支持的写法示例：
+//   - "daily"、"every 3 days"、"every weekday"、"every weekend"
+//   - "weekly"、"every 2 weeks on Mon,Thu"、"every monday and friday"
+//   - "monthly"、"monthly on the 15th"、"monthly on last Friday"、"every month on the last day"
+//   - "yearly"、"every year on Mar 15"
+//   - 结尾可加 "for 5 times" 或 "until 2026-12-31"
+func FromText(text string) (*Rule, error) {
+	input := strings.TrimSpace(text)
+	if strings.HasPrefix(strings.ToUpper(input), rrulePrefix) || strings.HasPrefix(strings.ToUpper(input), "FREQ=") {
+		return Parse(input)
+	}
+
+	s := strings.ToLower(input)
+	rule := &Rule{Interval: 1}
+
+	// 先剥离结尾的次数/截止日期
+	if m := untilPattern.FindStringSubmatch(s); m != nil {
+		until, err := time.Parse("2006-01-02", m[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid until date %q", m[1])
+		}
+		rule.Until = until.Add(24*time.Hour - time.Second)
+		s = strings.TrimSpace(s[:len(s)-len(m[0])])
+	} else if m := countPattern.FindStringSubmatch(s); m != nil {
+		rule.Count, _ = strconv.Atoi(m[1])
+		s = strings.TrimSpace(s[:len(s)-len(m[0])])
+	}
+
+	if err := parseFrequency(rule, s); err != nil {
+		return nil, fmt.Errorf("%v; examples: \"every weekday\", \"every 2 weeks on Mon,Thu\", \"monthly on last Friday\"", err)
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// parseFrequency 解析频率及其后的 "on ..." 部分
+func parseFrequency(rule *Rule, s string) error {
+	switch s {
+	case "every weekday", "weekdays", "every workday":
+		rule.Freq = Weekly
+		rule.ByDay = weekdaysOf(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+		return nil
+	case "every weekend", "weekends":
+		rule.Freq = Weekly
+		rule.ByDay = weekdaysOf(time.Saturday, time.Sunday)
+		return nil
+	}
+
+	// "every monday and thursday"
+	if rest, ok := strings.CutPrefix(s, "every "); ok {
+		if days, err := parseWeekdayList(rest); err == nil {
+			rule.Freq = Weekly
+			rule.ByDay = days
+			return nil
+		}
+	}
+
+	m := everyPattern.FindStringSubmatch(s)
+	if m == nil {
+		return fmt.Errorf("unrecognized recurrence %q", s)
+	}
+	unit := m[2]
+	if unit == "" {
+		unit = map[string]string{"daily": "day", "weekly": "week", "monthly": "month", "yearly": "year", "annually": "year"}[m[3]]
+	}
+	if m[1] != "" {
+		rule.Interval, _ = strconv.Atoi(m[1])
+	} else if strings.Contains(s, "every other ") {
+		rule.Interval = 2
+	}
+
+	on := strings.TrimSpace(m[4])
+	if on != "" {
+		var ok bool
+		if on, ok = strings.CutPrefix(on, "on "); !ok {
+			return fmt.Errorf("unrecognized recurrence detail %q", m[4])
+		}
+	}
+
+	switch unit {
+	case "day":
+		rule.Freq = Daily
+		if on != "" {
+			return fmt.Errorf("daily recurrence does not take %q", on)
+		}
+	case "week":
+		rule.Freq = Weekly
+		if on != "" {
+			days, err := parseWeekdayList(on)
+			if err != nil {
+				return err
+			}
+			rule.ByDay = days
+		}
+	case "month":
+		rule.Freq = Monthly
+		if on != "" {
+			return parseMonthlyDetail(rule, on)
+		}
+	case "year":
+		rule.Freq = Yearly
+		if on != "" {
+			dm := dateInYearPattern.FindStringSubmatch(on)
+			if dm == nil {
+				return fmt.Errorf("unrecognized yearly date %q, expected e.g. \"Mar 15\"", on)
+			}
+			month, ok := monthNames[dm[1]]
+			if !ok {
+				return fmt.Errorf("unknown month %q", dm[1])
+			}
+			day, _ := strconv.Atoi(dm[2])
+			rule.ByMonth = []int{month}
+			rule.ByMonthDay = []int{day}
+		}
+	}
+	return nil
+}
+
+// parseMonthlyDetail 解析 "the 15th"、"the last day"、"last friday"、"the 2nd tuesday"
+func parseMonthlyDetail(rule *Rule, on string) error {
+	on = strings.TrimPrefix(on, "the ")
+	if on == "last day" {
+		rule.ByMonthDay = []int{-1}
+		return nil
+	}
+	if m := monthDayPattern.FindStringSubmatch(on); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		if day < 1 || day > 31 {
+			return fmt.Errorf("invalid day of month %d", day)
+		}
+		rule.ByMonthDay = []int{day}
+		return nil
+	}
+
+	fields := strings.Fields(on)
+	if len(fields) == 2 {
+		n, ok := ordinalWords[fields[0]]
+		weekday, ok2 := weekdayNames[fields[1]]
+		if ok && ok2 {
+			rule.ByDay = []WeekdayNum{{N: n, Weekday: weekday}}
+			return nil
+		}
+	}
+	return fmt.Errorf("unrecognized monthly detail %q, expected e.g. \"the 15th\" or \"last Friday\"", on)
+}
+
+// parseWeekdayList 解析 "mon,thu"、"monday and friday" 等星期列表
+func parseWeekdayList(s string) ([]WeekdayNum, error) {
+	s = strings.NewReplacer(" and ", ",", "&", ",", "/", ",").Replace(s)
+	var days []WeekdayNum
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		weekday, ok := weekdayNames[item]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", item)
+		}
+		days = append(days, WeekdayNum{Weekday: weekday})
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no weekdays given")
+	}
+	return days, nil
+}
+
+func weekdaysOf(weekdays ...time.Weekday) []WeekdayNum {
+	days := make([]WeekdayNum, len(weekdays))
+	for i, wd := range weekdays {
+		days[i] = WeekdayNum{Weekday: wd}
+	}
+	return days
+}
+
+// Describe 将规则描述为英文短语，如 "every 2 weeks on Mon, Thu"
+func (r *Rule) Describe() string {
+	var b strings.Builder
+
+	unit := map[Frequency]string{Daily: "day", Weekly: "week", Monthly: "month", Yearly: "year"}[r.Freq]
+	switch {
+	case r.Freq == Weekly && r.Interval == 1 && sameWeekdays(r.ByDay, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday):
+		b.WriteString("every weekday")
+	case r.Freq == Weekly && r.Interval == 1 && sameWeekdays(r.ByDay, time.Saturday, time.Sunday):
+		b.WriteString("every weekend")
+	default:
+		if r.Interval == 1 {
+			b.WriteString(map[Frequency]string{Daily: "daily", Weekly: "weekly", Monthly: "monthly", Yearly: "yearly"}[r.Freq])
+		} else {
+			fmt.Fprintf(&b, "every %d %ss", r.Interval, unit)
+		}
+		if detail := r.describeDetail(); detail != "" {
+			b.WriteString(" on " + detail)
+		}
+	}
+
+	if r.Count > 0 {
+		fmt.Fprintf(&b, ", %d times", r.Count)
+	}
+	if !r.Until.IsZero() {
+		fmt.Fprintf(&b, ", until %s", r.Until.Format("2006-01-02"))
+	}
+	return b.String()
+}
+
+// describeDetail 描述 BYDAY / BYMONTHDAY / BYMONTH 部分
+func (r *Rule) describeDetail() string {
+	var parts []string
+
+	if len(r.ByMonth) > 0 && r.Freq == Yearly && len(r.ByMonthDay) == 1 && len(r.ByDay) == 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = time.Month(m).String()[:3]
+		}
+		return fmt.Sprintf("%s %d", strings.Join(months, ", "), r.ByMonthDay[0])
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			name := d.Weekday.String()
+			if d.N == 0 {
+				days[i] = name[:3]
+			} else {
+				days[i] = "the " + ordinalName(d.N) + " " + name
+			}
+		}
+		parts = append(parts, strings.Join(days, ", "))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			if d == -1 {
+				days[i] = "the last day"
+			} else {
+				days[i] = "the " + ordinalName(d)
+			}
+		}
+		parts = append(parts, strings.Join(days, ", "))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = time.Month(m).String()[:3]
+		}
+		parts = append(parts, "in "+strings.Join(months, ", "))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ordinalName 将数字转换为序数词，如 1st、-1 -> last、-2 -> 2nd last
+func ordinalName(n int) string {
+	if n == -1 {
+		return "last"
+	}
+	if n < 0 {
+		return ordinalName(-n) + " last"
+	}
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// sameWeekdays 判断 BYDAY 是否恰好为给定的星期集合
+func sameWeekdays(days []WeekdayNum, weekdays ...time.Weekday) bool {
+	if len(days) != len(weekdays) {
+		return false
+	}
+	set := make(map[time.Weekday]bool, len(weekdays))
+	for _, wd := range weekdays {
+		set[wd] = true
+	}
+	for _, d := range days {
+		if d.N != 0 || !set[d.Weekday] {
+			return false
+		}
+	}
+	return true
+}
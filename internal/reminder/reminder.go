@@ -0,0 +1,237 @@
+package reminder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// triggerPrefix TickTick 提醒使用的 iCalendar TRIGGER 前缀
+const triggerPrefix = "TRIGGER:"
+
+const day = 24 * time.Hour
+
+var (
+	// offsetPattern 匹配 "15m before"、"2 hours after"、"1d before 9:00" 等写法
+	offsetPattern = regexp.MustCompile(`^(\d+)\s*([a-z]+)\s+(before|after)(?:\s+(?:at\s+)?(\d{1,2}):(\d{2}))?$`)
+	// dayTimePattern 匹配 "at 9:00"、"on day 9:00" 等当天某时刻的写法
+	dayTimePattern = regexp.MustCompile(`^(?:at|on day|on the day(?: at)?)\s+(\d{1,2}):(\d{2})$`)
+	// durationPattern 匹配 ISO 8601 时长，如 -P1DT15H0M0S、PT0S
+	durationPattern = regexp.MustCompile(`^(-)?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+)
+
+// unitDurations 偏移量单位
+var unitDurations = map[string]time.Duration{
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": day, "day": day, "days": day,
+	"w": 7 * day, "week": 7 * day, "weeks": 7 * day,
+}
+
+// Anchor 返回提醒的基准时刻：TickTick 的提醒相对于任务的开始时间，没有开始时间时相对于到期时间
+// 两者都为零值时返回零值
+func Anchor(start, due time.Time) time.Time {
+	if !start.IsZero() {
+		return start
+	}
+	return due
+}
+
+// ToTrigger 将友好的提醒写法转换为 TickTick 的 TRIGGER 格式
+// 偏移量都相对于基准时刻，见 Anchor
+//
+// 支持的写法：
+//   - "on time" / "at start" / "at due"：在基准时刻提醒
+//   - "15m before" / "2 hours after"：相对基准时刻的偏移
+//   - "1d before 9:00"：提前若干天的某个时刻（适用于全天任务）
+//   - "at 9:00"：基准日期当天的某个时刻（适用于全天任务）
+//   - "TRIGGER:-PT15M"：原样校验后返回
+func ToTrigger(input string) (string, error) {
+	text := strings.ToLower(strings.TrimSpace(input))
+	if text == "" {
+		return "", fmt.Errorf("empty reminder")
+	}
+
+	if strings.HasPrefix(text, strings.ToLower(triggerPrefix)) {
+		offset, err := ParseTrigger(input)
+		if err != nil {
+			return "", err
+		}
+		return FormatTrigger(offset), nil
+	}
+
+	switch text {
+	case "on time", "at start", "at due", "at due time", "due":
+		return FormatTrigger(0), nil
+	}
+
+	if m := dayTimePattern.FindStringSubmatch(text); m != nil {
+		clock, err := clockOffset(m[1], m[2])
+		if err != nil {
+			return "", err
+		}
+		return FormatTrigger(clock), nil
+	}
+
+	m := offsetPattern.FindStringSubmatch(text)
+	if m == nil {
+		return "", fmt.Errorf("unrecognized reminder %q, expected forms like \"15m before\", \"on time\" or \"1d before 9:00\"", input)
+	}
+	amount, _ := strconv.Atoi(m[1])
+	unit, ok := unitDurations[m[2]]
+	if !ok {
+		return "", fmt.Errorf("unknown time unit %q in reminder %q", m[2], input)
+	}
+	offset := time.Duration(amount) * unit
+	if m[3] == "before" {
+		offset = -offset
+	}
+	if m[4] != "" {
+		// 指定时刻时以天为单位偏移，再加上当天的时刻
+		if unit < day {
+			return "", fmt.Errorf("a time of day can only be combined with day or week offsets in %q", input)
+		}
+		clock, err := clockOffset(m[4], m[5])
+		if err != nil {
+			return "", err
+		}
+		offset += clock
+	}
+	return FormatTrigger(offset), nil
+}
+
+// ParseTrigger 解析 TRIGGER 字符串，返回相对基准时刻（见 Anchor）的偏移，负数表示提前
+func ParseTrigger(trigger string) (time.Duration, error) {
+	value := strings.TrimSpace(trigger)
+	if len(value) >= len(triggerPrefix) && strings.EqualFold(value[:len(triggerPrefix)], triggerPrefix) {
+		value = value[len(triggerPrefix):]
+	}
+	m := durationPattern.FindStringSubmatch(strings.ToUpper(value))
+	if m == nil || strings.Join(m[2:], "") == "" {
+		return 0, fmt.Errorf("invalid reminder trigger %q", trigger)
+	}
+
+	var offset time.Duration
+	units := []time.Duration{7 * day, day, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid reminder trigger %q: %v", trigger, err)
+		}
+		offset += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// FormatTrigger 将偏移量格式化为 TRIGGER 字符串
+func FormatTrigger(offset time.Duration) string {
+	if offset == 0 {
+		return triggerPrefix + "PT0S"
+	}
+
+	sign := ""
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	days := offset / day
+	offset -= days * day
+	hours := offset / time.Hour
+	offset -= hours * time.Hour
+	minutes := offset / time.Minute
+	offset -= minutes * time.Minute
+	seconds := offset / time.Second
+
+	var b strings.Builder
+	b.WriteString(triggerPrefix + sign + "P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 {
+		b.WriteString("T")
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+	return b.String()
+}
+
+// Describe 将 TRIGGER 字符串描述为友好的写法
+// 全天任务的基准时刻为当天 0 点，因此按 "1d before 9:00" 的形式描述
+func Describe(trigger string, allDay bool) string {
+	offset, err := ParseTrigger(trigger)
+	if err != nil {
+		return trigger
+	}
+	if offset == 0 {
+		return "on time"
+	}
+
+	if allDay {
+		// 拆分为整天偏移和当天时刻
+		days := offset / day
+		clock := offset - days*day
+		if clock < 0 {
+			days--
+			clock += day
+		}
+		hours := clock / time.Hour
+		minutes := (clock - hours*time.Hour) / time.Minute
+		switch {
+		case days == 0:
+			return fmt.Sprintf("at %d:%02d", hours, minutes)
+		case days < 0:
+			return fmt.Sprintf("%dd before %d:%02d", -days, hours, minutes)
+		default:
+			return fmt.Sprintf("%dd after %d:%02d", days, hours, minutes)
+		}
+	}
+
+	direction := "before"
+	if offset > 0 {
+		direction = "after"
+	} else {
+		offset = -offset
+	}
+	return fmt.Sprintf("%s %s", formatOffset(offset), direction)
+}
+
+// formatOffset 用最大的整除单位表示偏移量，如 15m、2h、1d、1w
+func formatOffset(offset time.Duration) string {
+	switch {
+	case offset%(7*day) == 0:
+		return fmt.Sprintf("%dw", offset/(7*day))
+	case offset%day == 0:
+		return fmt.Sprintf("%dd", offset/day)
+	case offset%time.Hour == 0:
+		return fmt.Sprintf("%dh", offset/time.Hour)
+	case offset%time.Minute == 0:
+		return fmt.Sprintf("%dm", offset/time.Minute)
+	default:
+		return offset.String()
+	}
+}
+
+// clockOffset 将 "9:00" 转换为当天零点起的偏移
+func clockOffset(hour, minute string) (time.Duration, error) {
+	h, _ := strconv.Atoi(hour)
+	m, _ := strconv.Atoi(minute)
+	if h > 23 || m > 59 {
+		return 0, fmt.Errorf("invalid time of day %s:%s", hour, minute)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
@@ -0,0 +1,135 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToTrigger(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"at due", "TRIGGER:PT0S"},
+		{"at start", "TRIGGER:PT0S"},
+		{"On Time", "TRIGGER:PT0S"},
+		{"15m before", "TRIGGER:-PT15M"},
+		{"2 hours after", "TRIGGER:PT2H"},
+		{"90 minutes before", "TRIGGER:-PT1H30M"},
+		{"1w before", "TRIGGER:-P7D"},
+		{"1d before 9:00", "TRIGGER:-PT15H"},
+		{"2 days before at 18:30", "TRIGGER:-P1DT5H30M"},
+		{"at 9:00", "TRIGGER:PT9H"},
+		{"on the day at 8:15", "TRIGGER:PT8H15M"},
+		{"TRIGGER:-PT15M", "TRIGGER:-PT15M"},
+		{"trigger:-p1dt15h0m0s", "TRIGGER:-P1DT15H"},
+		{"TRIGGER:-P1W", "TRIGGER:-P7D"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ToTrigger(tt.input)
+			if err != nil {
+				t.Fatalf("ToTrigger(%q): %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ToTrigger(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToTriggerRejects(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"soon",
+		"15 fortnights before",
+		"2h before 9:00",
+		"at 25:00",
+		"1d before 9:60",
+		"TRIGGER:P",
+		"TRIGGER:-PT",
+		"TRIGGER:15M",
+		"TRIGGER:PT1D",
+	} {
+		if got, err := ToTrigger(input); err == nil {
+			t.Errorf("ToTrigger(%q) = %s, want an error", input, got)
+		}
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	tests := []struct {
+		trigger string
+		want    time.Duration
+	}{
+		{"TRIGGER:PT0S", 0},
+		{"TRIGGER:-PT15M", -15 * time.Minute},
+		{"-P1DT15H0M0S", -(39 * time.Hour)},
+		{"P2W", 14 * day},
+		{"TRIGGER:PT1H30M15S", time.Hour + 30*time.Minute + 15*time.Second},
+	}
+	for _, tt := range tests {
+		got, err := ParseTrigger(tt.trigger)
+		if err != nil {
+			t.Errorf("ParseTrigger(%q): %v", tt.trigger, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTrigger(%q) = %v, want %v", tt.trigger, got, tt.want)
+		}
+		// 格式化后再解析得到相同的偏移
+		if again, err := ParseTrigger(FormatTrigger(got)); err != nil || again != got {
+			t.Errorf("round trip of %q = %v, %v", tt.trigger, again, err)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		trigger string
+		allDay  bool
+		want    string
+	}{
+		{"TRIGGER:PT0S", false, "on time"},
+		{"TRIGGER:-PT15M", false, "15m before"},
+		{"TRIGGER:-PT2H", false, "2h before"},
+		{"TRIGGER:P1D", false, "1d after"},
+		{"TRIGGER:-P7D", false, "1w before"},
+		{"TRIGGER:-PT15H", true, "1d before 9:00"},
+		{"TRIGGER:PT9H", true, "at 9:00"},
+		{"TRIGGER:-P1DT5H30M", true, "2d before 18:30"},
+		{"not a trigger", false, "not a trigger"},
+	}
+	for _, tt := range tests {
+		if got := Describe(tt.trigger, tt.allDay); got != tt.want {
+			t.Errorf("Describe(%q, %v) = %q, want %q", tt.trigger, tt.allDay, got, tt.want)
+		}
+		// 描述可以再转换回相同的 TRIGGER
+		if tt.want == "not a trigger" {
+			continue
+		}
+		back, err := ToTrigger(Describe(tt.trigger, tt.allDay))
+		want, _ := ToTrigger(tt.trigger)
+		if err != nil || back != want {
+			t.Errorf("ToTrigger(Describe(%q)) = %s, %v, want %s", tt.trigger, back, err, want)
+		}
+	}
+}
+
+func TestAnchor(t *testing.T) {
+	start := time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)
+	due := start.Add(2 * time.Hour)
+	tests := []struct {
+		start, due, want time.Time
+	}{
+		{start, due, start},
+		{time.Time{}, due, due},
+		{start, time.Time{}, start},
+		{time.Time{}, time.Time{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := Anchor(tt.start, tt.due); !got.Equal(tt.want) {
+			t.Errorf("Anchor(%v, %v) = %v, want %v", tt.start, tt.due, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"dida/globalinit"
+	"dida/internal/audit"
+	"dida/internal/client"
+	"dida/internal/config"
+	"dida/internal/errors"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// auditLog 审计日志，未启用或打开失败时为 nil
+var auditLog *audit.Log
+
+// maxAuditErrorLength 审计记录中错误信息的最大长度
+const maxAuditErrorLength = 500
+
+// initAudit 打开审计日志
+func initAudit(cfg config.AuditConfig) {
+	logger := globalinit.GetLogger()
+	if !cfg.Enabled || auditLog != nil {
+		return
+	}
+	l, err := audit.Open(cfg.Path, int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxBackups)
+	if err != nil {
+		logger.Errorf("Audit log disabled: %v", err)
+		return
+	}
+	auditLog = l
+	attachClientHooks()
+	logger.Infof("Audit log enabled at %s", cfg.Path)
+}
+
+// writeAudit 写入一条审计记录，失败时只记录到运行日志
+func writeAudit(entry audit.Entry) {
+	if err := auditLog.Write(entry); err != nil {
+		globalinit.GetLogger().Errorf("Failed to write audit log: %v", err)
+	}
+}
+
+// audited 记录工具调用的参数、会话、耗时和结果
+func audited(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, request)
+
+		args := request.GetArguments()
+		entry := audit.Entry{
+			Time:       start,
+			Type:       audit.TypeToolCall,
+			Tool:       name,
+			Arguments:  audit.Redact(args),
+			ProjectIDs: collectProjectIDs(args),
+			DurationMS: time.Since(start).Milliseconds(),
+			Outcome:    audit.OutcomeOK,
+		}
+		if taskID, ok := args["task_id"].(string); ok {
+			entry.TaskID = taskID
+		}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			entry.SessionID = session.SessionID()
+			if withInfo, ok := session.(server.SessionWithClientInfo); ok {
+				info := withInfo.GetClientInfo()
+				entry.Client = strings.TrimSpace(info.Name + " " + info.Version)
+			}
+		}
+		switch {
+		case err != nil:
+			entry.Outcome, entry.Error = audit.OutcomeError, truncateAuditError(err.Error())
+		case result != nil && result.IsError:
+			entry.Outcome, entry.Error = audit.OutcomeError, truncateAuditError(resultText(result))
+		}
+		writeAudit(entry)
+		return result, err
+	}
+}
+
+// observeRequest 记录修改数据的 API 请求
+func observeRequest(req client.Request) {
+	entry := audit.Entry{
+		Time:       time.Now().Add(-req.Duration),
+		Type:       audit.TypeAPIRequest,
+		Method:     req.Method,
+		Endpoint:   req.Endpoint,
+		DurationMS: req.Duration.Milliseconds(),
+		Outcome:    audit.OutcomeOK,
+	}
+	entry.ProjectIDs, entry.TaskID = requestTargets(req.Endpoint, req.Body)
+	if req.Err != nil {
+		entry.Outcome, entry.Error = audit.OutcomeError, truncateAuditError(req.Err.Error())
+		entry.StatusCode = errors.GetStatusCode(req.Err)
+	}
+	writeAudit(entry)
+}
+
+// requestTargets 从接口路径和请求体中提取涉及的项目 ID 和任务 ID
+// 路径形如 /project/{id}/task/{taskId}；请求体中的 projectId、fromProjectId、toProjectId 也算作涉及的项目
+func requestTargets(endpoint string, body any) (projectIDs []string, taskID string) {
+	add := func(id string) {
+		if id == "" {
+			return
+		}
+		for _, existing := range projectIDs {
+			if existing == id {
+				return
+			}
+		}
+		projectIDs = append(projectIDs, id)
+	}
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		switch parts[i] {
+		case "project":
+			add(parts[i+1])
+		case "task":
+			if parts[i+1] != "move" {
+				taskID = parts[i+1]
+			}
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return projectIDs, taskID
+	}
+	var decoded any
+	if json.Unmarshal(data, &decoded) != nil {
+		return projectIDs, taskID
+	}
+	items, ok := decoded.([]any)
+	if !ok {
+		items = []any{decoded}
+	}
+	for _, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"projectId", "fromProjectId", "toProjectId"} {
+			if id, ok := fields[key].(string); ok {
+				add(id)
+			}
+		}
+		if id, ok := fields["taskId"].(string); ok && taskID == "" {
+			taskID = id
+		}
+	}
+	return projectIDs, taskID
+}
+
+// resultText 拼接工具结果中的文本内容
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// truncateAuditError 截断过长的错误信息
+func truncateAuditError(message string) string {
+	runes := []rune(message)
+	if len(runes) <= maxAuditErrorLength {
+		return message
+	}
+	return string(runes[:maxAuditErrorLength]) + "..."
+}
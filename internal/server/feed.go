@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"dida/globalinit"
+	"dida/internal/config"
+	"dida/internal/ical"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// feedTimeout 生成一次订阅内容的超时时间
+const feedTimeout = 30 * time.Second
+
+// feedAllName 包含所有项目的订阅的文件名
+const feedAllName = "all"
+
+// feedVersion 订阅内容的版本：内容摘要及其最后变化的时间
+type feedVersion struct {
+	sum      [sha256.Size]byte
+	modified time.Time
+}
+
+// icsFeed 只读的 iCalendar 订阅服务
+type icsFeed struct {
+	token string
+	mode  string
+
+	mu       sync.Mutex
+	versions map[string]feedVersion
+}
+
+// initFeed 配置了监听地址时在后台启动 iCalendar 订阅服务
+func initFeed(cfg config.FeedConfig) {
+	if cfg.Addr == "" {
+		return
+	}
+	logger := globalinit.GetLogger()
+	feed := &icsFeed{token: cfg.Token, mode: cfg.Mode, versions: make(map[string]feedVersion)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ics/{token}/{file}", feed.serve)
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		logger.Infof("iCalendar feed listening on http://%s/ics/<token>/%s.ics", cfg.Addr, feedAllName)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("iCalendar feed stopped: %v", err)
+		}
+	}()
+}
+
+// serve 处理 /ics/{token}/{project}.ics 请求，{project} 为 all 时包含所有项目
+// 支持 ?mode=todo|event 覆盖默认的导出方式，以及 If-None-Match / If-Modified-Since 条件请求
+func (f *icsFeed) serve(w http.ResponseWriter, r *http.Request) {
+	logger := globalinit.GetLogger()
+	if subtle.ConstantTimeCompare([]byte(r.PathValue("token")), []byte(f.token)) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	file := r.PathValue("file")
+	name, ok := strings.CutSuffix(file, ".ics")
+	if !ok || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = f.mode
+	}
+	if mode != ical.ModeTodo && mode != ical.ModeEvent {
+		http.Error(w, fmt.Sprintf("mode must be %s or %s", ical.ModeTodo, ical.ModeEvent), http.StatusBadRequest)
+		return
+	}
+	var projectIDs []string
+	if name != feedAllName {
+		projectIDs = []string{name}
+		if !allowProject(toolPolicy, name) {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	if err := ensureClientInitialized(); err != nil {
+		http.Error(w, "TickTick client is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), feedTimeout)
+	defer cancel()
+	selection, err := selectICSTasks(ctx, ICSExportOptions{ProjectIDs: projectIDs, Mode: mode})
+	if err == nil && selection.warnings != "" {
+		// 部分项目获取失败时不返回不完整的日历，以免日历应用删除这些项目的条目
+		err = fmt.Errorf("%s", strings.TrimSpace(selection.warnings))
+	}
+	var export *ICSExport
+	if err == nil {
+		// 以固定的 DTSTAMP 生成内容计算摘要，内容变化时才更新 Last-Modified
+		export, err = selection.render(time.Unix(0, 0))
+	}
+	var sum [sha256.Size]byte
+	var modified time.Time
+	if err == nil {
+		sum = sha256.Sum256(export.Data)
+		modified = f.version(name+"?"+mode, sum)
+		export, err = selection.render(modified)
+	}
+	if err != nil {
+		logger.Errorf("Failed to generate iCalendar feed %s: %v", file, err)
+		w.Header().Set("Retry-After", "300")
+		http.Error(w, "Failed to generate calendar", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sum[:16]))
+	http.ServeContent(w, r, file, modified, bytes.NewReader(export.Data))
+}
+
+// version 记录订阅内容的摘要，返回内容最后变化的时间
+func (f *icsFeed) version(key string, sum [sha256.Size]byte) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.versions[key]; ok && v.sum == sum {
+		return v.modified
+	}
+	// HTTP 日期精确到秒
+	modified := time.Now().UTC().Truncate(time.Second)
+	f.versions[key] = feedVersion{sum: sum, modified: modified}
+	return modified
+}
@@ -2,9 +2,22 @@ package server
 
 import (
 	"dida/internal/client"
+	"dida/internal/recurrence"
+	"dida/internal/reminder"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 )
 
+// priorityMap 优先级数值与名称的对应关系
+var priorityMap = map[int]string{
+	0: "None",
+	1: "Low",
+	3: "Medium",
+	5: "High",
+}
+
 func FormatProject(project client.Project) string {
 	formatted := fmt.Sprintf("Name: %s\n", project.Name)
 	formatted += fmt.Sprintf("ID: %s\n", project.ID)
@@ -21,35 +34,124 @@ func FormatProject(project client.Project) string {
 	return formatted
 }
 
+// FormatColumns 将看板列及各列的任务数格式化为可读字符串
+func FormatColumns(columns []client.Column, tasks []client.Task) string {
+	if len(columns) == 0 {
+		return "Columns: none\n"
+	}
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		counts[task.ColumnID]++
+	}
+	formatted := fmt.Sprintf("Columns (%d):\n", len(columns))
+	for i, column := range sortedColumns(columns) {
+		formatted += fmt.Sprintf("%d. %s (ID: %s, %d tasks)\n", i+1, column.Name, column.ID, counts[column.ID])
+	}
+	return formatted
+}
+
+// FormatTasksByColumn 按看板列分组格式化任务，不属于任何已知列的任务放在最后
+func FormatTasksByColumn(columns []client.Column, tasks []client.Task) string {
+	groups := make(map[string][]client.Task)
+	for _, task := range tasks {
+		groups[task.ColumnID] = append(groups[task.ColumnID], task)
+	}
+
+	formatted := ""
+	n := 0
+	writeGroup := func(title string, group []client.Task) {
+		formatted += fmt.Sprintf("\n## %s (%d tasks)\n\n", title, len(group))
+		for _, task := range group {
+			n++
+			formatted += fmt.Sprintf("Task %d: \n%s\n", n, FormatTask(task))
+		}
+	}
+	for _, column := range sortedColumns(columns) {
+		writeGroup(fmt.Sprintf("%s (Column ID: %s)", column.Name, column.ID), groups[column.ID])
+		delete(groups, column.ID)
+	}
+	var rest []client.Task
+	for _, task := range tasks {
+		if _, ok := groups[task.ColumnID]; ok {
+			rest = append(rest, task)
+		}
+	}
+	if len(rest) > 0 {
+		writeGroup("No column", rest)
+	}
+	return formatted
+}
+
+// sortedColumns 返回按 SortOrder 排序的看板列副本
+func sortedColumns(columns []client.Column) []client.Column {
+	sorted := make([]client.Column, len(columns))
+	copy(sorted, columns)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].SortOrder < sorted[j].SortOrder })
+	return sorted
+}
+
 // FormatTask 将任务对象格式化为可读字符串
 func FormatTask(task client.Task) string {
 
-	formatted := fmt.Sprintf("ID: %s\n", task.ID)
+	formatted := ""
+	// 预览中尚未创建的任务没有 ID
+	if task.ID != "" {
+		formatted += fmt.Sprintf("ID: %s\n", task.ID)
+	}
 	formatted += fmt.Sprintf("Title: %s\n", task.Title)
-	formatted += fmt.Sprintf("Project ID: %s\n", task.ProjectID)
+	if task.ProjectID == "" {
+		formatted += "Project ID: (inbox)\n"
+	} else {
+		formatted += fmt.Sprintf("Project ID: %s\n", task.ProjectID)
+	}
+
+	now := time.Now()
+	active := task.Status != client.TaskStatusCompleted
 
-	if task.StartDate != "" {
-		formatted += fmt.Sprintf("Start Date: %s\n", task.StartDate)
+	if !task.StartDate.IsZero() {
+		formatted += fmt.Sprintf("Start Date: %s", formatTaskDate(task, task.StartDate))
+		if active {
+			formatted += fmt.Sprintf(" (%s)", relativeHint(task, task.StartDate, now, startHints))
+		}
+		formatted += "\n"
+	}
+
+	if !task.DueDate.IsZero() {
+		formatted += fmt.Sprintf("Due Date: %s", formatTaskDate(task, task.DueDate))
+		if active {
+			formatted += fmt.Sprintf(" (%s)", relativeHint(task, task.DueDate, now, dueHints))
+		}
+		formatted += "\n"
+	}
+
+	if len(task.Tags) > 0 {
+		formatted += fmt.Sprintf("Tags: %s\n", formatTags(task.Tags))
 	}
 
-	if task.DueDate != "" {
-		formatted += fmt.Sprintf("Due Date: %s\n", task.DueDate)
+	if task.ColumnID != "" {
+		formatted += fmt.Sprintf("Column ID: %s\n", task.ColumnID)
 	}
 
-	priorityMap := map[int]string{
-		0: "None",
-		1: "Low",
-		3: "Medium",
-		5: "High",
+	if len(task.Reminders) > 0 {
+		formatted += fmt.Sprintf("Reminders: %s\n", formatReminders(task))
 	}
+
+	if task.RepeatFlag != "" {
+		formatted += formatRecurrence(task)
+	}
+
 	formatted += fmt.Sprintf("Priority: %s\n", priorityMap[task.Priority])
 
 	status := "Active"
-	if task.Status == 2 {
+	if task.Status == client.TaskStatusCompleted {
 		status = "Completed"
 	}
 	formatted += fmt.Sprintf("Status: %s\n", status)
 
+	if !task.CompletedTime.IsZero() {
+		formatted += fmt.Sprintf("Completed Time: %s\n", task.CompletedTime.In(userLocation).Format(dateTimeDisplayLayout))
+	}
+
 	if task.Content != "" {
 		formatted += fmt.Sprintf("\nContent:\n%s\n", task.Content)
 	}
@@ -58,12 +160,206 @@ func FormatTask(task client.Task) string {
 		formatted += fmt.Sprintf("\nSubtasks (%d):\n", len(task.Items))
 		for i, item := range task.Items {
 			statusMark := "□"
-			if item.Status == 1 {
+			if item.Status == client.ItemStatusCompleted {
 				statusMark = "✓"
 			}
-			formatted += fmt.Sprintf("%d. [%s] %s\n", i+1, statusMark, item.Title)
+			if item.ID == "" {
+				formatted += fmt.Sprintf("%d. [%s] %s\n", i+1, statusMark, item.Title)
+				continue
+			}
+			formatted += fmt.Sprintf("%d. [%s] %s (ID: %s)\n", i+1, statusMark, item.Title, item.ID)
+		}
+	}
+
+	return formatted
+}
+
+// 日期展示格式
+const (
+	dateDisplayLayout     = "2006-01-02 Mon"
+	dateTimeDisplayLayout = "2006-01-02 15:04 Mon MST"
+)
+
+// formatTaskDate 在用户时区中展示任务日期，全天任务只展示日期，零值返回空字符串
+func formatTaskDate(task client.Task, t client.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if task.IsAllDay {
+		return t.InZone(task, userLocation).Format(dateDisplayLayout)
+	}
+	return t.InZone(task, userLocation).Format(dateTimeDisplayLayout)
+}
+
+// relativeWords 相对时间提示的措辞
+type relativeWords struct {
+	today, tomorrow, future, past string
+}
+
+var (
+	dueHints   = relativeWords{today: "due today", tomorrow: "due tomorrow", future: "due in %s", past: "overdue by %s"}
+	startHints = relativeWords{today: "starts today", tomorrow: "starts tomorrow", future: "starts in %s", past: "started %s ago"}
+)
+
+// relativeHint 返回相对于当前时间的提示，如 "due in 2 days"、"overdue by 3h"
+// 全天任务按用户时区的自然日计算，其余任务按精确时长计算
+func relativeHint(task client.Task, t client.Time, now time.Time, words relativeWords) string {
+	if task.IsAllDay {
+		date := t.InZone(task, userLocation)
+		today := now.In(userLocation)
+		days := int(civilDate(date).Sub(civilDate(today)).Hours() / 24)
+		switch {
+		case days == 0:
+			return words.today
+		case days == 1:
+			return words.tomorrow
+		case days > 1:
+			return fmt.Sprintf(words.future, pluralDays(days))
+		default:
+			return fmt.Sprintf(words.past, pluralDays(-days))
+		}
+	}
+
+	d := t.Sub(now)
+	if d >= 0 {
+		return fmt.Sprintf(words.future, shortDuration(d))
+	}
+	return fmt.Sprintf(words.past, shortDuration(-d))
+}
+
+// civilDate 取时间的年月日，以 UTC 零点表示，便于按自然日相减
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// shortDuration 将时长格式化为简短写法，如 45m、3h、2 days
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		return pluralDays(int(d / (24 * time.Hour)))
+	}
+}
+
+// pluralDays 返回 "1 day" 或 "N days"
+func pluralDays(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// formatTags 将标签格式化为 #a #b
+func formatTags(list []string) string {
+	formatted := make([]string, len(list))
+	for i, tag := range list {
+		formatted[i] = "#" + tag
+	}
+	return strings.Join(formatted, " ")
+}
+
+// formatReminders 将任务的提醒描述为友好的写法
+func formatReminders(task client.Task) string {
+	descriptions := make([]string, 0, len(task.Reminders))
+	for _, trigger := range task.Reminders {
+		descriptions = append(descriptions, reminder.Describe(trigger, task.IsAllDay))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// upcomingOccurrences FormatTask 中展示的后续重复次数
+const upcomingOccurrences = 3
+
+// formatRecurrence 描述任务的重复规则及接下来的几次重复时间
+func formatRecurrence(task client.Task) string {
+	rule, err := recurrence.Parse(task.RepeatFlag)
+	if err != nil {
+		return fmt.Sprintf("Repeat: %s\n", task.RepeatFlag)
+	}
+	formatted := fmt.Sprintf("Repeat: %s\n", rule.Describe())
+
+	anchor := task.StartDate
+	if anchor.IsZero() {
+		anchor = task.DueDate
+	}
+	if anchor.IsZero() {
+		return formatted
+	}
+
+	// 按任务时区展开重复，保证跨夏令时等情况下时刻不漂移
+	start := anchor.In(task.Location(userLocation))
+	next := rule.Next(start, time.Now(), upcomingOccurrences)
+	if len(next) > 0 {
+		occurrences := make([]string, len(next))
+		for i, t := range next {
+			occurrences[i] = formatTaskDate(task, client.NewTime(t))
+		}
+		formatted += fmt.Sprintf("Next Occurrences: %s\n", strings.Join(occurrences, ", "))
+	}
+	return formatted
+}
+
+// describeRepeat 将 repeatFlag 描述为友好的写法
+func describeRepeat(repeatFlag string) string {
+	if repeatFlag == "" {
+		return ""
+	}
+	rule, err := recurrence.Parse(repeatFlag)
+	if err != nil {
+		return repeatFlag
+	}
+	return rule.Describe()
+}
+
+// TaskChange 描述任务某个字段的变化
+type TaskChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// DiffTasks 比较任务更新前后的字段差异
+func DiffTasks(before, after client.Task) []TaskChange {
+	var changes []TaskChange
+	add := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, TaskChange{Field: field, Old: old, New: new})
 		}
 	}
 
+	add("Title", before.Title, after.Title)
+	add("Content", before.Content, after.Content)
+	add("Start Date", formatTaskDate(before, before.StartDate), formatTaskDate(after, after.StartDate))
+	add("Due Date", formatTaskDate(before, before.DueDate), formatTaskDate(after, after.DueDate))
+	add("Priority", priorityMap[before.Priority], priorityMap[after.Priority])
+	add("All Day", fmt.Sprint(before.IsAllDay), fmt.Sprint(after.IsAllDay))
+	add("Reminders", formatReminders(before), formatReminders(after))
+	add("Repeat", describeRepeat(before.RepeatFlag), describeRepeat(after.RepeatFlag))
+	add("Tags", formatTags(before.Tags), formatTags(after.Tags))
+	return changes
+}
+
+// FormatTaskChanges 将字段变化格式化为可读字符串
+func FormatTaskChanges(changes []TaskChange) string {
+	if len(changes) == 0 {
+		return "Changes: none\n"
+	}
+	formatted := fmt.Sprintf("Changes (%d):\n", len(changes))
+	for _, change := range changes {
+		formatted += fmt.Sprintf("- %s: %s -> %s\n", change.Field, displayValue(change.Old), displayValue(change.New))
+	}
 	return formatted
 }
+
+// displayValue 将空值显示为 (empty)
+func displayValue(value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+	return fmt.Sprintf("%q", value)
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"dida/internal/client"
+	"dida/internal/dateparse"
+	"dida/internal/ical"
+	"fmt"
+	"time"
+)
+
+// ICSExportOptions 导出 iCalendar 的条件
+type ICSExportOptions struct {
+	// ProjectIDs 要导出的项目，为空时导出所有可见项目（含收集箱）
+	ProjectIDs []string
+	// From、To 日期范围（包含两端），支持自然语言日期；设置后没有日期的任务不导出
+	From string
+	To   string
+	// Mode ical.ModeTodo 或 ical.ModeEvent，默认 ical.ModeTodo
+	Mode string
+	// Refresh 跳过本地镜像和缓存
+	Refresh bool
+}
+
+// ICSExport 导出结果
+type ICSExport struct {
+	Data []byte
+	// Exported 导出的任务数
+	Exported int
+	// Skipped 没有日期、无法导出为事件的任务数
+	Skipped int
+	// Warnings 部分项目获取失败的提示
+	Warnings string
+}
+
+// ExportICS 将项目中的未完成任务导出为 iCalendar 数据
+func ExportICS(ctx context.Context, opts ICSExportOptions) (*ICSExport, error) {
+	selection, err := selectICSTasks(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return selection.render(time.Now())
+}
+
+// icsSelection 按导出条件选出的任务
+type icsSelection struct {
+	name     string
+	mode     string
+	tasks    []client.Task
+	warnings string
+}
+
+// selectICSTasks 校验导出条件并获取要导出的任务
+func selectICSTasks(ctx context.Context, opts ICSExportOptions) (*icsSelection, error) {
+	if opts.Mode == "" {
+		opts.Mode = ical.ModeTodo
+	}
+	if opts.Mode != ical.ModeTodo && opts.Mode != ical.ModeEvent {
+		return nil, fmt.Errorf("invalid mode %q: use %s or %s", opts.Mode, ical.ModeTodo, ical.ModeEvent)
+	}
+	now := time.Now().In(userLocation)
+	from, err := parseExportDate(opts.From, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date: %v", err)
+	}
+	to, err := parseExportDate(opts.To, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date: %v", err)
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, fmt.Errorf("to date %s is before from date %s", to.Format(dateDisplayLayout), from.Format(dateDisplayLayout))
+	}
+
+	projects, errs, err := loadTasksOf(ctx, opts.ProjectIDs, opts.Refresh)
+	if err != nil {
+		return nil, err
+	}
+	selection := &icsSelection{name: "TickTick", mode: opts.Mode, warnings: formatFetchErrors(errs)}
+	if len(projects) == 1 {
+		selection.name = projects[0].Project.Name
+	}
+	for _, project := range projects {
+		for _, task := range project.Tasks {
+			if inDateRange(task, from, to) {
+				selection.tasks = append(selection.tasks, task)
+			}
+		}
+	}
+	return selection, nil
+}
+
+// render 生成 iCalendar 数据，stamp 为各条目的 DTSTAMP
+func (s *icsSelection) render(stamp time.Time) (*ICSExport, error) {
+	cal, skipped := ical.Export(s.tasks, ical.ExportOptions{Name: s.name, Mode: s.mode, Location: userLocation, Stamp: stamp})
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		return nil, err
+	}
+	return &ICSExport{
+		Data:     buf.Bytes(),
+		Exported: len(s.tasks) - skipped,
+		Skipped:  skipped,
+		Warnings: s.warnings,
+	}, nil
+}
+
+// loadTasksOf 获取指定项目的任务，projectIDs 为空时获取所有可见项目；指定的项目需在访问策略允许的范围内
+func loadTasksOf(ctx context.Context, projectIDs []string, refresh bool) ([]projectTasks, []error, error) {
+	if len(projectIDs) == 0 {
+		return fetchAllProjectTasks(ctx, refresh)
+	}
+	results := make([]projectTasks, 0, len(projectIDs))
+	for _, projectID := range projectIDs {
+		if !allowProject(toolPolicy, projectID) {
+			return nil, nil, fmt.Errorf("access to project %s is not allowed by the server policy", projectID)
+		}
+		data, err := loadProjectData(projectID, refresh)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error fetching project %s: %v", projectID, err)
+		}
+		project := data.Project
+		if client.IsInbox(projectID) {
+			project = client.InboxProject()
+		}
+		results = append(results, projectTasks{Project: project, Tasks: data.Tasks})
+	}
+	return results, nil, nil
+}
+
+// parseExportDate 解析导出日期范围的一端，返回用户时区中的自然日；空字符串返回零值
+func parseExportDate(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	result, err := dateparse.Parse(value, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return civilDate(result.Time), nil
+}
+
+// inDateRange 判断任务的日期是否在范围内（包含两端），范围为空时所有任务都满足
+func inDateRange(task client.Task, from, to time.Time) bool {
+	if from.IsZero() && to.IsZero() {
+		return true
+	}
+	day, ok := taskDay(task)
+	if !ok {
+		return false
+	}
+	return (from.IsZero() || !day.Before(from)) && (to.IsZero() || !day.After(to))
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"dida/internal/client"
+	"dida/internal/ical"
+	"dida/internal/tags"
+	"fmt"
+	"strings"
+)
+
+// ICSImportOptions 导入 iCalendar 的选项
+type ICSImportOptions struct {
+	// ProjectID 目标项目，为空时导入到收集箱
+	ProjectID string
+	// IncludeCompleted 为 true 时也导入已完成的条目（创建后标记完成）
+	IncludeCompleted bool
+}
+
+// icsImportEntry 一个待导入的条目及其跳过原因
+type icsImportEntry struct {
+	ical.ImportedTask
+	// skip 不为空时该条目不会被创建
+	skip string
+}
+
+// planICSImport 解析 iCalendar 数据，转换其中的 VTODO 并与目标项目中已有的任务去重
+// 已导入过的条目按正文中记录的 UID 识别；由 export_ics 导出的条目按任务 ID 识别
+func planICSImport(ctx context.Context, data []byte, opts ICSImportOptions) ([]icsImportEntry, error) {
+	calendars, err := ical.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid iCalendar data: %v", err)
+	}
+	imported := ical.Tasks(calendars, ical.ImportOptions{Location: userLocation, TimeZone: userTimeZone})
+	if len(imported) == 0 {
+		return nil, fmt.Errorf("no VTODO entries found")
+	}
+
+	projectID := opts.ProjectID
+	if projectID == "" {
+		projectID = client.InboxProjectID
+	}
+	projects, _, err := loadTasksOf(ctx, []string{projectID}, true)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]string)
+	for _, task := range projects[0].Tasks {
+		if uid := ical.ContentUID(task.Content); uid != "" {
+			existing[uid] = task.ID
+		}
+		existing[task.ID+ical.UIDSuffix] = task.ID
+	}
+
+	seen := make(map[string]bool, len(imported))
+	entries := make([]icsImportEntry, len(imported))
+	for i, item := range imported {
+		item.Task.Tags = tags.Merge(item.Task.Tags)
+		if !client.IsInbox(projectID) {
+			item.Task.ProjectID = projectID
+		}
+		entry := icsImportEntry{ImportedTask: item}
+		switch {
+		case existing[item.UID] != "":
+			entry.skip = fmt.Sprintf("already in the project as task %s", existing[item.UID])
+		case seen[item.UID]:
+			entry.skip = "duplicate UID in the file"
+		case item.Cancelled:
+			entry.skip = "cancelled"
+		case item.Completed && !opts.IncludeCompleted:
+			entry.skip = "completed (set include_completed to import it)"
+		}
+		seen[item.UID] = true
+		entries[i] = entry
+	}
+	return entries, nil
+}
+
+// PreviewICSImport 返回导入 iCalendar 数据将创建的任务，不实际创建
+func PreviewICSImport(ctx context.Context, data []byte, opts ICSImportOptions) (string, error) {
+	entries, err := planICSImport(ctx, data, opts)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	created := 0
+	for _, entry := range entries {
+		if entry.skip == "" {
+			created++
+		}
+	}
+	fmt.Fprintf(&b, "Importing would create %d of %d tasks:\n", created, len(entries))
+	for i, entry := range entries {
+		fmt.Fprintf(&b, "\nEntry %d (UID %s):\n", i+1, entry.UID)
+		if entry.skip != "" {
+			fmt.Fprintf(&b, "Skipped: %s — %q\n", entry.skip, entry.Task.Title)
+			continue
+		}
+		b.WriteString(FormatTask(entry.Task))
+		if entry.Completed {
+			b.WriteString("Will be marked completed\n")
+		}
+		for _, warning := range entry.Warnings {
+			fmt.Fprintf(&b, "Warning: %s\n", warning)
+		}
+	}
+	return b.String(), nil
+}
+
+// ImportICS 按文件中的顺序创建 iCalendar 数据中的任务，返回逐项结果
+func ImportICS(ctx context.Context, data []byte, opts ICSImportOptions) (string, error) {
+	if err := checkOutboxEmpty("import"); err != nil {
+		return "", err
+	}
+	entries, err := planICSImport(ctx, data, opts)
+	if err != nil {
+		return "", err
+	}
+	var results []batchResult
+	skipped := 0
+	for _, entry := range entries {
+		if entry.skip != "" {
+			skipped++
+			continue
+		}
+		created, err := ticktickClient.CreateTask(entry.Task)
+		if err != nil {
+			results = append(results, batchResult{err: fmt.Errorf("failed to create %q: %v", entry.Task.Title, err)})
+			continue
+		}
+		message := fmt.Sprintf("created %q (ID: %s, Project ID: %s)", created.Title, created.ID, created.ProjectID)
+		if entry.Completed {
+			if err := ticktickClient.CompletedTask(created.ProjectID, created.ID); err != nil {
+				results = append(results, batchResult{err: fmt.Errorf("created %q (ID: %s) but could not mark it completed: %v", created.Title, created.ID, err)})
+				continue
+			}
+			message += ", marked completed"
+		}
+		if len(entry.Warnings) > 0 {
+			message += "; ignored: " + strings.Join(entry.Warnings, "; ")
+		}
+		results = append(results, batchResult{message: message})
+	}
+
+	var b strings.Builder
+	b.WriteString(formatBatchResults("import", results))
+	if skipped > 0 {
+		fmt.Fprintf(&b, "\nSkipped %d entries:\n", skipped)
+		for _, entry := range entries {
+			if entry.skip != "" {
+				fmt.Fprintf(&b, "- %q: %s\n", entry.Task.Title, entry.skip)
+			}
+		}
+	}
+	return b.String(), nil
+}
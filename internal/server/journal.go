@@ -0,0 +1,165 @@
+package server
+
+import (
+	"dida/globalinit"
+	"dida/internal/client"
+	"dida/internal/config"
+	"dida/internal/errors"
+	"dida/internal/mirror"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// journalEnabled 是否在本地镜像中记录任务修改以便撤销
+var journalEnabled bool
+
+// journalRetention 修改记录的保留时长，<= 0 表示永久保留
+var journalRetention time.Duration
+
+// initJournal 启用修改日志
+func initJournal(cfg config.JournalConfig) {
+	if taskMirror == nil || !cfg.Enabled {
+		return
+	}
+	journalEnabled, journalRetention = true, cfg.Retention
+	attachClientHooks()
+}
+
+// recordMutation 将客户端报告的任务修改写入修改日志
+func recordMutation(m client.Mutation) {
+	logger := globalinit.GetLogger()
+	action := &mirror.Action{
+		Kind:      m.Kind,
+		ProjectID: m.ProjectID,
+		TaskID:    m.TaskID,
+		Before:    m.Before,
+		After:     m.After,
+	}
+	switch {
+	case m.After != nil:
+		action.Title = m.After.Title
+	case m.Before != nil:
+		action.Title = m.Before.Title
+	}
+	if err := taskMirror.RecordAction(action); err != nil {
+		logger.Errorf("Failed to record %s of task %s: %v", m.Kind, m.TaskID, err)
+		return
+	}
+	if journalRetention > 0 {
+		if err := taskMirror.PruneActions(time.Now().Add(-journalRetention)); err != nil {
+			logger.Errorf("Failed to prune action journal: %v", err)
+		}
+	}
+}
+
+// undoAction 撤销一次修改，返回结果说明
+// force 为 false 时，任务在该修改之后又被修改过则拒绝撤销，避免覆盖之后的修改
+func undoAction(action *mirror.Action, force bool) (string, error) {
+	if !action.UndoneAt.IsZero() {
+		return "", fmt.Errorf("action #%d was already undone at %s", action.ID, action.UndoneAt.In(userLocation).Format(dateTimeDisplayLayout))
+	}
+	if action.Kind != client.MutationCreate && action.Before == nil {
+		return "", fmt.Errorf("action #%d cannot be undone: the task could not be read before it was changed", action.ID)
+	}
+
+	switch action.Kind {
+	case client.MutationCreate:
+		if _, err := currentTaskForUndo(action, force); err != nil {
+			return "", err
+		}
+		if err := ticktickClient.DeleteTask(action.ProjectID, action.TaskID); err != nil {
+			return "", fmt.Errorf("error deleting task: %v", err)
+		}
+		return fmt.Sprintf("Deleted task %q created by action #%d", action.Title, action.ID), nil
+
+	case client.MutationUpdate:
+		current, err := currentTaskForUndo(action, force)
+		if err != nil {
+			return "", err
+		}
+		// 修改中新增的日期需要显式清除，未设置的日期不会发送
+		before := *action.Before
+		before.ClearRemovedDates(*current)
+		restored, err := ticktickClient.UpdateTask(before)
+		if err != nil {
+			return "", fmt.Errorf("error restoring task: %v", err)
+		}
+		return fmt.Sprintf("Restored task to its state before action #%d:\n%s", action.ID, FormatTask(*restored)), nil
+
+	case client.MutationComplete:
+		current, err := currentTaskForUndo(action, force)
+		if err != nil {
+			return "", err
+		}
+		// 完成重复任务时任务本身保持未完成并顺延到下一次，恢复原来的日期即可
+		if current.Status != client.TaskStatusCompleted && action.Before.RepeatFlag == "" && !force {
+			return "", fmt.Errorf("task %q is not completed anymore, nothing to undo", current.Title)
+		}
+		reopened := *action.Before
+		reopened.Status = client.TaskStatusNormal
+		reopened.ClearRemovedDates(*current)
+		restored, err := ticktickClient.UpdateTask(reopened)
+		if err != nil {
+			return "", fmt.Errorf("error reopening task: %v", err)
+		}
+		if restored.Status == client.TaskStatusCompleted {
+			return "", fmt.Errorf("TickTick kept task %q completed after the update; reopen it in the app", restored.Title)
+		}
+		return fmt.Sprintf("Reopened task completed by action #%d:\n%s", action.ID, FormatTask(*restored)), nil
+
+	case client.MutationDelete:
+		recreated := *action.Before
+		recreated.ID = ""
+		recreated.Items = make([]client.TaskItem, len(action.Before.Items))
+		for i, item := range action.Before.Items {
+			item.ID = ""
+			recreated.Items[i] = item
+		}
+		if client.IsInbox(recreated.ProjectID) {
+			recreated.ProjectID = ""
+		}
+		created, err := ticktickClient.CreateTask(recreated)
+		if err != nil {
+			return "", fmt.Errorf("error recreating task: %v", err)
+		}
+		if action.Before.Status == client.TaskStatusCompleted && created.Status != client.TaskStatusCompleted {
+			if err := ticktickClient.CompletedTask(created.ProjectID, created.ID); err != nil {
+				return "", fmt.Errorf("task was recreated as %s but could not be marked completed: %v", created.ID, err)
+			}
+			created.Status = client.TaskStatusCompleted
+		}
+		return fmt.Sprintf("Recreated task deleted by action #%d (it has a new ID):\n%s", action.ID, FormatTask(*created)), nil
+
+	case client.MutationMove:
+		current, err := currentTaskForUndo(action, force)
+		if err != nil {
+			return "", err
+		}
+		moved, err := ticktickClient.MoveTask(*current, action.Before.ProjectID)
+		if err != nil {
+			return "", fmt.Errorf("error moving task back: %v", err)
+		}
+		return fmt.Sprintf("Moved task back to project %s:\n%s", action.Before.ProjectID, FormatTask(*moved)), nil
+	}
+	return "", fmt.Errorf("action #%d has unknown kind %q", action.ID, action.Kind)
+}
+
+// currentTaskForUndo 读取任务的当前状态，并检查任务在该修改之后是否又被修改过
+func currentTaskForUndo(action *mirror.Action, force bool) (*client.Task, error) {
+	current, err := ticktickClient.Fresh().GetTask(action.ProjectID, action.TaskID)
+	if err != nil {
+		if errors.GetStatusCode(err) == http.StatusNotFound {
+			return nil, fmt.Errorf("task %q no longer exists", action.Title)
+		}
+		return nil, fmt.Errorf("error fetching task: %v", err)
+	}
+	if action.After != nil && !force {
+		if fields := conflictFields(*action.After, *current); len(fields) > 0 {
+			return nil, fmt.Errorf("task %q was changed again after action #%d (%s); use force to undo anyway",
+				current.Title, action.ID, strings.Join(fields, ", "))
+		}
+	}
+	return current, nil
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"dida/globalinit"
+	"dida/internal/client"
+	"dida/internal/config"
+	"dida/internal/mirror"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// taskMirror 本地镜像，未启用或打开失败时为 nil，此时所有读取直接请求 API
+var taskMirror *mirror.Mirror
+
+// mirrorMaxAge 镜像数据的最大有效期
+var mirrorMaxAge time.Duration
+
+// mirrorGeneration 每次修改操作后递增；
+// 拉取期间发生过修改时不写入镜像，避免把修改前的数据标记为新鲜
+var mirrorGeneration atomic.Int64
+
+// initMirror 按配置打开本地镜像，打开失败时记录日志并退回到直接请求 API
+func initMirror(cfg config.MirrorConfig) {
+	logger := globalinit.GetLogger()
+	if !cfg.Enabled || taskMirror != nil {
+		return
+	}
+	m, err := mirror.Open(cfg.Path)
+	if err != nil {
+		logger.Errorf("Local mirror disabled: %v", err)
+		return
+	}
+	taskMirror = m
+	mirrorMaxAge = cfg.MaxAge
+	logger.Infof("Local mirror enabled at %s (max age %s)", cfg.Path, cfg.MaxAge)
+}
+
+// mirrorFresh 判断同步时间是否仍在有效期内
+func mirrorFresh(syncedAt time.Time) bool {
+	return !syncedAt.IsZero() && time.Since(syncedAt) <= mirrorMaxAge
+}
+
+// invalidateMirror 在修改操作后将镜像标记为过期，下次读取时重新请求 API
+func invalidateMirror() {
+	mirrorGeneration.Add(1)
+	if taskMirror == nil {
+		return
+	}
+	if err := taskMirror.Invalidate(); err != nil {
+		globalinit.GetLogger().Errorf("Failed to invalidate local mirror: %v", err)
+	}
+}
+
+// loadProjects 获取项目列表（不含收集箱）
+// 镜像新鲜时从镜像读取，否则请求 API 并写入镜像；refresh 为 true 时跳过镜像和缓存
+func loadProjects(refresh bool) ([]client.Project, error) {
+	logger := globalinit.GetLogger()
+	if taskMirror != nil && !refresh {
+		projects, syncedAt, err := taskMirror.Projects()
+		if err != nil {
+			logger.Errorf("Failed to read projects from local mirror: %v", err)
+		} else if mirrorFresh(syncedAt) {
+			return projects, nil
+		}
+	}
+
+	generation, fetchedAt := mirrorGeneration.Load(), time.Now()
+	projects, err := apiClient(refresh).GetProjects()
+	if err != nil {
+		return nil, err
+	}
+	if taskMirror != nil && mirrorGeneration.Load() == generation {
+		if err := taskMirror.SaveProjects(projects, fetchedAt); err != nil {
+			logger.Errorf("Failed to save projects to local mirror: %v", err)
+		}
+	}
+	return projects, nil
+}
+
+// loadProject 获取单个项目，镜像中有新鲜的项目列表时从镜像读取
+func loadProject(projectID string, refresh bool) (*client.Project, error) {
+	if taskMirror != nil && !refresh {
+		projects, syncedAt, err := taskMirror.Projects()
+		if err == nil && mirrorFresh(syncedAt) {
+			for _, project := range projects {
+				if project.ID == projectID {
+					return &project, nil
+				}
+			}
+		}
+	}
+	return apiClient(refresh).GetProject(projectID)
+}
+
+// loadProjectData 获取项目的任务和看板列
+// 镜像新鲜时从镜像读取，否则请求 API 并写入镜像；refresh 为 true 时跳过镜像和缓存
+func loadProjectData(projectID string, refresh bool) (*client.ProjectData, error) {
+	logger := globalinit.GetLogger()
+	if taskMirror != nil && !refresh {
+		data, syncedAt, err := taskMirror.ProjectData(projectID)
+		if err != nil && !errors.Is(err, mirror.ErrNotFound) {
+			logger.Errorf("Failed to read project %s from local mirror: %v", projectID, err)
+		} else if err == nil && mirrorFresh(syncedAt) {
+			return data, nil
+		}
+	}
+
+	generation, fetchedAt := mirrorGeneration.Load(), time.Now()
+	data, err := apiClient(refresh).GetProjectWithData(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if taskMirror != nil && mirrorGeneration.Load() == generation {
+		if err := taskMirror.SaveProjectData(projectID, data, fetchedAt); err != nil {
+			logger.Errorf("Failed to save project %s to local mirror: %v", projectID, err)
+		}
+	}
+	return data, nil
+}
+
+// loadTask 获取单个任务，所在项目在镜像中新鲜且包含该任务时从镜像读取
+// 镜像只包含未完成任务，未命中时请求 API
+func loadTask(projectID, taskID string, refresh bool) (*client.Task, error) {
+	if taskMirror != nil && !refresh {
+		task, syncedAt, err := taskMirror.Task(projectID, taskID)
+		if err == nil && mirrorFresh(syncedAt) {
+			return task, nil
+		}
+	}
+	return apiClient(refresh).GetTask(projectID, taskID)
+}
+
+// apiClient 返回用于读取的客户端，refresh 为 true 时跳过内存缓存
+func apiClient(refresh bool) *client.TickTickClient {
+	if refresh {
+		return ticktickClient.Fresh()
+	}
+	return ticktickClient
+}
+
+// withRefresh 为读取工具添加跳过本地镜像和内存缓存的参数
+func withRefresh() mcp.ToolOption {
+	return mcp.WithBoolean("refresh",
+		mcp.Description("Bypass the local mirror and response cache and fetch the latest data from TickTick (default false)"),
+	)
+}
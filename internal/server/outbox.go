@@ -0,0 +1,584 @@
+package server
+
+import (
+	"context"
+	"dida/globalinit"
+	"dida/internal/client"
+	"dida/internal/config"
+	"dida/internal/dateparse"
+	"dida/internal/errors"
+	"dida/internal/mirror"
+	"dida/internal/syncer"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// 可以离线排队的操作，取值与工具名相同
+const (
+	opCreateTask   = "create_task"
+	opUpdateTask   = "update_task"
+	opCompleteTask = "complete_task"
+	opDeleteTask   = "delete_task"
+)
+
+// outboxOperations 各操作的执行函数，返回结果说明；工具调用和离线重放共用
+var outboxOperations = map[string]func(request mcp.CallToolRequest) (string, error){
+	opCreateTask:   applyCreateTask,
+	opUpdateTask:   applyUpdateTask,
+	opCompleteTask: applyCompleteTask,
+	opDeleteTask:   applyDeleteTask,
+}
+
+// outboxRetention 已完成的队列条目保留多久，期间相同幂等键的调用直接返回原结果
+const outboxRetention = 7 * 24 * time.Hour
+
+// outboxMu 保证同一时间只有一个重放过程，且排队与重放不会交错
+var outboxMu sync.Mutex
+
+// idempotencyMu 串行化带幂等键的调用
+var idempotencyMu sync.Mutex
+
+// outboxEnabled 离线队列依赖本地镜像数据库，未启用时修改操作直接执行
+var outboxEnabled bool
+
+// initOutbox 启用离线队列，配置了重试间隔时在后台定期重放待处理的操作
+func initOutbox(cfg config.OutboxConfig) {
+	if taskMirror == nil || !cfg.Enabled || outboxEnabled {
+		return
+	}
+	outboxEnabled = true
+	if cfg.RetryInterval > 0 {
+		go runOutboxRetry(cfg.RetryInterval)
+	}
+}
+
+// runOutboxRetry 按 interval 检查离线队列，有待处理的操作时尝试重放
+func runOutboxRetry(interval time.Duration) {
+	logger := globalinit.GetLogger()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		pending, err := taskMirror.ListOutbox(mirror.OutboxPending)
+		if err != nil || len(pending) == 0 {
+			continue
+		}
+		if err := ensureClientInitialized(); err != nil {
+			continue
+		}
+		report, err := replayOutbox(nil)
+		if report != "" {
+			logger.Infof("Outbox replay:\n%s", report)
+		}
+		if err != nil {
+			logger.Infof("Outbox replay stopped: %v", err)
+		}
+	}
+}
+
+// outboxExempt 离线队列非空时仍可调用的修改类工具：可排队的操作本身、队列管理工具和授权
+var outboxExempt = map[string]bool{
+	opCreateTask:           true,
+	opUpdateTask:           true,
+	opCompleteTask:         true,
+	opDeleteTask:           true,
+	"replay_outbox":        true,
+	"discard_outbox_entry": true,
+	"oauth_authorize":      true,
+}
+
+// checkOutboxEmpty 离线队列中还有待重放的操作时返回错误
+// 其他修改不经过队列，先于排队的操作生效会打乱修改顺序，因此在队列清空前拒绝执行
+func checkOutboxEmpty(action string) error {
+	if !outboxEnabled {
+		return nil
+	}
+	pending, err := taskMirror.ListOutbox(mirror.OutboxPending)
+	if err != nil {
+		return fmt.Errorf("error reading offline queue: %v", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d operation(s) are still waiting in the offline queue; %s is unavailable until they are applied, so changes stay in order. Call replay_outbox once TickTick is reachable, or discard_outbox_entry to drop them",
+			len(pending), action)
+	}
+	return nil
+}
+
+// waitsForOutbox 离线队列非空时拒绝调用修改类工具
+func waitsForOutbox(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := checkOutboxEmpty(name); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// withIdempotencyKey 为可离线排队的工具添加幂等键参数
+func withIdempotencyKey() mcp.ToolOption {
+	return mcp.WithString("idempotency_key",
+		mcp.Description("Optional idempotency key. Repeating a call with the same key returns the result of the first call (or its offline queue entry when TickTick was unreachable) instead of applying it twice."),
+	)
+}
+
+// runWithOutbox 执行可离线排队的操作
+// API 不可达时将操作加入离线队列；队列中还有待重放的操作时，新操作排在其后以保持顺序
+func runWithOutbox(op string, request mcp.CallToolRequest) *mcp.CallToolResult {
+	apply := outboxOperations[op]
+	if !outboxEnabled {
+		message, err := apply(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error())
+		}
+		return mcp.NewToolResultText(message)
+	}
+
+	key := request.GetString("idempotency_key", "")
+	remember := key != ""
+	if remember {
+		// 相同幂等键的调用串行执行，避免并发的重复调用在记录结果前都被执行
+		idempotencyMu.Lock()
+		defer idempotencyMu.Unlock()
+		if entry, err := taskMirror.OutboxByKey(key); err == nil {
+			return describeOutboxEntry(entry, "An operation with this idempotency key already exists.")
+		}
+	}
+	pending, err := taskMirror.ListOutbox(mirror.OutboxPending)
+	if err != nil {
+		return mcp.NewToolResultErrorf("Error reading offline queue: %v", err)
+	}
+	if len(pending) > 0 {
+		entry, err := enqueueOperation(op, request, key, nil, time.Time{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error())
+		}
+		replayOutbox(nil)
+		if entry, err = taskMirror.OutboxByID(entry.ID); err != nil {
+			return mcp.NewToolResultErrorf("Error reading offline queue: %v", err)
+		}
+		return describeOutboxEntry(entry, "")
+	}
+
+	sentAt := time.Now()
+	message, err := apply(request)
+	if err == nil {
+		if remember {
+			recordApplied(op, request, key, message)
+		}
+		return mcp.NewToolResultText(message)
+	}
+	if !errors.IsUnavailable(err) {
+		return mcp.NewToolResultError(err.Error())
+	}
+	entry, qerr := enqueueOperation(op, request, key, err, sentAt)
+	if qerr != nil {
+		return mcp.NewToolResultErrorf("%v (could not queue offline: %v)", err, qerr)
+	}
+	return describeOutboxEntry(entry, "")
+}
+
+// recordApplied 将直接执行成功的操作以已完成状态记入队列，相同幂等键的重复调用返回这次的结果
+// 记录失败只写日志，操作本身已经生效
+func recordApplied(op string, request mcp.CallToolRequest, key, message string) {
+	args, err := normalizeOutboxArgs(request.GetArguments())
+	if err != nil {
+		args = nil
+	}
+	entry := &mirror.OutboxEntry{
+		Key:       key,
+		Operation: op,
+		ProjectID: request.GetString("project_id", ""),
+		TaskID:    request.GetString("task_id", ""),
+		Args:      args,
+		Status:    mirror.OutboxDone,
+		Attempts:  1,
+		Result:    message,
+	}
+	if _, err := taskMirror.Enqueue(entry); err != nil {
+		globalinit.GetLogger().Infof("Could not record idempotency key %s: %v", key, err)
+	}
+	taskMirror.PruneOutbox(time.Now().Add(-outboxRetention))
+}
+
+// enqueueOperation 校验参数并将操作加入离线队列
+// 相对日期在排队时转换为绝对时间；任务在本地镜像中有副本时记录下来，用于重放前检测冲突
+// cause 为导致排队的错误，非 nil 时表示在 sentAt 直接发送的请求可能已经到达服务器，重放创建操作前会先查重
+func enqueueOperation(op string, request mcp.CallToolRequest, key string, cause error, sentAt time.Time) (*mirror.OutboxEntry, error) {
+	args, err := normalizeOutboxArgs(request.GetArguments())
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	entry := &mirror.OutboxEntry{
+		Key:       key,
+		Operation: op,
+		ProjectID: request.GetString("project_id", ""),
+		TaskID:    request.GetString("task_id", ""),
+		Args:      args,
+	}
+	if entry.Key == "" {
+		entry.Key = uuid.NewString()
+	}
+	if cause != nil {
+		entry.Attempts, entry.LastError, entry.SentAt = 1, cause.Error(), sentAt
+	}
+
+	if op == opCreateTask {
+		if _, err := buildTaskFromArgs(newToolRequest(op, args)); err != nil {
+			return nil, err
+		}
+	} else {
+		if entry.ProjectID == "" || entry.TaskID == "" {
+			return nil, fmt.Errorf("project_id and task_id are required")
+		}
+		if base, err := taskMirror.CachedTask(entry.ProjectID, entry.TaskID); err == nil {
+			entry.Base = base
+		}
+	}
+
+	if _, err := taskMirror.Enqueue(entry); err != nil {
+		return nil, fmt.Errorf("error queueing operation: %v", err)
+	}
+	return entry, nil
+}
+
+// normalizeOutboxArgs 复制参数并将自然语言日期转换为绝对时间，避免重放时按重放时刻解析
+func normalizeOutboxArgs(args map[string]any) (map[string]any, error) {
+	now := time.Now().In(userLocation)
+	normalized := make(map[string]any, len(args))
+	for name, value := range args {
+		if name == "idempotency_key" {
+			continue
+		}
+		text, ok := value.(string)
+		if (name == "start_date" || name == "due_date") && ok && text != "" {
+			result, err := dateparse.Parse(text, now)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", name, err)
+			}
+			if result.AllDay {
+				value = result.Time.In(userLocation).Format("2006-01-02")
+			} else {
+				value = result.Time.Format(time.RFC3339)
+			}
+		}
+		normalized[name] = value
+	}
+	return normalized, nil
+}
+
+// replayOutbox 按排队顺序重放待处理的操作，force 中的条目跳过冲突检测
+// API 仍不可达时停止并返回错误，剩余条目保持排队；返回本次处理的条目报告
+func replayOutbox(force map[int64]bool) (string, error) {
+	outboxMu.Lock()
+	defer outboxMu.Unlock()
+
+	pending, err := taskMirror.ListOutbox(mirror.OutboxPending)
+	if err != nil {
+		return "", err
+	}
+	// 任务在条目排队后被队列中之前的操作修改过时，条目记录的副本已过时，以之前的冲突检测为准
+	done, err := taskMirror.ListOutbox(mirror.OutboxDone)
+	if err != nil {
+		return "", err
+	}
+	touched := make(map[string]time.Time)
+	for _, entry := range done {
+		if entry.UpdatedAt.After(touched[entry.TaskID]) {
+			touched[entry.TaskID] = entry.UpdatedAt
+		}
+	}
+
+	var report strings.Builder
+	applied := 0
+	defer func() {
+		if applied > 0 {
+			invalidateMirror()
+		}
+	}()
+
+	for i := range pending {
+		entry := &pending[i]
+		entry.Attempts++
+		status, result, err := replayEntry(entry, force[entry.ID] || touched[entry.TaskID].After(entry.CreatedAt))
+		if err != nil && errors.IsUnavailable(err) {
+			entry.LastError = err.Error()
+			taskMirror.UpdateOutbox(entry)
+			return report.String(), fmt.Errorf("TickTick is still unreachable, %d operation(s) remain queued: %v", len(pending)-i, err)
+		}
+		entry.Status, entry.Result, entry.LastError = status, result, ""
+		if err != nil {
+			entry.LastError = err.Error()
+		}
+		if status == mirror.OutboxDone {
+			applied++
+			touched[entry.TaskID] = time.Now()
+		}
+		if uerr := taskMirror.UpdateOutbox(entry); uerr != nil {
+			return report.String(), fmt.Errorf("error updating offline queue: %v", uerr)
+		}
+		fmt.Fprintf(&report, "#%d %s: %s\n", entry.ID, entry.Operation, firstLine(outboxSummary(entry)))
+	}
+	if err := taskMirror.PruneOutbox(time.Now().Add(-outboxRetention)); err != nil {
+		return report.String(), err
+	}
+	return report.String(), nil
+}
+
+// replayEntry 重放单个条目，返回新的状态和结果说明
+// 返回不可达错误时调用方保持条目排队
+func replayEntry(entry *mirror.OutboxEntry, force bool) (string, string, error) {
+	request := newToolRequest(entry.Operation, entry.Args)
+
+	if entry.Operation == opCreateTask {
+		// 之前的尝试可能已到达服务器，先查找是否已经创建
+		if entry.Attempts > 1 {
+			existing, err := findCreatedTask(entry)
+			if err != nil {
+				return "", "", err
+			}
+			if existing != nil {
+				return mirror.OutboxDone, fmt.Sprintf("Task was already created by an earlier attempt:\n%s", FormatTask(*removeCreateKey(existing))), nil
+			}
+		}
+		// 重放的请求在正文中记录幂等键，响应丢失时下次重放据此找到已创建的任务，确认创建后再删除
+		created, err := createTaskFromArgs(newToolRequest(entry.Operation, withCreateKey(entry.Args, entry.Key)))
+		if err != nil {
+			if errors.IsUnavailable(err) {
+				return "", "", err
+			}
+			return mirror.OutboxFailed, "The server rejected the operation.", err
+		}
+		return mirror.OutboxDone, fmt.Sprintf("Task created successfully:\n%s", FormatTask(*removeCreateKey(created))), nil
+	}
+
+	if entry.Base != nil && !force {
+		current, err := ticktickClient.Fresh().GetTask(entry.ProjectID, entry.TaskID)
+		if err != nil {
+			if errors.IsUnavailable(err) {
+				return "", "", err
+			}
+			if errors.GetStatusCode(err) == http.StatusNotFound {
+				return mirror.OutboxConflict, "The task no longer exists on the server.", err
+			}
+			return mirror.OutboxFailed, "Could not fetch the task to check for conflicts.", err
+		}
+		if fields := conflictFields(*entry.Base, *current); len(fields) > 0 {
+			return mirror.OutboxConflict, fmt.Sprintf("The task was changed on the server while the operation was queued (%s). Use replay_outbox with force to apply it anyway, or discard_outbox_entry to drop it.\nServer copy:\n%s",
+				strings.Join(fields, ", "), FormatTask(*current)), nil
+		}
+	}
+
+	message, err := outboxOperations[entry.Operation](request)
+	if err != nil {
+		if errors.IsUnavailable(err) {
+			return "", "", err
+		}
+		return mirror.OutboxFailed, "The server rejected the operation.", err
+	}
+	return mirror.OutboxDone, message, nil
+}
+
+// conflictFields 比较排队时的副本和服务器上的当前任务，返回被修改的字段
+func conflictFields(base, current client.Task) []string {
+	fields := syncer.ChangedFields(base, current)
+	if base.Status != current.Status {
+		fields = append(fields, "status")
+	}
+	if mirror.ProjectKey(base.ProjectID) != mirror.ProjectKey(current.ProjectID) {
+		fields = append(fields, "project")
+	}
+	return fields
+}
+
+// sentMatchWindow 按创建时间匹配排队前直接发送的创建请求时允许的偏差，覆盖请求超时和服务器时钟偏差
+const sentMatchWindow = 2 * time.Minute
+
+// findCreatedTask 在目标项目中查找之前的尝试已创建的任务
+// 重放的请求在正文中记录了幂等键；排队前直接发送的请求没有标记，按标题和任务 ID 中的创建时间匹配
+func findCreatedTask(entry *mirror.OutboxEntry) (*client.Task, error) {
+	projectID, _ := entry.Args["project_id"].(string)
+	if projectID == "" {
+		projectID = client.InboxProjectID
+	}
+	data, err := ticktickClient.Fresh().GetProjectWithData(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range data.Tasks {
+		if contentKey(existing.Content) == entry.Key {
+			return &existing, nil
+		}
+	}
+	if entry.SentAt.IsZero() {
+		return nil, nil
+	}
+	// 标题中的标签会被移出，与实际发送的标题比较
+	task, err := buildTaskFromArgs(newToolRequest(entry.Operation, entry.Args))
+	if err != nil {
+		return nil, nil
+	}
+	for _, existing := range data.Tasks {
+		created, ok := existing.CreatedAt()
+		if !ok || existing.Title != task.Title || contentKey(existing.Content) != "" {
+			continue
+		}
+		if d := created.Sub(entry.SentAt); d > -sentMatchWindow && d < sentMatchWindow {
+			return &existing, nil
+		}
+	}
+	return nil, nil
+}
+
+// createKeyMarker 重放 create_task 时在正文中记录幂等键的行前缀
+const createKeyMarker = "idempotency-key: "
+
+// withCreateKey 复制 create_task 的参数，在正文末尾追加记录幂等键的行
+func withCreateKey(args map[string]any, key string) map[string]any {
+	marked := make(map[string]any, len(args)+1)
+	for name, value := range args {
+		marked[name] = value
+	}
+	line := createKeyMarker + key
+	if content, _ := args["content"].(string); content != "" {
+		line = content + "\n\n" + line
+	}
+	marked["content"] = line
+	return marked
+}
+
+// removeCreateKey 删除已确认创建的任务正文中记录幂等键的行，返回更新后的任务
+// 更新失败只写日志，任务保留该行
+func removeCreateKey(task *client.Task) *client.Task {
+	if contentKey(task.Content) == "" {
+		return task
+	}
+	var kept []string
+	for _, line := range strings.Split(task.Content, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), createKeyMarker) {
+			kept = append(kept, line)
+		}
+	}
+	cleaned := *task
+	cleaned.Content = strings.TrimRight(strings.Join(kept, "\n"), "\n")
+	updated, err := ticktickClient.UpdateTask(cleaned)
+	if err != nil {
+		globalinit.GetLogger().Infof("Could not remove the idempotency key from task %s: %v", task.ID, err)
+		return task
+	}
+	return updated
+}
+
+// contentKey 返回正文中记录的幂等键，没有时返回空字符串
+func contentKey(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if key, ok := strings.CutPrefix(strings.TrimSpace(line), createKeyMarker); ok {
+			return strings.TrimSpace(key)
+		}
+	}
+	return ""
+}
+
+// describeOutboxEntry 将队列条目的状态转换为工具结果，冲突和失败作为错误返回
+func describeOutboxEntry(entry *mirror.OutboxEntry, prefix string) *mcp.CallToolResult {
+	text := outboxSummary(entry)
+	if prefix != "" {
+		text = prefix + "\n" + text
+	}
+	switch entry.Status {
+	case mirror.OutboxConflict, mirror.OutboxFailed:
+		return mcp.NewToolResultError(text)
+	}
+	return mcp.NewToolResultText(text)
+}
+
+// outboxSummary 描述队列条目的当前状态
+func outboxSummary(entry *mirror.OutboxEntry) string {
+	switch entry.Status {
+	case mirror.OutboxPending:
+		text := fmt.Sprintf("TickTick is unreachable, so the %s was queued offline as outbox entry #%d (idempotency key %s). It will be replayed in order once the connection returns; use get_outbox to check its status.",
+			strings.ReplaceAll(entry.Operation, "_", " "), entry.ID, entry.Key)
+		if entry.LastError != "" {
+			text += "\nLast error: " + entry.LastError
+		}
+		return text
+	case mirror.OutboxDone:
+		return entry.Result
+	case mirror.OutboxConflict:
+		return fmt.Sprintf("Outbox entry #%d (%s) was not applied because of a conflict: %s", entry.ID, entry.Operation, entry.Result)
+	default:
+		return fmt.Sprintf("Outbox entry #%d (%s) failed: %s %s", entry.ID, entry.Operation, entry.Result, entry.LastError)
+	}
+}
+
+// firstLine 返回文本的第一行
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
+
+// applyCreateTask 执行 create_task
+func applyCreateTask(request mcp.CallToolRequest) (string, error) {
+	createdTask, err := createTaskFromArgs(request)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Task created successfully:\n%s", FormatTask(*createdTask)), nil
+}
+
+// applyUpdateTask 执行 update_task
+func applyUpdateTask(request mcp.CallToolRequest) (string, error) {
+	current, updatedTask, err := updateTaskFromArgs(request)
+	if err != nil {
+		return "", err
+	}
+	if updatedTask == nil {
+		return fmt.Sprintf("No changes to apply:\n%s", FormatTask(*current)), nil
+	}
+	return fmt.Sprintf("Task updated successfully:\n%s\n%s",
+		FormatTask(*updatedTask), FormatTaskChanges(DiffTasks(*current, *updatedTask))), nil
+}
+
+// applyCompleteTask 执行 complete_task
+func applyCompleteTask(request mcp.CallToolRequest) (string, error) {
+	projectID, taskID, err := taskRef(request)
+	if err != nil {
+		return "", err
+	}
+	if err := ticktickClient.CompletedTask(projectID, taskID); err != nil {
+		return "", fmt.Errorf("failed to complete task: %w", err)
+	}
+	return "Task completed successfully!\n", nil
+}
+
+// applyDeleteTask 执行 delete_task
+func applyDeleteTask(request mcp.CallToolRequest) (string, error) {
+	projectID, taskID, err := taskRef(request)
+	if err != nil {
+		return "", err
+	}
+	if err := ticktickClient.DeleteTask(projectID, taskID); err != nil {
+		return "", fmt.Errorf("failed to delete task: %w", err)
+	}
+	return "Task deleted successfully!\n", nil
+}
+
+// parseEntryID 解析 "#12" 或 "12" 形式的记录 ID，kind 用于错误信息
+func parseEntryID(value, kind string) (int64, error) {
+	var id int64
+	if _, err := fmt.Sscanf(strings.TrimPrefix(strings.TrimSpace(value), "#"), "%d", &id); err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s ID %q", kind, value)
+	}
+	return id, nil
+}
+
+// isNotFound 判断是否为镜像中不存在的错误
+func isNotFound(err error) bool {
+	return stderrors.Is(err, mirror.ErrNotFound)
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"dida/internal/client"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// maxConcurrentFetches 跨项目拉取任务时的最大并发请求数
+const maxConcurrentFetches = 4
+
+// projectTasks 一个项目及其未完成任务
+type projectTasks struct {
+	Project client.Project
+	Tasks   []client.Task
+}
+
+// fetchAllProjectTasks 并发获取所有可见项目（含收集箱）的任务，并发数受 maxConcurrentFetches 限制
+// 单个项目获取失败不影响其他项目，失败信息通过 errs 返回；本地镜像新鲜时直接读取镜像，refresh 为 true 时跳过镜像和缓存
+func fetchAllProjectTasks(ctx context.Context, refresh bool) (results []projectTasks, errs []error, err error) {
+	projects, err := loadProjects(refresh)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching projects: %v", err)
+	}
+	projects = visibleProjects(append([]client.Project{client.InboxProject()}, projects...))
+
+	results = make([]projectTasks, len(projects))
+	fetchErrs := make([]error, len(projects))
+	sem := make(chan struct{}, maxConcurrentFetches)
+	var wg sync.WaitGroup
+
+	for i, project := range projects {
+		wg.Add(1)
+		go func(i int, project client.Project) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				fetchErrs[i] = fmt.Errorf("project %s: %v", project.Name, ctx.Err())
+				return
+			}
+
+			data, err := loadProjectData(project.ID, refresh)
+			if err != nil {
+				fetchErrs[i] = fmt.Errorf("project %s: %v", project.Name, err)
+				return
+			}
+			results[i] = projectTasks{Project: project, Tasks: data.Tasks}
+		}(i, project)
+	}
+	wg.Wait()
+
+	// 保持项目原有顺序，剔除失败的项目
+	fetched := results[:0]
+	for i, result := range results {
+		if fetchErrs[i] != nil {
+			errs = append(errs, fetchErrs[i])
+			continue
+		}
+		fetched = append(fetched, result)
+	}
+	return fetched, errs, nil
+}
+
+// formatFetchErrors 将部分项目获取失败的信息格式化为提示
+func formatFetchErrors(errs []error) string {
+	if len(errs) == 0 {
+		return ""
+	}
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = "- " + err.Error()
+	}
+	return fmt.Sprintf("\nWarning: %d project(s) could not be fetched:\n%s\n", len(errs), strings.Join(messages, "\n"))
+}
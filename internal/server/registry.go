@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"dida/globalinit"
+	"dida/internal/client"
+	"dida/internal/config"
+	"dida/internal/policy"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolPolicy 当前生效的工具访问策略
+var toolPolicy = policy.New(config.PolicyConfig{})
+
+// toolRegistrar 按访问策略向 MCP 服务器注册工具
+type toolRegistrar struct {
+	server *server.MCPServer
+	policy *policy.Policy
+}
+
+func newToolRegistrar(s *server.MCPServer, p *policy.Policy) *toolRegistrar {
+	return &toolRegistrar{server: s, policy: p}
+}
+
+// AddTool 注册工具；被策略禁止的工具直接跳过，
+// 配置了项目白名单时为处理函数加上项目校验，修改类工具在离线队列非空时拒绝执行、执行后使本地镜像过期，
+// 启用审计日志时记录每次调用（包括被项目校验拒绝的调用）
+func (r *toolRegistrar) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	logger := globalinit.GetLogger()
+
+	readOnly := tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint
+	if !r.policy.AllowTool(tool.Name, readOnly) {
+		logger.Infof("Tool %s disabled by policy", tool.Name)
+		return
+	}
+	if !readOnly && !outboxExempt[tool.Name] {
+		handler = waitsForOutbox(tool.Name, handler)
+	}
+	if r.policy.RestrictsProjects() {
+		handler = r.restrictProjects(handler)
+	}
+	if !readOnly {
+		handler = invalidatesMirror(handler)
+	}
+	if auditLog != nil {
+		handler = audited(tool.Name, handler)
+	}
+	r.server.AddTool(tool, handler)
+}
+
+// invalidatesMirror 在修改类工具执行后将本地镜像标记为过期
+func invalidatesMirror(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		defer invalidateMirror()
+		return next(ctx, request)
+	}
+}
+
+// restrictProjects 拒绝引用了白名单以外项目的调用
+func (r *toolRegistrar) restrictProjects(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		for _, projectID := range collectProjectIDs(request.GetArguments()) {
+			if !allowProject(r.policy, projectID) {
+				return mcp.NewToolResultErrorf("Access to project %s is not allowed by the server policy", projectID), nil
+			}
+		}
+		return next(ctx, request)
+	}
+}
+
+// collectProjectIDs 递归收集参数中所有 project_id / *_project_id 的值，以及 project_ids 列表中的值
+func collectProjectIDs(value any) []string {
+	var ids []string
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if id, ok := item.(string); ok && id != "" && (key == "project_id" || strings.HasSuffix(key, "_project_id")) {
+				ids = append(ids, id)
+				continue
+			}
+			if list, ok := item.([]any); ok && (key == "project_ids" || strings.HasSuffix(key, "_project_ids")) {
+				for _, element := range list {
+					if id, ok := element.(string); ok && id != "" {
+						ids = append(ids, id)
+					}
+				}
+				continue
+			}
+			ids = append(ids, collectProjectIDs(item)...)
+		}
+	case []any:
+		for _, item := range v {
+			ids = append(ids, collectProjectIDs(item)...)
+		}
+	}
+	return ids
+}
+
+// allowProject 判断策略是否允许访问项目；收集箱的各种 ID 统一按 "inbox" 判断
+func allowProject(p *policy.Policy, projectID string) bool {
+	if client.IsInbox(projectID) {
+		projectID = client.InboxProjectID
+	}
+	return p.AllowProject(projectID)
+}
+
+// visibleProjects 过滤掉策略不允许访问的项目
+func visibleProjects(projects []client.Project) []client.Project {
+	if !toolPolicy.RestrictsProjects() {
+		return projects
+	}
+	visible := make([]client.Project, 0, len(projects))
+	for _, project := range projects {
+		if allowProject(toolPolicy, project.ID) {
+			visible = append(visible, project)
+		}
+	}
+	return visible
+}
@@ -3,6 +3,8 @@ package server
 import (
 	"dida/globalinit"
 	"dida/internal/client"
+	"dida/internal/config"
+	"dida/internal/policy"
 	"fmt"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -19,6 +21,19 @@ func ensureClientInitialized() error {
 	return nil
 }
 
+// attachClientHooks 为已创建的客户端设置修改日志和审计回调
+func attachClientHooks() {
+	if ticktickClient == nil {
+		return
+	}
+	if journalEnabled {
+		ticktickClient.SetMutationRecorder(recordMutation)
+	}
+	if auditLog != nil {
+		ticktickClient.SetRequestObserver(observeRequest)
+	}
+}
+
 func InitializeClient() bool {
 	var err error
 	logger := globalinit.GetLogger()
@@ -29,6 +44,7 @@ func InitializeClient() bool {
 		logger.Error("Please check your .env file and ensure TICKTICK_CLIENT_ID and TICKTICK_CLIENT_SECRET are set.")
 		return false
 	}
+	attachClientHooks()
 
 	// 检查是否有访问令牌
 	if ticktickClient.GetAccessToken() == "" {
@@ -47,6 +63,41 @@ func InitializeClient() bool {
 	return true
 }
 
+// applyConfig 应用访问策略、用户时区和模板目录配置
+func applyConfig(cfg *config.Config) error {
+	var err error
+	toolPolicy = policy.New(cfg.Policy)
+	if userLocation, err = cfg.User.Location(); err != nil {
+		return err
+	}
+	userTimeZone = cfg.User.TimeZone
+	templateDir = cfg.Templates.Dir
+	return nil
+}
+
+// InitCommand 为命令行子命令加载配置、打开本地镜像和审计日志并初始化客户端，不启动 MCP 服务器
+func InitCommand() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if err := applyConfig(cfg); err != nil {
+		return err
+	}
+	initMirror(cfg.Mirror)
+	// 命令行不在后台重放，只用于检查离线队列是否为空
+	initOutbox(config.OutboxConfig{Enabled: cfg.Outbox.Enabled})
+	initJournal(cfg.Journal)
+	initAudit(cfg.Audit)
+	if err := ensureClientInitialized(); err != nil {
+		return err
+	}
+	if ticktickClient.GetAccessToken() == "" {
+		return fmt.Errorf("no access token found, start the server and complete OAuth2 authorization with the oauth_authorize tool first")
+	}
+	return nil
+}
+
 func Start() error {
 	logger := globalinit.GetLogger()
 	// 初始化TickTick客户端
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"dida/globalinit"
+	"dida/internal/config"
+	"dida/internal/syncer"
+	"time"
+)
+
+// syncEngine 同步引擎，本地镜像未启用时为 nil
+var syncEngine *syncer.Engine
+
+// initSync 创建同步引擎，配置了同步间隔时在后台定期同步
+func initSync(cfg config.SyncConfig) {
+	if taskMirror == nil || syncEngine != nil {
+		return
+	}
+	syncEngine = syncer.New(syncSource, taskMirror, cfg.ChangeRetention)
+	if cfg.Interval > 0 {
+		go runPeriodicSync(cfg.Interval)
+	}
+}
+
+// syncSource 返回跳过缓存的 TickTick 客户端作为同步数据来源
+func syncSource() (syncer.Source, error) {
+	if err := ensureClientInitialized(); err != nil {
+		return nil, err
+	}
+	return ticktickClient.Fresh(), nil
+}
+
+// runPeriodicSync 启动时立即同步一次，之后按 interval 定期同步
+func runPeriodicSync(interval time.Duration) {
+	logger := globalinit.GetLogger()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		result, err := syncNow(context.Background())
+		if err != nil {
+			logger.Errorf("Background sync failed: %v", err)
+		} else {
+			logger.Infof("Background sync finished: %d changes, %d project(s) failed", len(result.Changes), len(result.Errors))
+		}
+		<-ticker.C
+	}
+}
+
+// syncNow 执行一次完整同步，并用拉取到的数据刷新本地镜像
+func syncNow(ctx context.Context) (*syncer.Result, error) {
+	logger := globalinit.GetLogger()
+	generation := mirrorGeneration.Load()
+	result, err := syncEngine.Sync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 同步期间发生过修改时不刷新，避免把修改前的数据标记为新鲜
+	if mirrorGeneration.Load() != generation {
+		return result, nil
+	}
+	if err := taskMirror.SaveProjects(result.Projects, result.SyncedAt); err != nil {
+		logger.Errorf("Failed to save projects to local mirror: %v", err)
+	}
+	for key, data := range result.Data {
+		if err := taskMirror.SaveProjectData(key, data, result.SyncedAt); err != nil {
+			logger.Errorf("Failed to save project %s to local mirror: %v", key, err)
+		}
+	}
+	return result, nil
+}
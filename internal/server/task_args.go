@@ -0,0 +1,245 @@
+package server
+
+import (
+	"dida/internal/client"
+	"dida/internal/dateparse"
+	"dida/internal/recurrence"
+	"dida/internal/reminder"
+	"dida/internal/tags"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// userLocation 用户时区，自然语言日期以此为基准
+var userLocation = time.Local
+
+// userTimeZone 用户时区的 IANA 名称，未配置时为空
+var userTimeZone string
+
+// validPriorities TickTick 支持的优先级取值
+var validPriorities = map[int]bool{0: true, 1: true, 3: true, 5: true}
+
+// dateArgDescription start_date / due_date 参数的说明
+const dateArgDescription = `%s, either ISO format (2026-11-01T15:00:00Z, 2026-11-01) or natural language in English or Chinese, e.g. "tomorrow 3pm", "next Friday", "in 3 days", "下周一上午九点". Date-only values make the task all-day.`
+
+// reminderArgDescription reminders 参数的说明
+const reminderArgDescription = `Reminders relative to the task's start time (its due time when it has no start date), e.g. "on time", "15m before", "2h before", "1d before 9:00" (all-day tasks). Replaces existing reminders; pass an empty array to clear.`
+
+// tagsArgDescription tags 参数的说明
+const tagsArgDescription = `Tags of the task, replacing existing tags; pass an empty array to clear. Inline #tags in the title are also added as tags.`
+
+// repeatArgDescription repeat 参数的说明
+const repeatArgDescription = `Recurrence, e.g. "daily", "every weekday", "every 2 weeks on Mon,Thu", "monthly on last Friday", "every year on Mar 15", optionally followed by "for 5 times" or "until 2026-12-31". A raw RRULE is also accepted. Pass an empty string to stop repeating.`
+
+// applyTaskDates 解析 start_date / due_date，并根据是否只给出日期设置全天标记和时区
+func applyTaskDates(task *client.Task, request mcp.CallToolRequest) error {
+	args := request.GetArguments()
+	now := time.Now().In(userLocation)
+
+	supplied, allDay := false, true
+	for _, field := range []struct {
+		name   string
+		target *client.Time
+	}{
+		{"start_date", &task.StartDate},
+		{"due_date", &task.DueDate},
+	} {
+		if _, ok := args[field.name]; !ok {
+			continue
+		}
+		value := request.GetString(field.name, "")
+		if value == "" {
+			*field.target = client.ClearTime()
+			continue
+		}
+		result, err := dateparse.Parse(value, now)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %v", field.name, err)
+		}
+		*field.target = client.NewTime(result.Time)
+		supplied = true
+		allDay = allDay && result.AllDay
+	}
+
+	if supplied {
+		task.IsAllDay = allDay
+		if userTimeZone != "" {
+			task.TimeZone = userTimeZone
+		}
+	}
+	return nil
+}
+
+// parsePriority 严格解析优先级：接受整数或整数字符串（模板渲染的参数为字符串），小数和其他取值报错
+func parsePriority(value any) (int, error) {
+	priority := -1
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) {
+			priority = int(v)
+		}
+	case int:
+		priority = v
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			priority = n
+		}
+	}
+	if !validPriorities[priority] {
+		return 0, fmt.Errorf("invalid priority %v, expected one of 0, 1, 3, 5", value)
+	}
+	return priority, nil
+}
+
+// applyTaskArgs 将请求中显式提供的字段合并到任务上
+// 未提供的字段保持原值，提供空字符串表示清除该字段
+func applyTaskArgs(task *client.Task, request mcp.CallToolRequest) error {
+	args := request.GetArguments()
+
+	if _, ok := args["tags"]; ok {
+		task.Tags = tags.Merge(request.GetStringSlice("tags", nil))
+	}
+	if _, ok := args["title"]; ok {
+		// 标题中的 #标签 提取为任务标签
+		title, inline := tags.ParseTitle(request.GetString("title", ""))
+		if title == "" {
+			return fmt.Errorf("title cannot be empty")
+		}
+		task.Title = title
+		task.Tags = tags.Merge(task.Tags, inline)
+	}
+	if _, ok := args["content"]; ok {
+		task.Content = request.GetString("content", "")
+	}
+	if err := applyTaskDates(task, request); err != nil {
+		return err
+	}
+	if value, ok := args["priority"]; ok {
+		priority, err := parsePriority(value)
+		if err != nil {
+			return err
+		}
+		task.Priority = priority
+	}
+	if _, ok := args["reminders"]; ok {
+		reminders := request.GetStringSlice("reminders", nil)
+		triggers := make([]string, 0, len(reminders))
+		for _, r := range reminders {
+			trigger, err := reminder.ToTrigger(r)
+			if err != nil {
+				return err
+			}
+			triggers = append(triggers, trigger)
+		}
+		task.Reminders = triggers
+	}
+	if _, ok := args["repeat"]; ok {
+		repeat := request.GetString("repeat", "")
+		if repeat == "" {
+			task.RepeatFlag = ""
+		} else {
+			rule, err := recurrence.FromText(repeat)
+			if err != nil {
+				return err
+			}
+			task.RepeatFlag = rule.String()
+		}
+	}
+
+	// 提醒相对于任务时间触发，没有日期的任务无法提醒
+	if len(task.Reminders) > 0 && task.StartDate.IsZero() && task.DueDate.IsZero() {
+		return fmt.Errorf("reminders require the task to have a start_date or due_date")
+	}
+	// 重复规则从任务的日期开始计算
+	if task.RepeatFlag != "" && task.StartDate.IsZero() && task.DueDate.IsZero() {
+		return fmt.Errorf("repeat requires the task to have a start_date or due_date")
+	}
+	return nil
+}
+
+// buildTaskFromArgs 根据 create_task 的参数构造新任务（不发送请求），未指定项目时放入收集箱
+func buildTaskFromArgs(request mcp.CallToolRequest) (client.Task, error) {
+	// 未指定项目时不发送 projectId，任务会创建到收集箱
+	projectID := request.GetString("project_id", "")
+	if client.IsInbox(projectID) {
+		projectID = ""
+	}
+	if toolPolicy.RestrictsProjects() {
+		policyID := projectID
+		if policyID == "" {
+			policyID = client.InboxProjectID
+		}
+		if !allowProject(toolPolicy, policyID) {
+			return client.Task{}, fmt.Errorf("access to project %s is not allowed by the server policy", policyID)
+		}
+	}
+	if _, err := request.RequireString("title"); err != nil {
+		return client.Task{}, err
+	}
+
+	task := client.Task{
+		ProjectID: projectID,
+	}
+	if err := applyTaskArgs(&task, request); err != nil {
+		return client.Task{}, fmt.Errorf("invalid arguments: %v", err)
+	}
+	return task, nil
+}
+
+// createTaskFromArgs 根据 create_task 的参数创建任务
+func createTaskFromArgs(request mcp.CallToolRequest) (*client.Task, error) {
+	task, err := buildTaskFromArgs(request)
+	if err != nil {
+		return nil, err
+	}
+	createdTask, err := ticktickClient.CreateTask(task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	return createdTask, nil
+}
+
+// newToolRequest 用参数表构造工具请求，便于复用单任务工具的参数解析
+func newToolRequest(name string, args map[string]any) mcp.CallToolRequest {
+	var request mcp.CallToolRequest
+	request.Params.Name = name
+	request.Params.Arguments = args
+	return request
+}
+
+// updateTaskFromArgs 以读取-合并-写回的方式更新任务，只修改参数中提供的字段
+// 没有任何变化时 updated 为 nil，不会发送更新请求
+func updateTaskFromArgs(request mcp.CallToolRequest) (current, updated *client.Task, err error) {
+	taskID, err := request.RequireString("task_id")
+	if err != nil {
+		return nil, nil, err
+	}
+	projectID, err := request.RequireString("project_id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 先获取当前任务（跳过缓存），只合并显式提供的字段，避免覆盖其他字段
+	current, err = ticktickClient.Fresh().GetTask(projectID, taskID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching task: %w", err)
+	}
+	task := *current
+	if err := applyTaskArgs(&task, request); err != nil {
+		return nil, nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	if len(DiffTasks(*current, task)) == 0 {
+		return current, nil, nil
+	}
+
+	updated, err = ticktickClient.UpdateTask(task)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update task: %w", err)
+	}
+	return current, updated, nil
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"dida/internal/client"
+	"dida/internal/taskfile"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TaskExportOptions 导出项目任务的选项
+type TaskExportOptions struct {
+	// ProjectID 要导出的项目，"inbox" 表示收集箱
+	ProjectID string
+	// Format taskfile.FormatCSV 或 taskfile.FormatMarkdown
+	Format string
+	// Columns CSV 输出的列，为空时使用默认列
+	Columns []string
+	// Refresh 跳过本地镜像和缓存
+	Refresh bool
+}
+
+// TaskExport 导出结果
+type TaskExport struct {
+	Data []byte
+	// Tasks 导出的任务数
+	Tasks int
+	// Subtasks 导出的子任务数
+	Subtasks int
+}
+
+// ExportProjectTasks 将项目中的未完成任务及其子任务导出为 CSV 或 Markdown 清单
+func ExportProjectTasks(ctx context.Context, opts TaskExportOptions) (*TaskExport, error) {
+	if opts.ProjectID == "" {
+		return nil, fmt.Errorf("project ID is required")
+	}
+	projects, _, err := loadTasksOf(ctx, []string{opts.ProjectID}, opts.Refresh)
+	if err != nil {
+		return nil, err
+	}
+	project := projects[0]
+	// 按 TickTick 中的排序值排列任务和子任务，使导出顺序与应用中一致
+	tasks := make([]client.Task, len(project.Tasks))
+	copy(tasks, project.Tasks)
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].SortOrder < tasks[j].SortOrder })
+	for i := range tasks {
+		tasks[i].Items = client.SortedItems(tasks[i].Items)
+	}
+
+	exportOpts := taskfile.ExportOptions{Title: project.Project.Name, Location: userLocation, Columns: opts.Columns}
+	var buf bytes.Buffer
+	switch opts.Format {
+	case taskfile.FormatCSV:
+		err = taskfile.WriteCSV(&buf, tasks, exportOpts)
+	case taskfile.FormatMarkdown:
+		err = taskfile.WriteMarkdown(&buf, tasks, exportOpts)
+	default:
+		return nil, fmt.Errorf("invalid format %q: use %s or %s", opts.Format, taskfile.FormatCSV, taskfile.FormatMarkdown)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	export := &TaskExport{Data: buf.Bytes(), Tasks: len(tasks)}
+	for _, task := range tasks {
+		export.Subtasks += len(task.Items)
+	}
+	return export, nil
+}
+
+// TaskImportOptions 导入 CSV 或 Markdown 清单的选项
+type TaskImportOptions struct {
+	// ProjectID 目标项目，为空时导入到收集箱
+	ProjectID string
+	// Format taskfile.FormatCSV 或 taskfile.FormatMarkdown
+	Format string
+	// CSV CSV 的列映射和分隔符
+	CSV taskfile.CSVOptions
+}
+
+// taskImportEntry 一个待导入的条目及其构造结果
+type taskImportEntry struct {
+	taskfile.Entry
+	task client.Task
+	// err 不为空时该条目的参数无效，不会被创建
+	err error
+}
+
+// planTaskImport 读取文件内容并按 create_task 的规则构造任务（不发送请求）
+func planTaskImport(data []byte, opts TaskImportOptions) ([]taskImportEntry, error) {
+	var entries []taskfile.Entry
+	var err error
+	switch opts.Format {
+	case taskfile.FormatCSV:
+		entries, err = taskfile.ReadCSV(bytes.NewReader(data), opts.CSV)
+	case taskfile.FormatMarkdown:
+		entries, err = taskfile.ReadMarkdown(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("invalid format %q: use %s or %s", opts.Format, taskfile.FormatCSV, taskfile.FormatMarkdown)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s data: %v", opts.Format, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no tasks found")
+	}
+
+	planned := make([]taskImportEntry, len(entries))
+	for i, entry := range entries {
+		args := make(map[string]any, len(entry.Args)+1)
+		for key, value := range entry.Args {
+			args[key] = value
+		}
+		if opts.ProjectID != "" {
+			args["project_id"] = opts.ProjectID
+		}
+		task, err := buildTaskFromArgs(newToolRequest("create_task", args))
+		if err != nil {
+			planned[i] = taskImportEntry{Entry: entry, err: fmt.Errorf("line %d (%q): %v", entry.Line, entry.Title(), err)}
+			continue
+		}
+		for _, subtask := range entry.Subtasks {
+			item := client.TaskItem{Title: subtask.Title}
+			setItemCompleted(&item, subtask.Completed)
+			task.Items = append(task.Items, item)
+		}
+		planned[i] = taskImportEntry{Entry: entry, task: task}
+	}
+	return planned, nil
+}
+
+// PreviewTaskImport 返回导入将创建的任务，不实际创建
+func PreviewTaskImport(data []byte, opts TaskImportOptions) (string, error) {
+	entries, err := planTaskImport(data, opts)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	valid := 0
+	for _, entry := range entries {
+		if entry.err == nil {
+			valid++
+		}
+	}
+	fmt.Fprintf(&b, "Importing would create %d of %d tasks:\n", valid, len(entries))
+	for i, entry := range entries {
+		fmt.Fprintf(&b, "\nEntry %d (line %d):\n", i+1, entry.Line)
+		if entry.err != nil {
+			fmt.Fprintf(&b, "Invalid: %v\n", entry.err)
+			continue
+		}
+		b.WriteString(FormatTask(entry.task))
+		if entry.Completed {
+			b.WriteString("Will be marked completed\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// ImportTasks 按文件中的顺序创建任务及其子任务，返回逐项结果；参数无效的条目记为失败，不影响其他条目
+func ImportTasks(data []byte, opts TaskImportOptions) (string, error) {
+	if err := checkOutboxEmpty("import"); err != nil {
+		return "", err
+	}
+	entries, err := planTaskImport(data, opts)
+	if err != nil {
+		return "", err
+	}
+	results := make([]batchResult, 0, len(entries))
+	for _, entry := range entries {
+		if entry.err != nil {
+			results = append(results, batchResult{err: entry.err})
+			continue
+		}
+		created, err := ticktickClient.CreateTask(entry.task)
+		if err != nil {
+			results = append(results, batchResult{err: fmt.Errorf("failed to create %q: %v", entry.task.Title, err)})
+			continue
+		}
+		message := fmt.Sprintf("created %q (ID: %s, Project ID: %s)", created.Title, created.ID, created.ProjectID)
+		if len(entry.task.Items) > 0 {
+			message += fmt.Sprintf(" with %d subtasks", len(entry.task.Items))
+		}
+		if entry.Completed {
+			if err := ticktickClient.CompletedTask(created.ProjectID, created.ID); err != nil {
+				results = append(results, batchResult{err: fmt.Errorf("created %q (ID: %s) but could not mark it completed: %v", created.Title, created.ID, err)})
+				continue
+			}
+			message += ", marked completed"
+		}
+		results = append(results, batchResult{message: message})
+	}
+	return formatBatchResults("import", results), nil
+}
@@ -5,6 +5,7 @@ import (
 	"dida/globalinit"
 	"dida/internal/auth"
 	"dida/internal/client"
+	"dida/internal/config"
 	"fmt"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -14,18 +15,42 @@ import (
 func InitAllTools(s *server.MCPServer) error {
 	// 获取日志器
 	logger := globalinit.GetLogger()
+
+	// 加载工具访问策略
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if err := applyConfig(cfg); err != nil {
+		return err
+	}
+	initMirror(cfg.Mirror)
+	initSync(cfg.Sync)
+	initOutbox(cfg.Outbox)
+	initJournal(cfg.Journal)
+	initAudit(cfg.Audit)
+	initFeed(cfg.Feed)
+	if toolPolicy.ReadOnly() {
+		logger.Info("Read-only mode enabled, mutating tools will not be registered")
+	}
+	r := newToolRegistrar(s, toolPolicy)
+
 	// 添加工具：获取所有项目
 	getProjectsTool := mcp.NewTool("get_projects",
-		mcp.WithDescription("Get all projects from TickTick."),
+		mcp.WithDescription("Get all projects from TickTick. The inbox is listed as a pseudo-project with ID \"inbox\"."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		withRefresh(),
 	)
-	s.AddTool(getProjectsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r.AddTool(getProjectsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if err := ensureClientInitialized(); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		projects, err := ticktickClient.GetProjects()
+		projects, err := loadProjects(request.GetBool("refresh", false))
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error fetching projects: %v", err)), nil
 		}
+		// 收集箱不在项目列表中，作为伪项目放在最前面
+		projects = visibleProjects(append([]client.Project{client.InboxProject()}, projects...))
 
 		if len(projects) == 0 {
 			return mcp.NewToolResultText("No projects found."), nil
@@ -41,13 +66,15 @@ func InitAllTools(s *server.MCPServer) error {
 
 	// 获取特定项目
 	getProjectTool := mcp.NewTool("get_project",
-		mcp.WithDescription("Get details about a specific project."),
+		mcp.WithDescription("Get details about a specific project, including its columns for kanban projects."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("project_id",
 			mcp.Required(),
 			mcp.Description("ID of the project"),
 		),
+		withRefresh(),
 	)
-	s.AddTool(getProjectTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r.AddTool(getProjectTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if err := ensureClientInitialized(); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -58,24 +85,44 @@ func InitAllTools(s *server.MCPServer) error {
 			return mcp.NewToolResultErrorf(err.Error()), nil
 		}
 
+		if client.IsInbox(projectID) {
+			return mcp.NewToolResultText(FormatProject(client.InboxProject())), nil
+		}
+
 		// 获取项目
-		project, err := ticktickClient.GetProject(projectID)
+		refresh := request.GetBool("refresh", false)
+		project, err := loadProject(projectID, refresh)
 		if err != nil {
 			return mcp.NewToolResultErrorf(fmt.Sprintf("Error fetching project: %v", err)), nil
 		}
-		return mcp.NewToolResultText(FormatProject(*project)), nil
+		result := FormatProject(*project)
+
+		// 看板项目附带列信息
+		if project.ViewMode == client.ViewModeKanban {
+			projectData, err := loadProjectData(projectID, refresh)
+			if err != nil {
+				return mcp.NewToolResultErrorf("Error fetching project columns: %v", err), nil
+			}
+			result += FormatColumns(projectData.Columns, projectData.Tasks)
+		}
+		return mcp.NewToolResultText(result), nil
 
 	})
 
 	// 获取所有任务在指定Project中
 	getProjectTasks := mcp.NewTool("get_project_tasks",
-		mcp.WithDescription("Get all tasks from a specific project"),
+		mcp.WithDescription("Get all tasks from a specific project. Tasks of kanban projects are grouped by column."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("project_id",
 			mcp.Required(),
-			mcp.Description("ID of the project to retrieve tasks from"),
+			mcp.Description("ID of the project to retrieve tasks from, or \"inbox\" for the inbox"),
 		),
+		mcp.WithString("tag",
+			mcp.Description("Only return tasks with this tag"),
+		),
+		withRefresh(),
 	)
-	s.AddTool(getProjectTasks, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r.AddTool(getProjectTasks, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if err := ensureClientInitialized(); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -85,13 +132,19 @@ func InitAllTools(s *server.MCPServer) error {
 			return mcp.NewToolResultErrorf(err.Error()), nil
 		}
 		// 获取任务
-		projectData, err := ticktickClient.GetProjectWithData(projectID)
+		projectData, err := loadProjectData(projectID, request.GetBool("refresh", false))
 		if err != nil {
 			return mcp.NewToolResultErrorf(fmt.Sprintf("Error fetching project data: %v", err)), nil
 		}
+		if tag := request.GetString("tag", ""); tag != "" {
+			projectData.Tasks = filterTasksByTag(projectData.Tasks, tag)
+		}
 		result := ""
 		if len(projectData.Tasks) == 0 {
 			result = "No tasks found in project"
+		} else if len(projectData.Columns) > 0 {
+			result = fmt.Sprintf("Found %d tasks:\n", len(projectData.Tasks))
+			result += FormatTasksByColumn(projectData.Columns, projectData.Tasks)
 		} else {
 			result = fmt.Sprintf("Found %d tasks:\n\n", len(projectData.Tasks))
 			for i, task := range projectData.Tasks {
@@ -104,6 +157,7 @@ func InitAllTools(s *server.MCPServer) error {
 	// 获取指定Project的指定Task
 	getTask := mcp.NewTool("get_task",
 		mcp.WithDescription("Get details about a specific task"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("project_id",
 			mcp.Required(),
 			mcp.Description("ID of the project"),
@@ -112,8 +166,9 @@ func InitAllTools(s *server.MCPServer) error {
 			mcp.Required(),
 			mcp.Description("ID of the task"),
 		),
+		withRefresh(),
 	)
-	s.AddTool(getTask, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r.AddTool(getTask, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if err := ensureClientInitialized(); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -126,7 +181,7 @@ func InitAllTools(s *server.MCPServer) error {
 		if err != nil {
 			return mcp.NewToolResultErrorf(err.Error()), nil
 		}
-		task, err := ticktickClient.GetTask(projectID, taskID)
+		task, err := loadTask(projectID, taskID, request.GetBool("refresh", false))
 		if err != nil {
 			return mcp.NewToolResultErrorf("Error fetching task: %v", err), nil
 		}
@@ -135,73 +190,49 @@ func InitAllTools(s *server.MCPServer) error {
 	})
 	// 创建任务
 	createTaskTool := mcp.NewTool("create_task",
-		mcp.WithDescription("Create a new task in a specific project"),
+		mcp.WithDescription("Create a new task in a specific project, or in the inbox when no project is given"),
 		mcp.WithString("project_id",
-			mcp.Required(),
-			mcp.Description("ID of the project to add the task to"),
+			mcp.Description("ID of the project to add the task to, defaults to the inbox"),
 		),
 		mcp.WithString("title",
 			mcp.Required(),
-			mcp.Description("Title of the task"),
+			mcp.Description("Title of the task, inline #tags are extracted as tags"),
 		),
 		mcp.WithString("content",
 			mcp.Description("Content/description of the task"),
 		),
 		mcp.WithString("start_date",
-			mcp.Description("Start date in format YYYY-MM-DDThh:mm:ssZ"),
+			mcp.Description(fmt.Sprintf(dateArgDescription, "Start date")),
 		),
 		mcp.WithString("due_date",
-			mcp.Description("Due date in format YYYY-MM-DDThh:mm:ssZ"),
+			mcp.Description(fmt.Sprintf(dateArgDescription, "Due date")),
 		),
-		mcp.WithString("priority",
+		mcp.WithNumber("priority",
 			mcp.Description("Priority level: 0=None, 1=Low, 3=Medium, 5=High"),
 		),
+		mcp.WithArray("reminders",
+			mcp.Description(reminderArgDescription),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("repeat",
+			mcp.Description(repeatArgDescription),
+		),
+		mcp.WithArray("tags",
+			mcp.Description(tagsArgDescription),
+			mcp.WithStringItems(),
+		),
+		withIdempotencyKey(),
 	)
-	s.AddTool(createTaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r.AddTool(createTaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if err := ensureClientInitialized(); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		projectID, err := request.RequireString("project_id")
-		if err != nil {
-			return mcp.NewToolResultErrorf(err.Error()), nil
-		}
-		title, err := request.RequireString("title")
-		if err != nil {
-			return mcp.NewToolResultErrorf(err.Error()), nil
-		}
-
-		task := client.Task{
-			ProjectID: projectID,
-			Title:     title,
-		}
-
-		// 获取可选选项
-		content := request.GetString("content", "")
-		if content != "" {
-			task.Content = content
-		}
-
-		startDate := request.GetString("start_date", "")
-		if startDate != "" {
-			task.StartDate = startDate
-		}
-		dueDate := request.GetString("due_date", "")
-		if dueDate != "" {
-			task.DueDate = dueDate
-		}
-		priority := request.GetInt("priority", 0)
-		task.Priority = priority
-		createdTask, err := ticktickClient.CreateTask(task)
-		if err != nil {
-			return mcp.NewToolResultErrorf("Failed to create task: %v", err), nil
-		}
-		return mcp.NewToolResultText(fmt.Sprintf("Task created successfully:\n%s", FormatTask(*createdTask))), nil
-
+		return runWithOutbox(opCreateTask, request), nil
 	})
 
 	// 更新任务
 	updateTaskTool := mcp.NewTool("update_task",
-		mcp.WithDescription("Update an existing task"),
+		mcp.WithDescription("Update an existing task. Only the supplied fields are changed; pass an empty string to clear content, start_date or due_date."),
 		mcp.WithString("task_id",
 			mcp.Required(),
 			mcp.Description("ID of the task to update"),
@@ -211,63 +242,38 @@ func InitAllTools(s *server.MCPServer) error {
 			mcp.Description("ID of the project containing the task"),
 		),
 		mcp.WithString("title",
-			mcp.Required(),
-			mcp.Description("Title of the task"),
+			mcp.Description("New title of the task"),
 		),
 		mcp.WithString("content",
-			mcp.Description("Content/description of the task"),
+			mcp.Description("Content/description of the task, empty string to clear"),
 		),
 		mcp.WithString("start_date",
-			mcp.Description("Start date in format YYYY-MM-DDThh:mm:ssZ"),
+			mcp.Description(fmt.Sprintf(dateArgDescription, "Start date")+" Empty string to clear."),
 		),
 		mcp.WithString("due_date",
-			mcp.Description("Due date in format YYYY-MM-DDThh:mm:ssZ"),
+			mcp.Description(fmt.Sprintf(dateArgDescription, "Due date")+" Empty string to clear."),
 		),
-		mcp.WithString("priority",
+		mcp.WithNumber("priority",
 			mcp.Description("Priority level: 0=None, 1=Low, 3=Medium, 5=High"),
 		),
+		mcp.WithArray("reminders",
+			mcp.Description(reminderArgDescription),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("repeat",
+			mcp.Description(repeatArgDescription),
+		),
+		mcp.WithArray("tags",
+			mcp.Description(tagsArgDescription),
+			mcp.WithStringItems(),
+		),
+		withIdempotencyKey(),
 	)
-	s.AddTool(updateTaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r.AddTool(updateTaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if err := ensureClientInitialized(); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		// 获取请求参数
-		taskID, err := request.RequireString("task_id")
-		if err != nil {
-			return mcp.NewToolResultErrorf(err.Error()), nil
-		}
-		projectID, err := request.RequireString("project_id")
-		if err != nil {
-			return mcp.NewToolResultErrorf(err.Error()), nil
-		}
-
-		task := client.Task{
-			ID:        taskID,
-			ProjectID: projectID,
-		}
-
-		// 获取可选选项
-		content := request.GetString("content", "")
-		if content != "" {
-			task.Content = content
-		}
-
-		startDate := request.GetString("start_date", "")
-		if startDate != "" {
-			task.StartDate = startDate
-		}
-		dueDate := request.GetString("due_date", "")
-		if dueDate != "" {
-			task.DueDate = dueDate
-		}
-		priority := request.GetInt("priority", 0)
-		task.Priority = priority
-
-		updatedTask, err := ticktickClient.UpdateTask(task)
-		if err != nil {
-			return mcp.NewToolResultErrorf("Failed to update task: %v", err), nil
-		}
-		return mcp.NewToolResultText(fmt.Sprintf("Task updated successfully:\n%s", FormatTask(*updatedTask))), nil
+		return runWithOutbox(opUpdateTask, request), nil
 	})
 
 	// 完成任务
@@ -281,24 +287,13 @@ func InitAllTools(s *server.MCPServer) error {
 			mcp.Required(),
 			mcp.Description("ID of the task to mark as completed"),
 		),
+		withIdempotencyKey(),
 	)
-	s.AddTool(completeTaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r.AddTool(completeTaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if err := ensureClientInitialized(); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		projectID, err := request.RequireString("project_id")
-		if err != nil {
-			return mcp.NewToolResultErrorf(err.Error()), nil
-		}
-
-		taskID, err := request.RequireString("task_id")
-		if err != nil {
-			return mcp.NewToolResultErrorf(err.Error()), nil
-		}
-		if err := ticktickClient.CompletedTask(projectID, taskID); err != nil {
-			return mcp.NewToolResultErrorf("Failed to complete task: %v", err), nil
-		}
-		return mcp.NewToolResultText(fmt.Sprintf("Task completed successfully!\n")), nil
+		return runWithOutbox(opCompleteTask, request), nil
 	})
 
 	// 删除任务
@@ -312,32 +307,23 @@ func InitAllTools(s *server.MCPServer) error {
 			mcp.Required(),
 			mcp.Description("ID of the task to delete"),
 		),
+		withIdempotencyKey(),
 	)
 
-	s.AddTool(deleteTaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r.AddTool(deleteTaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if err := ensureClientInitialized(); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		projectID, err := request.RequireString("project_id")
-		if err != nil {
-			return mcp.NewToolResultErrorf(err.Error()), nil
-		}
-		taskID, err := request.RequireString("task_id")
-		if err != nil {
-			return mcp.NewToolResultErrorf(err.Error()), nil
-		}
-
-		if err := ticktickClient.DeleteTask(projectID, taskID); err != nil {
-			return mcp.NewToolResultErrorf("Failed to delete task: %v", err), nil
-		}
-		return mcp.NewToolResultText(fmt.Sprintf("Task deleted successfully!\n")), nil
+		return runWithOutbox(opDeleteTask, request), nil
 	})
 
 	// 添加OAuth2授权工具
 	oauthTool := mcp.NewTool("oauth_authorize",
 		mcp.WithDescription("Start OAuth2 authorization flow for TickTick. This will provide a URL for the user to visit and complete authorization."),
+		// 授权流程会改写保存的令牌，只读模式下不注册
+		mcp.WithReadOnlyHintAnnotation(false),
 	)
-	s.AddTool(oauthTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r.AddTool(oauthTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// 从环境变量读取Client ID和Secret
 		clientID := os.Getenv("TICKTICK_CLIENT_ID")
 		clientSecret := os.Getenv("TICKTICK_CLIENT_SECRET")
@@ -384,5 +370,20 @@ Waiting for authorization...`, authURL)
 		return mcp.NewToolResultText(result), nil
 	})
 
+	// 子任务管理工具
+	registerSubtaskTools(r)
+	registerViewTools(r)
+	registerSearchTools(r)
+	registerMoveTools(r)
+	registerBatchTools(r)
+	registerTemplateTools(r)
+	registerKanbanTools(r)
+	registerTagTools(r)
+	registerSyncTools(r)
+	registerOutboxTools(r)
+	registerUndoTools(r)
+	registerICSTools(r)
+	registerTaskFileTools(r)
+
 	return nil
 }
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 批量操作的限制
+const (
+	maxBatchSize        = 50 // 单次批量操作的最大任务数
+	maxBatchConcurrency = 4  // 批量操作的最大并发请求数
+)
+
+// taskFieldSchemas 创建/更新任务时每一项可用的字段
+var taskFieldSchemas = map[string]any{
+	"title":      map[string]any{"type": "string", "description": "Title of the task"},
+	"content":    map[string]any{"type": "string", "description": "Content/description of the task"},
+	"start_date": map[string]any{"type": "string", "description": fmt.Sprintf(dateArgDescription, "Start date")},
+	"due_date":   map[string]any{"type": "string", "description": fmt.Sprintf(dateArgDescription, "Due date")},
+	"priority":   map[string]any{"type": "number", "description": "Priority level: 0=None, 1=Low, 3=Medium, 5=High"},
+	"reminders":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": reminderArgDescription},
+	"repeat":     map[string]any{"type": "string", "description": repeatArgDescription},
+	"tags":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": tagsArgDescription},
+}
+
+// taskRefSchema 只包含项目 ID 和任务 ID 的任务引用
+var taskRefSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"project_id": map[string]any{"type": "string", "description": "ID of the project containing the task"},
+		"task_id":    map[string]any{"type": "string", "description": "ID of the task"},
+	},
+	"required": []string{"project_id", "task_id"},
+}
+
+// taskItemSchema 构造带任务字段的对象 schema
+func taskItemSchema(extra map[string]any, required ...string) map[string]any {
+	properties := make(map[string]any, len(taskFieldSchemas)+len(extra))
+	for name, schema := range taskFieldSchemas {
+		properties[name] = schema
+	}
+	for name, schema := range extra {
+		properties[name] = schema
+	}
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+// registerBatchTools 注册批量创建、更新、完成和删除任务的工具
+func registerBatchTools(r *toolRegistrar) {
+	batchCreateTool := mcp.NewTool("batch_create_tasks",
+		mcp.WithDescription(fmt.Sprintf("Create several tasks at once (max %d). Each item accepts the same fields as create_task. Returns a per-item report; failures do not stop the other items.", maxBatchSize)),
+		mcp.WithArray("tasks",
+			mcp.Required(),
+			mcp.Description("Tasks to create"),
+			mcp.Items(taskItemSchema(map[string]any{
+				"project_id": map[string]any{"type": "string", "description": "ID of the project to add the task to, defaults to the inbox"},
+			}, "title")),
+		),
+	)
+	r.AddTool(batchCreateTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return runBatch(ctx, request, "create", func(item mcp.CallToolRequest) (string, error) {
+			task, err := createTaskFromArgs(item)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("created %q (ID: %s, Project ID: %s)", task.Title, task.ID, task.ProjectID), nil
+		})
+	})
+
+	batchUpdateTool := mcp.NewTool("batch_update_tasks",
+		mcp.WithDescription(fmt.Sprintf("Update several tasks at once (max %d). Each item accepts the same fields as update_task; only supplied fields change. Returns a per-item report with the changes applied.", maxBatchSize)),
+		mcp.WithArray("tasks",
+			mcp.Required(),
+			mcp.Description("Task updates"),
+			mcp.Items(taskItemSchema(map[string]any{
+				"project_id": map[string]any{"type": "string", "description": "ID of the project containing the task"},
+				"task_id":    map[string]any{"type": "string", "description": "ID of the task to update"},
+			}, "project_id", "task_id")),
+		),
+	)
+	r.AddTool(batchUpdateTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return runBatch(ctx, request, "update", func(item mcp.CallToolRequest) (string, error) {
+			current, updated, err := updateTaskFromArgs(item)
+			if err != nil {
+				return "", err
+			}
+			if updated == nil {
+				return fmt.Sprintf("no changes for %q (ID: %s)", current.Title, current.ID), nil
+			}
+			changes := DiffTasks(*current, *updated)
+			fields := make([]string, len(changes))
+			for i, change := range changes {
+				fields[i] = change.Field
+			}
+			return fmt.Sprintf("updated %q (ID: %s): %s", updated.Title, updated.ID, strings.Join(fields, ", ")), nil
+		})
+	})
+
+	batchCompleteTool := mcp.NewTool("batch_complete_tasks",
+		mcp.WithDescription(fmt.Sprintf("Mark several tasks as completed at once (max %d). Returns a per-item report.", maxBatchSize)),
+		mcp.WithArray("tasks",
+			mcp.Required(),
+			mcp.Description("Tasks to complete"),
+			mcp.Items(taskRefSchema),
+		),
+	)
+	r.AddTool(batchCompleteTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return runBatch(ctx, request, "complete", func(item mcp.CallToolRequest) (string, error) {
+			projectID, taskID, err := taskRef(item)
+			if err != nil {
+				return "", err
+			}
+			if err := ticktickClient.CompletedTask(projectID, taskID); err != nil {
+				return "", fmt.Errorf("failed to complete task %s: %v", taskID, err)
+			}
+			return fmt.Sprintf("completed task %s", taskID), nil
+		})
+	})
+
+	batchDeleteTool := mcp.NewTool("batch_delete_tasks",
+		mcp.WithDescription(fmt.Sprintf("Delete several tasks at once (max %d). Returns a per-item report.", maxBatchSize)),
+		mcp.WithArray("tasks",
+			mcp.Required(),
+			mcp.Description("Tasks to delete"),
+			mcp.Items(taskRefSchema),
+		),
+	)
+	r.AddTool(batchDeleteTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return runBatch(ctx, request, "delete", func(item mcp.CallToolRequest) (string, error) {
+			projectID, taskID, err := taskRef(item)
+			if err != nil {
+				return "", err
+			}
+			if err := ticktickClient.DeleteTask(projectID, taskID); err != nil {
+				return "", fmt.Errorf("failed to delete task %s: %v", taskID, err)
+			}
+			return fmt.Sprintf("deleted task %s", taskID), nil
+		})
+	})
+}
+
+// taskRef 读取任务引用中的项目 ID 和任务 ID
+func taskRef(request mcp.CallToolRequest) (projectID, taskID string, err error) {
+	if projectID, err = request.RequireString("project_id"); err != nil {
+		return "", "", err
+	}
+	if taskID, err = request.RequireString("task_id"); err != nil {
+		return "", "", err
+	}
+	return projectID, taskID, nil
+}
+
+// batchResult 批量操作中单项的结果
+type batchResult struct {
+	message string
+	err     error
+}
+
+// runBatch 以有限并发对 tasks 参数中的每一项执行 fn，并汇总逐项结果
+// 每一项被包装为独立的工具请求，以便复用单任务工具的参数解析
+func runBatch(ctx context.Context, request mcp.CallToolRequest, operation string, fn func(item mcp.CallToolRequest) (string, error)) (*mcp.CallToolResult, error) {
+	if err := ensureClientInitialized(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	items, ok := request.GetArguments()["tasks"].([]any)
+	if !ok || len(items) == 0 {
+		return mcp.NewToolResultError("tasks must be a non-empty array"), nil
+	}
+	if len(items) > maxBatchSize {
+		return mcp.NewToolResultErrorf("too many tasks: %d (max %d per call)", len(items), maxBatchSize), nil
+	}
+
+	results := make([]batchResult, len(items))
+	sem := make(chan struct{}, maxBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		args, ok := item.(map[string]any)
+		if !ok {
+			results[i].err = fmt.Errorf("item must be an object")
+			continue
+		}
+		wg.Add(1)
+		go func(i int, args map[string]any) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].err = ctx.Err()
+				return
+			}
+			results[i].message, results[i].err = fn(newToolRequest(request.Params.Name, args))
+		}(i, args)
+	}
+	wg.Wait()
+
+	return mcp.NewToolResultText(formatBatchResults(operation, results)), nil
+}
+
+// formatBatchResults 格式化批量操作的逐项结果
+func formatBatchResults(operation string, results []batchResult) string {
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Batch %s: %d succeeded, %d failed (%d total)\n\n", operation, len(results)-failed, failed, len(results))
+	for i, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(&b, "%d. ✗ %v\n", i+1, result.err)
+			continue
+		}
+		fmt.Fprintf(&b, "%d. ✓ %s\n", i+1, result.message)
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"dida/internal/ical"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerICSTools 注册 iCalendar 导出和导入工具
+func registerICSTools(r *toolRegistrar) {
+	exportTool := mcp.NewTool("export_ics",
+		mcp.WithDescription("Export open tasks as an iCalendar (.ics, RFC 5545) document that calendar apps can import. Tasks become VTODO entries (or VEVENT entries in event mode) with start/due dates, all-day flag, RRULE repeat rules, VALARM reminders, priority, tags, status and subtasks in the description."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithArray("project_ids",
+			mcp.Description("IDs of the projects to export (\"inbox\" for the inbox); all projects when omitted"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("from",
+			mcp.Description(`Only export tasks dated on or after this day, e.g. "today", "2026-11-01". Tasks without dates are left out when from or to is set.`),
+		),
+		mcp.WithString("to",
+			mcp.Description(`Only export tasks dated on or before this day, e.g. "next friday", "2026-11-30"`),
+		),
+		mcp.WithString("mode",
+			mcp.Description("todo exports every task as VTODO (default); event exports dated tasks as VEVENT, which more calendar apps display, and skips undated tasks"),
+			mcp.Enum(ical.ModeTodo, ical.ModeEvent),
+		),
+		withRefresh(),
+	)
+	r.AddTool(exportTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		export, err := ExportICS(ctx, ICSExportOptions{
+			ProjectIDs: request.GetStringSlice("project_ids", nil),
+			From:       request.GetString("from", ""),
+			To:         request.GetString("to", ""),
+			Mode:       request.GetString("mode", ical.ModeTodo),
+			Refresh:    request.GetBool("refresh", false),
+		})
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error exporting tasks: %v", err), nil
+		}
+		summary := fmt.Sprintf("Exported %d tasks", export.Exported)
+		if export.Skipped > 0 {
+			summary += fmt.Sprintf(" (%d tasks without dates skipped)", export.Skipped)
+		}
+		return mcp.NewToolResultText(summary + ":\n\n" + string(export.Data) + export.Warnings), nil
+	})
+
+	previewImportTool := mcp.NewTool("preview_ics_import",
+		mcp.WithDescription("Show the tasks import_ics would create from iCalendar data, including entries skipped as duplicates, without creating anything."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		withICSImportArgs(),
+	)
+	r.AddTool(previewImportTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		data, opts, err := icsImportArgs(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		preview, err := PreviewICSImport(ctx, data, opts)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error reading iCalendar data: %v", err), nil
+		}
+		return mcp.NewToolResultText(preview), nil
+	})
+
+	importTool := mcp.NewTool("import_ics",
+		mcp.WithDescription("Create tasks from the VTODO entries of iCalendar data. SUMMARY, DESCRIPTION, DTSTART, DUE, PRIORITY, RRULE, VALARM, CATEGORIES and STATUS are mapped onto the task; child VTODOs (RELATED-TO) and trailing \"- [ ] item\" lines of the description become subtasks. The UID is kept in the task content so entries already imported into the project are skipped."),
+		withICSImportArgs(),
+	)
+	r.AddTool(importTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		data, opts, err := icsImportArgs(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result, err := ImportICS(ctx, data, opts)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error importing iCalendar data: %v", err), nil
+		}
+		return mcp.NewToolResultText(result), nil
+	})
+}
+
+// withICSImportArgs preview_ics_import 与 import_ics 共用的参数
+func withICSImportArgs() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		for _, opt := range []mcp.ToolOption{
+			mcp.WithString("ics",
+				mcp.Required(),
+				mcp.Description("Contents of the .ics file"),
+			),
+			mcp.WithString("project_id",
+				mcp.Description("Project to create the tasks in, defaults to the inbox"),
+			),
+			mcp.WithBoolean("include_completed",
+				mcp.Description("Also import completed entries and mark them completed (default false)"),
+			),
+		} {
+			opt(tool)
+		}
+	}
+}
+
+// icsImportArgs 读取导入工具的参数
+func icsImportArgs(request mcp.CallToolRequest) ([]byte, ICSImportOptions, error) {
+	data, err := request.RequireString("ics")
+	if err != nil {
+		return nil, ICSImportOptions{}, err
+	}
+	return []byte(data), ICSImportOptions{
+		ProjectID:        request.GetString("project_id", ""),
+		IncludeCompleted: request.GetBool("include_completed", false),
+	}, nil
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"dida/internal/client"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerKanbanTools 注册看板列相关工具
+func registerKanbanTools(r *toolRegistrar) {
+	moveTaskToColumnTool := mcp.NewTool("move_task_to_column",
+		mcp.WithDescription("Move a task to another column of its kanban project"),
+		mcp.WithString("project_id",
+			mcp.Required(),
+			mcp.Description("ID of the kanban project containing the task"),
+		),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("ID of the task to move"),
+		),
+		mcp.WithString("column",
+			mcp.Required(),
+			mcp.Description("ID or name (case-insensitive) of the destination column, see get_project"),
+		),
+	)
+	r.AddTool(moveTaskToColumnTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		projectID, err := request.RequireString("project_id")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		taskID, err := request.RequireString("task_id")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		columnArg, err := request.RequireString("column")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+
+		projectData, err := ticktickClient.Fresh().GetProjectWithData(projectID)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error fetching project data: %v", err), nil
+		}
+		column, err := findColumn(projectData.Columns, columnArg)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		task, err := ticktickClient.Fresh().GetTask(projectID, taskID)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error fetching task: %v", err), nil
+		}
+		if task.ColumnID == column.ID {
+			return mcp.NewToolResultText(fmt.Sprintf("Task is already in column %s", column.Name)), nil
+		}
+		task.ColumnID = column.ID
+
+		updatedTask, err := ticktickClient.UpdateTask(*task)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Failed to update task: %v", err), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Task moved to column %s successfully:\n%s", column.Name, FormatTask(*updatedTask))), nil
+	})
+}
+
+// findColumn 按 ID 或名称（不区分大小写）查找看板列
+func findColumn(columns []client.Column, idOrName string) (client.Column, error) {
+	if len(columns) == 0 {
+		return client.Column{}, fmt.Errorf("project has no columns, is it a kanban project?")
+	}
+	for _, column := range columns {
+		if column.ID == idOrName {
+			return column, nil
+		}
+	}
+	for _, column := range columns {
+		if strings.EqualFold(column.Name, idOrName) {
+			return column, nil
+		}
+	}
+	names := make([]string, 0, len(columns))
+	for _, column := range sortedColumns(columns) {
+		names = append(names, column.Name)
+	}
+	return client.Column{}, fmt.Errorf("column %q not found, available columns: %s", idOrName, strings.Join(names, ", "))
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"dida/internal/client"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerMoveTools 注册在项目之间移动任务的工具
+func registerMoveTools(r *toolRegistrar) {
+	moveTaskTool := mcp.NewTool("move_task",
+		mcp.WithDescription("Move a task to another project, e.g. to file an inbox item into a project. The task keeps its content, dates, priority, reminders, repeat rule and subtasks. If the API cannot move tasks natively, the task is recreated in the destination (getting a new ID) and the original is deleted only after the copy is verified."),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("ID of the task to move"),
+		),
+		mcp.WithString("project_id",
+			mcp.Description("ID of the project currently containing the task, defaults to the inbox"),
+		),
+		mcp.WithString("to_project_id",
+			mcp.Required(),
+			mcp.Description("ID of the destination project, or \"inbox\""),
+		),
+	)
+	r.AddTool(moveTaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		taskID, err := request.RequireString("task_id")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		toProjectID, err := request.RequireString("to_project_id")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		fromProjectID := request.GetString("project_id", client.InboxProjectID)
+		if fromProjectID == "" {
+			fromProjectID = client.InboxProjectID
+		}
+		if toolPolicy.RestrictsProjects() && !allowProject(toolPolicy, fromProjectID) {
+			return mcp.NewToolResultErrorf("Access to project %s is not allowed by the server policy", fromProjectID), nil
+		}
+
+		task, err := ticktickClient.Fresh().GetTask(fromProjectID, taskID)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error fetching task: %v", err), nil
+		}
+		if sameProject(task.ProjectID, toProjectID) {
+			return mcp.NewToolResultText(fmt.Sprintf("Task is already in project %s, nothing to move", toProjectID)), nil
+		}
+
+		moved, err := ticktickClient.MoveTask(*task, toProjectID)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Failed to move task: %v", err), nil
+		}
+		message := "Task moved successfully"
+		if moved.ID != task.ID {
+			message += fmt.Sprintf(" (recreated with new ID %s)", moved.ID)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s:\n%s", message, FormatTask(*moved))), nil
+	})
+}
+
+// sameProject 判断两个项目 ID 是否指向同一项目，收集箱的各种 ID 视为相同
+func sameProject(a, b string) bool {
+	if client.IsInbox(a) && client.IsInbox(b) {
+		return true
+	}
+	return a == b
+}
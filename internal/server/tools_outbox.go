@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"dida/internal/mirror"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerOutboxTools 注册离线队列的查看、重放和丢弃工具
+func registerOutboxTools(r *toolRegistrar) {
+	getOutboxTool := mcp.NewTool("get_outbox",
+		mcp.WithDescription("List operations in the offline queue. When TickTick is unreachable, create_task, update_task, complete_task and delete_task are queued and replayed in order once the connection returns; other modifying tools are refused while operations are pending. Entries are pending, done, conflict (the task changed on the server while queued) or failed (rejected by the server)."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("status",
+			mcp.Description("Only list entries with this status: pending, done, conflict or failed"),
+			mcp.Enum(mirror.OutboxPending, mirror.OutboxDone, mirror.OutboxConflict, mirror.OutboxFailed),
+		),
+	)
+	r.AddTool(getOutboxTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !outboxEnabled {
+			return mcp.NewToolResultError(outboxDisabledMessage), nil
+		}
+		var statuses []string
+		if status := request.GetString("status", ""); status != "" {
+			statuses = append(statuses, status)
+		}
+		entries, err := taskMirror.ListOutbox(statuses...)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error reading offline queue: %v", err), nil
+		}
+		visible := entries[:0]
+		for _, entry := range entries {
+			if entry.ProjectID == "" || !toolPolicy.RestrictsProjects() || allowProject(toolPolicy, entry.ProjectID) {
+				visible = append(visible, entry)
+			}
+		}
+		if len(visible) == 0 {
+			return mcp.NewToolResultText("The offline queue is empty.\n"), nil
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "Found %d outbox entries:\n\n", len(visible))
+		for _, entry := range visible {
+			b.WriteString(formatOutboxEntry(entry))
+		}
+		return mcp.NewToolResultText(b.String()), nil
+	})
+
+	replayOutboxTool := mcp.NewTool("replay_outbox",
+		mcp.WithDescription("Replay pending operations in the offline queue now, in the order they were queued. Replay stops at the first operation that still cannot reach TickTick."),
+		mcp.WithArray("force",
+			mcp.Description(`IDs of conflict entries to apply anyway, overwriting the changes made on the server (e.g. ["3"])`),
+			mcp.WithStringItems(),
+		),
+	)
+	r.AddTool(replayOutboxTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !outboxEnabled {
+			return mcp.NewToolResultError(outboxDisabledMessage), nil
+		}
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		force := make(map[int64]bool)
+		for _, value := range request.GetStringSlice("force", nil) {
+			id, err := parseEntryID(value, "outbox entry")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			entry, err := outboxEntryForTool(id)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if entry.Status != mirror.OutboxConflict && entry.Status != mirror.OutboxPending {
+				return mcp.NewToolResultErrorf("Outbox entry #%d is %s; only conflict entries can be forced", id, entry.Status), nil
+			}
+			entry.Status = mirror.OutboxPending
+			if err := taskMirror.UpdateOutbox(entry); err != nil {
+				return mcp.NewToolResultErrorf("Error updating offline queue: %v", err), nil
+			}
+			force[id] = true
+		}
+
+		report, err := replayOutbox(force)
+		var b strings.Builder
+		if report == "" && err == nil {
+			b.WriteString("No pending operations to replay.\n")
+		} else if report != "" {
+			fmt.Fprintf(&b, "Replayed offline queue:\n%s", report)
+		}
+		if err != nil {
+			fmt.Fprintf(&b, "\nWarning: %v\n", err)
+		}
+		return mcp.NewToolResultText(b.String()), nil
+	})
+
+	discardTool := mcp.NewTool("discard_outbox_entry",
+		mcp.WithDescription("Remove an operation from the offline queue without applying it, e.g. to resolve a conflict by keeping the server version."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("ID of the outbox entry, as shown by get_outbox"),
+		),
+	)
+	r.AddTool(discardTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !outboxEnabled {
+			return mcp.NewToolResultError(outboxDisabledMessage), nil
+		}
+		value, err := request.RequireString("id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		id, err := parseEntryID(value, "outbox entry")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		entry, err := outboxEntryForTool(id)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := taskMirror.DeleteOutbox(id); err != nil {
+			return mcp.NewToolResultErrorf("Error updating offline queue: %v", err), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Discarded outbox entry #%d (%s, was %s).\n", id, entry.Operation, entry.Status)), nil
+	})
+}
+
+// outboxDisabledMessage 离线队列未启用时的提示
+const outboxDisabledMessage = "The offline queue requires the local mirror; enable it with TICKTICK_MIRROR=true and TICKTICK_OUTBOX=true"
+
+// outboxEntryForTool 读取条目，并检查其项目是否在允许范围内
+func outboxEntryForTool(id int64) (*mirror.OutboxEntry, error) {
+	entry, err := taskMirror.OutboxByID(id)
+	if isNotFound(err) || (err == nil && entry.ProjectID != "" && toolPolicy.RestrictsProjects() && !allowProject(toolPolicy, entry.ProjectID)) {
+		return nil, fmt.Errorf("outbox entry #%d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading offline queue: %v", err)
+	}
+	return entry, nil
+}
+
+// formatOutboxEntry 格式化一条队列条目
+func formatOutboxEntry(entry mirror.OutboxEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#%d [%s] %s", entry.ID, entry.Status, entry.Operation)
+	if title, ok := entry.Args["title"].(string); ok && title != "" {
+		fmt.Fprintf(&b, " %q", title)
+	} else if entry.Base != nil {
+		fmt.Fprintf(&b, " %q", entry.Base.Title)
+	}
+	if entry.TaskID != "" {
+		fmt.Fprintf(&b, " (task %s)", entry.TaskID)
+	}
+	fmt.Fprintf(&b, "\n  Queued: %s, attempts: %d, key: %s\n",
+		entry.CreatedAt.In(userLocation).Format(dateTimeDisplayLayout), entry.Attempts, entry.Key)
+	if entry.Status != mirror.OutboxPending && entry.Result != "" {
+		fmt.Fprintf(&b, "  Result: %s\n", firstLine(entry.Result))
+	}
+	if entry.LastError != "" {
+		fmt.Fprintf(&b, "  Last error: %s\n", entry.LastError)
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"dida/internal/client"
+	"dida/internal/query"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// searchQueryDescription search_tasks 查询语法说明
+const searchQueryDescription = `Search query. Terms are ANDed by default; use AND, OR, NOT (or -term) and parentheses.
+Supported terms:
+- plain text or "quoted phrase": matches title, content and subtask titles
+- title:xxx, content:xxx
+- priority:high (none/low/medium/high, comparisons like priority:>=medium)
+- due:<2026-11-01, due:today, due:<="next friday", due:none, due:any, due:overdue; start: works the same way
+- project:Work (project name or ID)
+- tag:urgent
+- status:open / status:completed
+- has:subtasks, has:reminders, has:repeat, has:due, has:start, has:content, has:tags
+- repeat:yes / repeat:no
+- sort:due,-priority and limit:20
+Example: report priority:high due:<"next monday" -project:Personal sort:due`
+
+// registerSearchTools 注册任务搜索工具
+func registerSearchTools(r *toolRegistrar) {
+	searchTasksTool := mcp.NewTool("search_tasks",
+		mcp.WithDescription("Search and filter tasks across all projects with a small query language. Only open tasks are returned by the TickTick API, so status:completed usually matches nothing."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description(searchQueryDescription),
+		),
+		mcp.WithString("sort",
+			mcp.Description("Comma-separated sort fields (due, start, priority, title), prefix with - for descending. Overrides sort: in the query."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of tasks to return (default 50). Overrides limit: in the query."),
+		),
+		withRefresh(),
+	)
+	r.AddTool(searchTasksTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		input, err := request.RequireString("query")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		result, err := SearchTasks(ctx, SearchOptions{
+			Query:   input,
+			Sort:    request.GetString("sort", ""),
+			Limit:   request.GetInt("limit", 0),
+			Refresh: request.GetBool("refresh", false),
+		})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(result), nil
+	})
+}
+
+// SearchOptions 搜索任务的条件
+type SearchOptions struct {
+	// Query 查询语句，语法见 query.Parse
+	Query string
+	// Sort 逗号分隔的排序字段，非空时覆盖查询中的 sort:
+	Sort string
+	// Limit 大于 0 时覆盖查询中的 limit:
+	Limit int
+	// Refresh 跳过本地镜像和缓存
+	Refresh bool
+}
+
+// SearchTasks 跨项目搜索任务并返回结果文本，search_tasks 工具和 search 子命令共用
+func SearchTasks(ctx context.Context, opts SearchOptions) (string, error) {
+	now := time.Now().In(userLocation)
+	q, err := query.Parse(opts.Query, now)
+	if err != nil {
+		return "", fmt.Errorf("invalid query: %v", err)
+	}
+	if opts.Sort != "" {
+		if q.Sort, err = query.ParseSort(opts.Sort); err != nil {
+			return "", fmt.Errorf("invalid sort: %v", err)
+		}
+	}
+	if opts.Limit > 0 {
+		q.Limit = opts.Limit
+	}
+
+	if err := ensureClientInitialized(); err != nil {
+		return "", err
+	}
+	projects, errs, err := fetchAllProjectTasks(ctx, opts.Refresh)
+	if err != nil {
+		return "", err
+	}
+
+	names := make(map[string]string, len(projects))
+	var tasks []client.Task
+	for _, project := range projects {
+		names[project.Project.ID] = project.Project.Name
+		// 收集箱中任务的 projectId 与伪项目 ID 不同，按任务记录名称
+		for _, task := range project.Tasks {
+			names[task.ProjectID] = project.Project.Name
+		}
+		tasks = append(tasks, project.Tasks...)
+	}
+	matched := q.Apply(tasks, names)
+
+	if len(matched) == 0 {
+		return fmt.Sprintf("No tasks match %s\n%s", q, formatFetchErrors(errs)), nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d tasks matching %s:\n\n", len(matched), q)
+	for i, task := range matched {
+		fmt.Fprintf(&b, "%d. [%s] %s", i+1, names[task.ProjectID], strings.TrimPrefix(formatTaskLine(task, now), "- "))
+	}
+	b.WriteString(formatFetchErrors(errs))
+	return b.String(), nil
+}
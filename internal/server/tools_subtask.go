@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"dida/internal/client"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerSubtaskTools 注册子任务（检查项）管理工具
+func registerSubtaskTools(r *toolRegistrar) {
+	// 添加子任务
+	addSubtaskTool := mcp.NewTool("add_subtask",
+		mcp.WithDescription("Add a checklist item (subtask) to a task"),
+		mcp.WithString("project_id",
+			mcp.Required(),
+			mcp.Description("ID of the project containing the task"),
+		),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("ID of the parent task"),
+		),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("Title of the subtask"),
+		),
+		mcp.WithNumber("position",
+			mcp.Description("1-based position to insert the subtask at, defaults to the end"),
+		),
+		mcp.WithBoolean("completed",
+			mcp.Description("Whether the subtask starts checked"),
+		),
+	)
+	r.AddTool(addSubtaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		title, err := request.RequireString("title")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		if title == "" {
+			return mcp.NewToolResultError("title cannot be empty"), nil
+		}
+		return modifySubtasks(request, "Subtask added", func(items []client.TaskItem) ([]client.TaskItem, error) {
+			item := client.TaskItem{Title: title}
+			setItemCompleted(&item, request.GetBool("completed", false))
+
+			position := request.GetInt("position", len(items)+1)
+			if position < 1 || position > len(items)+1 {
+				return nil, fmt.Errorf("position must be between 1 and %d", len(items)+1)
+			}
+			items = append(items, client.TaskItem{})
+			copy(items[position:], items[position-1:])
+			items[position-1] = item
+			return items, nil
+		})
+	})
+
+	// 更新子任务
+	updateSubtaskTool := mcp.NewTool("update_subtask",
+		mcp.WithDescription("Rename a checklist item (subtask) or change its checked state"),
+		mcp.WithString("project_id",
+			mcp.Required(),
+			mcp.Description("ID of the project containing the task"),
+		),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("ID of the parent task"),
+		),
+		mcp.WithString("subtask_id",
+			mcp.Required(),
+			mcp.Description("ID of the subtask to update"),
+		),
+		mcp.WithString("title",
+			mcp.Description("New title of the subtask"),
+		),
+		mcp.WithBoolean("completed",
+			mcp.Description("Whether the subtask is checked"),
+		),
+	)
+	r.AddTool(updateSubtaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		subtaskID, err := request.RequireString("subtask_id")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		args := request.GetArguments()
+		return modifySubtasks(request, "Subtask updated", func(items []client.TaskItem) ([]client.TaskItem, error) {
+			index, err := findItem(items, subtaskID)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := args["title"]; ok {
+				title := request.GetString("title", "")
+				if title == "" {
+					return nil, fmt.Errorf("title cannot be empty")
+				}
+				items[index].Title = title
+			}
+			if _, ok := args["completed"]; ok {
+				setItemCompleted(&items[index], request.GetBool("completed", false))
+			}
+			return items, nil
+		})
+	})
+
+	// 勾选/取消勾选子任务
+	completeSubtaskTool := mcp.NewTool("complete_subtask",
+		mcp.WithDescription("Check or uncheck a checklist item (subtask)"),
+		mcp.WithString("project_id",
+			mcp.Required(),
+			mcp.Description("ID of the project containing the task"),
+		),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("ID of the parent task"),
+		),
+		mcp.WithString("subtask_id",
+			mcp.Required(),
+			mcp.Description("ID of the subtask"),
+		),
+		mcp.WithBoolean("completed",
+			mcp.Description("true to check (default), false to uncheck"),
+		),
+	)
+	r.AddTool(completeSubtaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		subtaskID, err := request.RequireString("subtask_id")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		completed := request.GetBool("completed", true)
+		message := "Subtask checked"
+		if !completed {
+			message = "Subtask unchecked"
+		}
+		return modifySubtasks(request, message, func(items []client.TaskItem) ([]client.TaskItem, error) {
+			index, err := findItem(items, subtaskID)
+			if err != nil {
+				return nil, err
+			}
+			setItemCompleted(&items[index], completed)
+			return items, nil
+		})
+	})
+
+	// 删除子任务
+	deleteSubtaskTool := mcp.NewTool("delete_subtask",
+		mcp.WithDescription("Delete a checklist item (subtask) from a task"),
+		mcp.WithString("project_id",
+			mcp.Required(),
+			mcp.Description("ID of the project containing the task"),
+		),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("ID of the parent task"),
+		),
+		mcp.WithString("subtask_id",
+			mcp.Required(),
+			mcp.Description("ID of the subtask to delete"),
+		),
+	)
+	r.AddTool(deleteSubtaskTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		subtaskID, err := request.RequireString("subtask_id")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		return modifySubtasks(request, "Subtask deleted", func(items []client.TaskItem) ([]client.TaskItem, error) {
+			index, err := findItem(items, subtaskID)
+			if err != nil {
+				return nil, err
+			}
+			return append(items[:index], items[index+1:]...), nil
+		})
+	})
+
+	// 重新排序子任务
+	reorderSubtasksTool := mcp.NewTool("reorder_subtasks",
+		mcp.WithDescription("Reorder the checklist items (subtasks) of a task. Subtasks not listed keep their relative order after the listed ones."),
+		mcp.WithString("project_id",
+			mcp.Required(),
+			mcp.Description("ID of the project containing the task"),
+		),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("ID of the parent task"),
+		),
+		mcp.WithArray("subtask_ids",
+			mcp.Required(),
+			mcp.Description("Subtask IDs in the desired order"),
+			mcp.WithStringItems(),
+		),
+	)
+	r.AddTool(reorderSubtasksTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		subtaskIDs, err := request.RequireStringSlice("subtask_ids")
+		if err != nil {
+			return mcp.NewToolResultErrorf(err.Error()), nil
+		}
+		return modifySubtasks(request, "Subtasks reordered", func(items []client.TaskItem) ([]client.TaskItem, error) {
+			ordered := make([]client.TaskItem, 0, len(items))
+			used := make(map[int]bool, len(items))
+			for _, id := range subtaskIDs {
+				index, err := findItem(items, id)
+				if err != nil {
+					return nil, err
+				}
+				if used[index] {
+					return nil, fmt.Errorf("subtask %s listed more than once", id)
+				}
+				used[index] = true
+				ordered = append(ordered, items[index])
+			}
+			for i, item := range items {
+				if !used[i] {
+					ordered = append(ordered, item)
+				}
+			}
+			return ordered, nil
+		})
+	})
+}
+
+// modifySubtasks 以读取-修改-写回的方式修改父任务的子任务列表
+func modifySubtasks(request mcp.CallToolRequest, message string, modify func(items []client.TaskItem) ([]client.TaskItem, error)) (*mcp.CallToolResult, error) {
+	if err := ensureClientInitialized(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	projectID, err := request.RequireString("project_id")
+	if err != nil {
+		return mcp.NewToolResultErrorf(err.Error()), nil
+	}
+	taskID, err := request.RequireString("task_id")
+	if err != nil {
+		return mcp.NewToolResultErrorf(err.Error()), nil
+	}
+
+	task, err := ticktickClient.Fresh().GetTask(projectID, taskID)
+	if err != nil {
+		return mcp.NewToolResultErrorf("Error fetching task: %v", err), nil
+	}
+
+	items := client.SortedItems(task.Items)
+	items, err = modify(items)
+	if err != nil {
+		return mcp.NewToolResultErrorf("Failed to modify subtasks: %v", err), nil
+	}
+	// 按当前顺序重新编号，保证服务器端顺序与列表一致
+	for i := range items {
+		items[i].SortOrder = int64(i)
+	}
+	task.Items = items
+
+	updatedTask, err := ticktickClient.UpdateTask(*task)
+	if err != nil {
+		return mcp.NewToolResultErrorf("Failed to update task: %v", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s successfully:\n%s", message, FormatTask(*updatedTask))), nil
+}
+
+// findItem 按 ID 查找子任务下标
+func findItem(items []client.TaskItem, id string) (int, error) {
+	for i, item := range items {
+		if item.ID == id {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("subtask %s not found", id)
+}
+
+// setItemCompleted 设置子任务的勾选状态及完成时间
+func setItemCompleted(item *client.TaskItem, completed bool) {
+	if completed {
+		if item.Status != client.ItemStatusCompleted {
+			item.Status = client.ItemStatusCompleted
+			item.CompletedTime = client.NewTime(time.Now())
+		}
+		return
+	}
+	item.Status = client.ItemStatusNormal
+	item.CompletedTime = client.ClearTime()
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"dida/internal/dateparse"
+	"dida/internal/mirror"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// changeLabels 变更类型的展示名称
+var changeLabels = map[string]string{
+	mirror.ChangeTaskCreated:    "created",
+	mirror.ChangeTaskUpdated:    "updated",
+	mirror.ChangeTaskCompleted:  "completed",
+	mirror.ChangeTaskDeleted:    "deleted",
+	mirror.ChangeTaskMoved:      "moved",
+	mirror.ChangeProjectCreated: "project created",
+	mirror.ChangeProjectRenamed: "project renamed",
+	mirror.ChangeProjectDeleted: "project deleted",
+}
+
+// defaultChangeLimit get_changes_since 默认返回的最大变更数
+const defaultChangeLimit = 100
+
+// registerSyncTools 注册同步和变更查询工具
+func registerSyncTools(r *toolRegistrar) {
+	getChangesTool := mcp.NewTool("get_changes_since",
+		mcp.WithDescription("List changes detected by the sync engine since a point in time: created, updated, completed, deleted and moved tasks, and created, renamed and deleted projects. Changes are detected by comparing full syncs, so edits made between two syncs are reported once at the later sync."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("since",
+			mcp.Description(`How far back to look: a duration such as "24h" or "90m", or a date such as "yesterday", "2026-11-01", "last monday 9am". Defaults to 24h.`),
+		),
+		mcp.WithString("project_id",
+			mcp.Description("Only report changes in this project (\"inbox\" for the inbox)"),
+		),
+		mcp.WithBoolean("sync",
+			mcp.Description("Run a full sync first so the latest changes are included (default true)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of most recent changes to return (default %d)", defaultChangeLimit)),
+		),
+	)
+	r.AddTool(getChangesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if syncEngine == nil {
+			return mcp.NewToolResultError("Change tracking requires the local mirror; enable it with TICKTICK_MIRROR=true"), nil
+		}
+		now := time.Now().In(userLocation)
+		since, err := parseSince(request.GetString("since", "24h"), now)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Invalid since: %v", err), nil
+		}
+		projectID := request.GetString("project_id", "")
+		limit := request.GetInt("limit", defaultChangeLimit)
+
+		var b strings.Builder
+		var fetchErrs []error
+		if request.GetBool("sync", true) {
+			result, err := syncNow(ctx)
+			switch {
+			case err != nil:
+				fmt.Fprintf(&b, "Warning: sync failed, showing previously recorded changes: %v\n\n", err)
+			case result.Baseline:
+				b.WriteString("First sync: a baseline snapshot was created. Changes are recorded from now on.\n\n")
+			}
+			if err == nil {
+				fetchErrs = result.Errors
+			}
+		}
+
+		lastSync, err := taskMirror.LastSync()
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error reading sync state: %v", err), nil
+		}
+		changes, err := taskMirror.ChangesSince(since, 0)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error reading changes: %v", err), nil
+		}
+		visible := changes[:0]
+		for _, change := range changes {
+			if change.ProjectID != "" && toolPolicy.RestrictsProjects() && !allowProject(toolPolicy, change.ProjectID) {
+				continue
+			}
+			if projectID != "" && mirror.ProjectKey(change.ProjectID) != mirror.ProjectKey(projectID) {
+				continue
+			}
+			visible = append(visible, change)
+		}
+		if limit > 0 && len(visible) > limit {
+			fmt.Fprintf(&b, "Showing the %d most recent of %d changes.\n", limit, len(visible))
+			visible = visible[len(visible)-limit:]
+		}
+
+		sinceText := since.In(userLocation).Format(dateTimeDisplayLayout)
+		syncText := "never synced"
+		if !lastSync.IsZero() {
+			syncText = "last sync " + lastSync.In(userLocation).Format(dateTimeDisplayLayout)
+		}
+		if len(visible) == 0 {
+			fmt.Fprintf(&b, "No changes since %s (%s).\n", sinceText, syncText)
+		} else {
+			fmt.Fprintf(&b, "Found %d changes since %s (%s):\n\n", len(visible), sinceText, syncText)
+			for _, change := range visible {
+				b.WriteString(formatChange(change))
+			}
+		}
+		b.WriteString(formatFetchErrors(fetchErrs))
+		return mcp.NewToolResultText(b.String()), nil
+	})
+}
+
+// parseSince 解析时间起点：Go 时长表示距今多久，否则按日期解析
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return now.Add(-d), nil
+	}
+	result, err := dateparse.Parse(value, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if result.Time.After(now) {
+		return time.Time{}, fmt.Errorf("%q is in the future", value)
+	}
+	return result.Time, nil
+}
+
+// formatChange 格式化一条变更记录
+func formatChange(change mirror.Change) string {
+	label, ok := changeLabels[change.Kind]
+	if !ok {
+		label = change.Kind
+	}
+	line := fmt.Sprintf("- %s %s: ", change.DetectedAt.In(userLocation).Format(dateTimeDisplayLayout), label)
+	if change.TaskID == "" {
+		line += fmt.Sprintf("%s (ID: %s)", change.ProjectName, change.ProjectID)
+	} else {
+		line += fmt.Sprintf("[%s] %s (ID: %s)", change.ProjectName, change.Title, change.TaskID)
+	}
+	if change.Details != "" {
+		line += " — " + change.Details
+	}
+	return line + "\n"
+}
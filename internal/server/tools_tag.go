@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"dida/internal/client"
+	"dida/internal/tags"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerTagTools 注册标签相关工具
+func registerTagTools(r *toolRegistrar) {
+	listTagsTool := mcp.NewTool("list_tags",
+		mcp.WithDescription("List all tags used by open tasks across projects, with the number of tasks and projects using each tag."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		withRefresh(),
+	)
+	r.AddTool(listTagsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		projects, errs, err := fetchAllProjectTasks(ctx, request.GetBool("refresh", false))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		type tagUsage struct {
+			name     string
+			tasks    int
+			projects []string
+		}
+		usage := make(map[string]*tagUsage)
+		for _, project := range projects {
+			for _, task := range project.Tasks {
+				for _, tag := range tags.Merge(task.Tags) {
+					u, ok := usage[tag]
+					if !ok {
+						u = &tagUsage{name: tag}
+						usage[tag] = u
+					}
+					u.tasks++
+					if len(u.projects) == 0 || u.projects[len(u.projects)-1] != project.Project.Name {
+						u.projects = append(u.projects, project.Project.Name)
+					}
+				}
+			}
+		}
+		if len(usage) == 0 {
+			return mcp.NewToolResultText("No tags found.\n" + formatFetchErrors(errs)), nil
+		}
+
+		list := make([]*tagUsage, 0, len(usage))
+		for _, u := range usage {
+			list = append(list, u)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].tasks != list[j].tasks {
+				return list[i].tasks > list[j].tasks
+			}
+			return list[i].name < list[j].name
+		})
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Found %d tags:\n\n", len(list))
+		for _, u := range list {
+			fmt.Fprintf(&b, "#%s: %d tasks (%s)\n", u.name, u.tasks, strings.Join(u.projects, ", "))
+		}
+		b.WriteString(formatFetchErrors(errs))
+		return mcp.NewToolResultText(b.String()), nil
+	})
+}
+
+// filterTasksByTag 返回带有指定标签的任务
+func filterTasksByTag(list []client.Task, tag string) []client.Task {
+	var filtered []client.Task
+	for _, task := range list {
+		if tags.Contains(task.Tags, tag) {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
+}
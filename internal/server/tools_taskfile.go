@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"dida/internal/taskfile"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerTaskFileTools 注册 CSV 和 Markdown 清单的导出和导入工具
+func registerTaskFileTools(r *toolRegistrar) {
+	exportTool := mcp.NewTool("export_tasks",
+		mcp.WithDescription("Export the open tasks of a project, including subtasks, as CSV or as a Markdown checklist. Markdown uses \"- [ ] title #tag 🛫 start 📅 due\" lines with the content and subtasks indented below each task; CSV has one row per task and per subtask (parent_id links a subtask to its task)."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("project_id",
+			mcp.Required(),
+			mcp.Description("ID of the project to export (\"inbox\" for the inbox)"),
+		),
+		mcp.WithString("format",
+			mcp.Required(),
+			mcp.Description("Output format"),
+			mcp.Enum(taskfile.FormatCSV, taskfile.FormatMarkdown),
+		),
+		mcp.WithArray("columns",
+			mcp.Description("CSV columns in output order, defaults to "+strings.Join(taskfile.DefaultColumns, ", ")),
+			mcp.WithStringItems(),
+		),
+		withRefresh(),
+	)
+	r.AddTool(exportTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		projectID, err := request.RequireString("project_id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		format, err := request.RequireString("format")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		export, err := ExportProjectTasks(ctx, TaskExportOptions{
+			ProjectID: projectID,
+			Format:    format,
+			Columns:   request.GetStringSlice("columns", nil),
+			Refresh:   request.GetBool("refresh", false),
+		})
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error exporting tasks: %v", err), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Exported %d tasks with %d subtasks:\n\n%s", export.Tasks, export.Subtasks, export.Data)), nil
+	})
+
+	previewImportTool := mcp.NewTool("preview_task_import",
+		mcp.WithDescription("Show the tasks import_tasks would create from CSV or a Markdown checklist, including entries with invalid values, without creating anything."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		withTaskImportArgs(),
+	)
+	r.AddTool(previewImportTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		data, opts, err := taskImportArgs(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		preview, err := PreviewTaskImport(data, opts)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error reading tasks: %v", err), nil
+		}
+		return mcp.NewToolResultText(preview), nil
+	})
+
+	importTool := mcp.NewTool("import_tasks",
+		mcp.WithDescription("Create tasks with subtasks from CSV or a Markdown checklist. In Markdown, top-level list items become tasks, deeper checklist items become their subtasks, indented text becomes the content, and \"📅 2026-11-01\" / \"🛫 2026-11-01 09:00\" set the due and start dates. In CSV, the header row names the columns (title, content, status, priority, start_date, due_date, all_day, tags, reminders, repeat, id, parent_id); rows whose parent_id matches another row's id become its subtasks. Values follow create_task, so dates may be natural language. Checked items are created and then marked completed."),
+		withTaskImportArgs(),
+	)
+	r.AddTool(importTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		data, opts, err := taskImportArgs(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result, err := ImportTasks(data, opts)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error importing tasks: %v", err), nil
+		}
+		return mcp.NewToolResultText(result), nil
+	})
+}
+
+// withTaskImportArgs preview_task_import 与 import_tasks 共用的参数
+func withTaskImportArgs() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		for _, opt := range []mcp.ToolOption{
+			mcp.WithString("data",
+				mcp.Required(),
+				mcp.Description("Contents of the CSV or Markdown file"),
+			),
+			mcp.WithString("format",
+				mcp.Required(),
+				mcp.Description("Format of data"),
+				mcp.Enum(taskfile.FormatCSV, taskfile.FormatMarkdown),
+			),
+			mcp.WithString("project_id",
+				mcp.Description("Project to create the tasks in, defaults to the inbox"),
+			),
+			mcp.WithObject("column_map",
+				mcp.Description(`CSV only: maps task fields to header names of the file, e.g. {"title": "Name", "due_date": "Deadline", "tags": "Labels"}. Unmapped fields are matched by header name, case-insensitively, and common aliases such as name, notes, due and done.`),
+			),
+			mcp.WithString("delimiter",
+				mcp.Description(`CSV only: field delimiter, defaults to ","; use "\t" for tab-separated files`),
+			),
+		} {
+			opt(tool)
+		}
+	}
+}
+
+// taskImportArgs 读取导入工具的参数
+func taskImportArgs(request mcp.CallToolRequest) ([]byte, TaskImportOptions, error) {
+	data, err := request.RequireString("data")
+	if err != nil {
+		return nil, TaskImportOptions{}, err
+	}
+	format, err := request.RequireString("format")
+	if err != nil {
+		return nil, TaskImportOptions{}, err
+	}
+	opts := TaskImportOptions{ProjectID: request.GetString("project_id", ""), Format: format}
+
+	if raw, ok := request.GetArguments()["column_map"]; ok && raw != nil {
+		columns, ok := raw.(map[string]any)
+		if !ok {
+			return nil, TaskImportOptions{}, fmt.Errorf("column_map must be an object of field names to header names")
+		}
+		opts.CSV.Mapping = make(map[string]string, len(columns))
+		for field, header := range columns {
+			name, ok := header.(string)
+			if !ok {
+				return nil, TaskImportOptions{}, fmt.Errorf("column_map value for %q must be a string", field)
+			}
+			opts.CSV.Mapping[field] = name
+		}
+	}
+	delimiter, err := ParseDelimiter(request.GetString("delimiter", ""))
+	if err != nil {
+		return nil, TaskImportOptions{}, err
+	}
+	opts.CSV.Delimiter = delimiter
+	return []byte(data), opts, nil
+}
+
+// ParseDelimiter 解析 CSV 分隔符参数，接受单个字符或 "\t"、"tab"，空字符串表示默认的逗号
+func ParseDelimiter(value string) (rune, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case `\t`, "tab":
+		return '\t', nil
+	}
+	if utf8.RuneCountInString(value) != 1 {
+		return 0, fmt.Errorf("invalid delimiter %q, expected a single character", value)
+	}
+	delimiter, _ := utf8.DecodeRuneInString(value)
+	return delimiter, nil
+}
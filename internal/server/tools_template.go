@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"dida/internal/client"
+	"dida/internal/dateparse"
+	"dida/internal/templates"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// templateDir 任务模板目录
+var templateDir = "templates"
+
+// registerTemplateTools 注册任务模板工具
+func registerTemplateTools(r *toolRegistrar) {
+	listTemplatesTool := mcp.NewTool("list_templates",
+		mcp.WithDescription("List the task templates available in the local template directory, with their variables."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	r.AddTool(listTemplatesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		list, errs, err := templates.Load(templateDir)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(list) == 0 && len(errs) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No templates found in %s", templateDir)), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Found %d templates in %s:\n", len(list), templateDir)
+		for _, t := range list {
+			b.WriteString("\n" + formatTemplate(t))
+		}
+		if len(errs) > 0 {
+			fmt.Fprintf(&b, "\nWarning: %d template file(s) could not be loaded:\n", len(errs))
+			for _, err := range errs {
+				fmt.Fprintf(&b, "- %v\n", err)
+			}
+		}
+		return mcp.NewToolResultText(b.String()), nil
+	})
+
+	previewTemplateTool := mcp.NewTool("preview_template",
+		mcp.WithDescription("Show the tasks a template would create with the given variables, without creating anything."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		withTemplateArgs(),
+	)
+	r.AddTool(previewTemplateTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		t, tasks, err := renderTemplate(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "Template %q would create %d tasks:\n", t.Name, len(tasks))
+		for i, task := range tasks {
+			fmt.Fprintf(&b, "\nTask %d:\n%s", i+1, FormatTask(task))
+		}
+		return mcp.NewToolResultText(b.String()), nil
+	})
+
+	instantiateTemplateTool := mcp.NewTool("instantiate_template",
+		mcp.WithDescription("Create the tasks described by a template. All tasks are validated before any is created; creation failures are reported per task."),
+		withTemplateArgs(),
+	)
+	r.AddTool(instantiateTemplateTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		t, tasks, err := renderTemplate(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// 按模板顺序依次创建，保证任务在清单中的顺序与模板一致
+		results := make([]batchResult, len(tasks))
+		for i, task := range tasks {
+			created, err := ticktickClient.CreateTask(task)
+			if err != nil {
+				results[i].err = fmt.Errorf("failed to create %q: %v", task.Title, err)
+				continue
+			}
+			results[i].message = fmt.Sprintf("created %q (ID: %s, Project ID: %s)", created.Title, created.ID, created.ProjectID)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Template %q\n%s", t.Name, formatBatchResults("create", results))), nil
+	})
+}
+
+// withTemplateArgs preview_template 与 instantiate_template 共用的参数
+func withTemplateArgs() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		for _, opt := range []mcp.ToolOption{
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Template name, see list_templates"),
+			),
+			mcp.WithObject("variables",
+				mcp.Description(`Values for the template's {{variables}}, e.g. {"version": "2.3.0"}`),
+			),
+			mcp.WithString("project_id",
+				mcp.Description("Project for tasks that do not set project_id in the template, defaults to the inbox"),
+			),
+			mcp.WithString("base_date",
+				mcp.Description("Date that relative dates such as \"+3d\" are counted from, defaults to now. Accepts the same formats as due_date."),
+			),
+		} {
+			opt(tool)
+		}
+	}
+}
+
+// renderTemplate 加载模板并用请求中的变量实例化为待创建的任务
+func renderTemplate(request mcp.CallToolRequest) (*templates.Template, []client.Task, error) {
+	name, err := request.RequireString("name")
+	if err != nil {
+		return nil, nil, err
+	}
+	t, err := templates.Find(templateDir, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vars := make(map[string]string)
+	if raw, ok := request.GetArguments()["variables"].(map[string]any); ok {
+		for key, value := range raw {
+			vars[key] = fmt.Sprint(value)
+		}
+	}
+
+	base := time.Now().In(userLocation)
+	if value := request.GetString("base_date", ""); value != "" {
+		result, err := dateparse.Parse(value, base)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid base_date: %v", err)
+		}
+		base = result.Time
+	}
+
+	specs, err := t.Render(vars, base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("template %q: %v", t.Name, err)
+	}
+
+	defaultProject := request.GetString("project_id", "")
+	tasks := make([]client.Task, len(specs))
+	for i, spec := range specs {
+		args := spec.Args()
+		if spec.ProjectID == "" && defaultProject != "" {
+			args["project_id"] = defaultProject
+		}
+		task, err := buildTaskFromArgs(newToolRequest("create_task", args))
+		if err != nil {
+			return nil, nil, fmt.Errorf("template %q task %d (%s): %v", t.Name, i+1, spec.Title, err)
+		}
+		for j, subtask := range spec.Subtasks {
+			item := client.TaskItem{Title: subtask.Title, SortOrder: int64(j)}
+			setItemCompleted(&item, subtask.Completed)
+			task.Items = append(task.Items, item)
+		}
+		tasks[i] = task
+	}
+	return t, tasks, nil
+}
+
+// formatTemplate 格式化模板概要
+func formatTemplate(t *templates.Template) string {
+	formatted := fmt.Sprintf("Name: %s\n", t.Name)
+	if t.Description != "" {
+		formatted += fmt.Sprintf("Description: %s\n", t.Description)
+	}
+	formatted += fmt.Sprintf("Tasks: %d\n", len(t.AllTasks()))
+	if len(t.Variables) > 0 {
+		formatted += "Variables:\n"
+		for _, v := range t.Variables {
+			line := "- " + v.Name
+			if v.Required() {
+				line += " (required)"
+			} else {
+				line += fmt.Sprintf(" (default %q)", *v.Default)
+			}
+			if v.Description != "" {
+				line += ": " + v.Description
+			}
+			formatted += line + "\n"
+		}
+	}
+	return formatted
+}
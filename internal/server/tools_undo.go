@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"dida/internal/client"
+	"dida/internal/mirror"
+	"dida/internal/syncer"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultActionLimit list_recent_actions 默认返回的记录数
+const defaultActionLimit = 20
+
+// actionLabels 修改类型的展示名称
+var actionLabels = map[string]string{
+	client.MutationCreate:   "created",
+	client.MutationUpdate:   "updated",
+	client.MutationComplete: "completed",
+	client.MutationDelete:   "deleted",
+	client.MutationMove:     "moved",
+}
+
+// registerUndoTools 注册修改日志和撤销工具
+func registerUndoTools(r *toolRegistrar) {
+	listActionsTool := mcp.NewTool("list_recent_actions",
+		mcp.WithDescription("List recent task changes made through this server (created, updated, completed, deleted and moved tasks), newest first, with the IDs needed by undo_action."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of actions to return (default %d)", defaultActionLimit)),
+		),
+		mcp.WithString("project_id",
+			mcp.Description("Only list actions on tasks in this project (\"inbox\" for the inbox)"),
+		),
+	)
+	r.AddTool(listActionsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !journalEnabled {
+			return mcp.NewToolResultError(journalDisabledMessage), nil
+		}
+		limit := request.GetInt("limit", defaultActionLimit)
+		projectID := request.GetString("project_id", "")
+		actions, err := taskMirror.RecentActions(0)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error reading action journal: %v", err), nil
+		}
+
+		var visible []mirror.Action
+		for _, action := range actions {
+			if !actionVisible(action) {
+				continue
+			}
+			if projectID != "" && !actionInProject(action, projectID) {
+				continue
+			}
+			visible = append(visible, action)
+			if limit > 0 && len(visible) == limit {
+				break
+			}
+		}
+		if len(visible) == 0 {
+			return mcp.NewToolResultText("No recent actions.\n"), nil
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "Found %d recent actions (newest first):\n\n", len(visible))
+		for _, action := range visible {
+			b.WriteString(formatAction(action))
+		}
+		return mcp.NewToolResultText(b.String()), nil
+	})
+
+	undoTool := mcp.NewTool("undo_action",
+		mcp.WithDescription("Undo a task change listed by list_recent_actions: deletes created tasks, restores the previous state of updated tasks, reopens completed tasks, recreates deleted tasks (with a new ID) and moves moved tasks back. Refuses when the task was changed again afterwards unless force is set."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("ID of the action, as shown by list_recent_actions"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Undo even if the task was changed again after the action, overwriting those changes (default false)"),
+		),
+	)
+	r.AddTool(undoTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !journalEnabled {
+			return mcp.NewToolResultError(journalDisabledMessage), nil
+		}
+		if err := ensureClientInitialized(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		value, err := request.RequireString("id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		id, err := parseEntryID(value, "action")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		action, err := taskMirror.ActionByID(id)
+		if isNotFound(err) || (err == nil && !actionVisible(*action)) {
+			return mcp.NewToolResultErrorf("Action #%d not found", id), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultErrorf("Error reading action journal: %v", err), nil
+		}
+
+		message, err := undoAction(action, request.GetBool("force", false))
+		if err != nil {
+			return mcp.NewToolResultErrorf("Failed to undo action #%d: %v", id, err), nil
+		}
+		if err := taskMirror.MarkUndone(id, firstLine(message)); err != nil {
+			message += fmt.Sprintf("\nWarning: could not mark the action as undone: %v", err)
+		}
+		return mcp.NewToolResultText(message), nil
+	})
+}
+
+// journalDisabledMessage 修改日志未启用时的提示
+const journalDisabledMessage = "Undo requires the local mirror; enable it with TICKTICK_MIRROR=true and TICKTICK_JOURNAL=true"
+
+// actionProjects 修改涉及的项目：当前所在项目，移动时还包括原项目
+func actionProjects(action mirror.Action) []string {
+	projects := []string{action.ProjectID}
+	if action.Before != nil && action.Before.ProjectID != action.ProjectID {
+		projects = append(projects, action.Before.ProjectID)
+	}
+	return projects
+}
+
+// actionVisible 判断修改涉及的项目是否都在访问策略允许的范围内
+func actionVisible(action mirror.Action) bool {
+	if !toolPolicy.RestrictsProjects() {
+		return true
+	}
+	for _, projectID := range actionProjects(action) {
+		if !allowProject(toolPolicy, projectID) {
+			return false
+		}
+	}
+	return true
+}
+
+// actionInProject 判断修改是否涉及指定项目
+func actionInProject(action mirror.Action, projectID string) bool {
+	for _, id := range actionProjects(action) {
+		if mirror.ProjectKey(id) == mirror.ProjectKey(projectID) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatAction 格式化一条修改记录
+func formatAction(action mirror.Action) string {
+	label, ok := actionLabels[action.Kind]
+	if !ok {
+		label = action.Kind
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "#%d %s %s %q (ID: %s, project: %s)", action.ID,
+		action.CreatedAt.In(userLocation).Format(dateTimeDisplayLayout), label, action.Title, action.TaskID, action.ProjectID)
+	switch {
+	case action.Kind == client.MutationUpdate && action.Before != nil && action.After != nil:
+		if fields := syncer.ChangedFields(*action.Before, *action.After); len(fields) > 0 {
+			b.WriteString(" — changed " + strings.Join(fields, ", "))
+		}
+	case action.Kind == client.MutationMove && action.Before != nil:
+		b.WriteString(" — from project " + action.Before.ProjectID)
+	}
+	b.WriteString("\n")
+	if !action.UndoneAt.IsZero() {
+		fmt.Fprintf(&b, "  Undone at %s: %s\n", action.UndoneAt.In(userLocation).Format(dateTimeDisplayLayout), action.UndoNote)
+	} else if action.Kind != client.MutationCreate && action.Before == nil {
+		b.WriteString("  Cannot be undone: the previous state was not captured\n")
+	}
+	return b.String()
+}
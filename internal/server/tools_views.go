@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"dida/internal/client"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxUpcomingDays get_upcoming 允许查询的最大天数
+const maxUpcomingDays = 90
+
+// registerViewTools 注册跨项目的任务视图工具（今天、未来几天、已过期）
+func registerViewTools(r *toolRegistrar) {
+	// 今天的任务
+	getTodayTool := mcp.NewTool("get_today",
+		mcp.WithDescription("Get tasks scheduled for today across all projects, grouped by project. Dates are evaluated in the user time zone."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithBoolean("include_overdue",
+			mcp.Description("Also list overdue tasks from previous days (default false)"),
+		),
+		withRefresh(),
+	)
+	r.AddTool(getTodayTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		now := time.Now().In(userLocation)
+		today := civilDate(now)
+		includeOverdue := request.GetBool("include_overdue", false)
+		return taskView(ctx, request.GetBool("refresh", false), "today", now, func(task client.Task, day time.Time) bool {
+			if day.Equal(today) {
+				return true
+			}
+			return includeOverdue && isOverdue(task, now)
+		})
+	})
+
+	// 未来几天的任务
+	getUpcomingTool := mcp.NewTool("get_upcoming",
+		mcp.WithDescription("Get tasks scheduled in the next N days across all projects, grouped by day and project. Dates are evaluated in the user time zone."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithNumber("days",
+			mcp.Description(fmt.Sprintf("Number of days to look ahead, counting today (default 7, max %d). Use days=2 for today and tomorrow.", maxUpcomingDays)),
+		),
+		mcp.WithBoolean("include_today",
+			mcp.Description("Whether today counts as the first day (default true). Set to false with days=1 to get only tomorrow."),
+		),
+		withRefresh(),
+	)
+	r.AddTool(getUpcomingTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		days := request.GetInt("days", 7)
+		if days < 1 || days > maxUpcomingDays {
+			return mcp.NewToolResultErrorf("days must be between 1 and %d", maxUpcomingDays), nil
+		}
+		now := time.Now().In(userLocation)
+		from := civilDate(now)
+		if !request.GetBool("include_today", true) {
+			from = from.AddDate(0, 0, 1)
+		}
+		to := from.AddDate(0, 0, days)
+		label := fmt.Sprintf("%s to %s", from.Format(dateDisplayLayout), to.AddDate(0, 0, -1).Format(dateDisplayLayout))
+		return taskView(ctx, request.GetBool("refresh", false), label, now, func(task client.Task, day time.Time) bool {
+			return !day.Before(from) && day.Before(to)
+		})
+	})
+
+	// 已过期的任务
+	getOverdueTool := mcp.NewTool("get_overdue",
+		mcp.WithDescription("Get overdue tasks across all projects, grouped by day and project. All-day tasks are overdue from the day after their due date."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		withRefresh(),
+	)
+	r.AddTool(getOverdueTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		now := time.Now().In(userLocation)
+		return taskView(ctx, request.GetBool("refresh", false), "overdue", now, func(task client.Task, day time.Time) bool {
+			return isOverdue(task, now)
+		})
+	})
+}
+
+// scheduledTask 带有所属项目和日期的任务
+type scheduledTask struct {
+	Project client.Project
+	Task    client.Task
+	Day     time.Time
+}
+
+// taskView 获取所有项目的未完成任务，按 match 过滤后以日期、项目分组输出
+func taskView(ctx context.Context, refresh bool, label string, now time.Time, match func(task client.Task, day time.Time) bool) (*mcp.CallToolResult, error) {
+	if err := ensureClientInitialized(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	projects, errs, err := fetchAllProjectTasks(ctx, refresh)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var matched []scheduledTask
+	for _, project := range projects {
+		for _, task := range project.Tasks {
+			if task.Status == client.TaskStatusCompleted {
+				continue
+			}
+			day, ok := taskDay(task)
+			if !ok || !match(task, day) {
+				continue
+			}
+			matched = append(matched, scheduledTask{Project: project.Project, Task: task, Day: day})
+		}
+	}
+
+	if len(matched) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No tasks found (%s).\n%s", label, formatFetchErrors(errs))), nil
+	}
+	result := fmt.Sprintf("Found %d tasks (%s):\n", len(matched), label)
+	result += formatScheduledTasks(matched, now)
+	result += formatFetchErrors(errs)
+	return mcp.NewToolResultText(result), nil
+}
+
+// taskAnchor 返回决定任务日期的时间：优先到期时间，其次开始时间
+func taskAnchor(task client.Task) client.Time {
+	if !task.DueDate.IsZero() {
+		return task.DueDate
+	}
+	return task.StartDate
+}
+
+// taskDay 返回任务在用户时区中所在的日期，没有日期的任务返回 false
+func taskDay(task client.Task) (time.Time, bool) {
+	anchor := taskAnchor(task)
+	if anchor.IsZero() {
+		return time.Time{}, false
+	}
+	return civilDate(anchor.InZone(task, userLocation)), true
+}
+
+// isOverdue 判断未完成任务是否已过期：全天任务按自然日，其余任务按精确时间
+func isOverdue(task client.Task, now time.Time) bool {
+	if task.Status == client.TaskStatusCompleted {
+		return false
+	}
+	day, ok := taskDay(task)
+	if !ok {
+		return false
+	}
+	if task.IsAllDay {
+		return day.Before(civilDate(now))
+	}
+	return taskAnchor(task).Before(now)
+}
+
+// formatScheduledTasks 按日期、项目分组格式化任务
+func formatScheduledTasks(tasks []scheduledTask, now time.Time) string {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if !tasks[i].Day.Equal(tasks[j].Day) {
+			return tasks[i].Day.Before(tasks[j].Day)
+		}
+		if tasks[i].Project.Name != tasks[j].Project.Name {
+			return tasks[i].Project.Name < tasks[j].Project.Name
+		}
+		// 同一天内全天任务在前，其余按时间排序
+		a, b := tasks[i].Task, tasks[j].Task
+		if a.IsAllDay != b.IsAllDay {
+			return a.IsAllDay
+		}
+		if !a.IsAllDay && !taskAnchor(a).Equal(taskAnchor(b).Time) {
+			return taskAnchor(a).Before(taskAnchor(b).Time)
+		}
+		return a.Priority > b.Priority
+	})
+
+	var b strings.Builder
+	var day time.Time
+	projectID := ""
+	for i, item := range tasks {
+		if i == 0 || !item.Day.Equal(day) {
+			day = item.Day
+			projectID = ""
+			fmt.Fprintf(&b, "\n## %s (%s)\n", day.Format(dateDisplayLayout), dayLabel(day, now))
+		}
+		if item.Project.ID != projectID {
+			projectID = item.Project.ID
+			fmt.Fprintf(&b, "\n### %s (Project ID: %s)\n", item.Project.Name, item.Project.ID)
+		}
+		b.WriteString(formatTaskLine(item.Task, now))
+	}
+	return b.String()
+}
+
+// dayLabel 返回日期相对今天的称呼
+func dayLabel(day, now time.Time) string {
+	days := int(day.Sub(civilDate(now)).Hours() / 24)
+	switch {
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "tomorrow"
+	case days == -1:
+		return "yesterday"
+	case days > 1:
+		return fmt.Sprintf("in %s", pluralDays(days))
+	default:
+		return fmt.Sprintf("%s ago", pluralDays(-days))
+	}
+}
+
+// formatTaskLine 将任务格式化为一行摘要
+func formatTaskLine(task client.Task, now time.Time) string {
+	anchor := taskAnchor(task)
+	details := []string{"ID: " + task.ID}
+	switch {
+	case anchor.IsZero():
+	case task.IsAllDay:
+		details = append(details, formatTaskDate(task, anchor))
+	default:
+		details = append(details, anchor.In(userLocation).Format("2006-01-02 15:04"))
+	}
+	if task.Priority != 0 {
+		details = append(details, "priority "+priorityMap[task.Priority])
+	}
+	if !task.DueDate.IsZero() {
+		details = append(details, relativeHint(task, task.DueDate, now, dueHints))
+	}
+	if len(task.Tags) > 0 {
+		details = append(details, formatTags(task.Tags))
+	}
+	if len(task.Items) > 0 {
+		done := 0
+		for _, item := range task.Items {
+			if item.Status == client.ItemStatusCompleted {
+				done++
+			}
+		}
+		details = append(details, fmt.Sprintf("subtasks %d/%d", done, len(task.Items)))
+	}
+	return fmt.Sprintf("- %s (%s)\n", task.Title, strings.Join(details, ", "))
+}
@@ -0,0 +1,276 @@
+// Package syncer 拉取所有项目和任务，与本地镜像中上次同步的快照比较并记录变更
+//
+// 第一次同步只建立快照；之后每次同步检测新建、更新、完成、删除和移动的任务，
+// 以及新建、重命名和删除的项目。消失的任务通过单独查询区分完成与删除。
+package syncer
+
+import (
+	"context"
+	"dida/internal/client"
+	"dida/internal/errors"
+	"dida/internal/mirror"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxConcurrentFetches 同步时并发拉取项目的最大请求数
+const maxConcurrentFetches = 4
+
+// Source 同步数据来源，通常为 *client.TickTickClient
+type Source interface {
+	GetProjects() ([]client.Project, error)
+	GetProjectWithData(projectID string) (*client.ProjectData, error)
+	GetTask(projectID, taskID string) (*client.Task, error)
+}
+
+// Result 一次同步的结果
+type Result struct {
+	SyncedAt time.Time
+	// Baseline 为 true 表示这是第一次同步，只建立了快照
+	Baseline bool
+	Changes  []mirror.Change
+	// Projects 拉取到的项目列表（不含收集箱）
+	Projects []client.Project
+	// Data 成功拉取的项目数据，以项目键索引
+	Data map[string]*client.ProjectData
+	// Errors 拉取失败的项目，这些项目本次不参与比较
+	Errors []error
+}
+
+// Engine 同步引擎，同一时间只执行一次同步
+type Engine struct {
+	source    func() (Source, error)
+	mirror    *mirror.Mirror
+	retention time.Duration
+	mu        sync.Mutex
+}
+
+// New 创建同步引擎；source 在每次同步时调用以获取当前的数据来源，
+// retention 为变更记录的保留时长，<= 0 表示永久保留
+func New(source func() (Source, error), m *mirror.Mirror, retention time.Duration) *Engine {
+	return &Engine{source: source, mirror: m, retention: retention}
+}
+
+// Sync 执行一次完整同步
+func (e *Engine) Sync(ctx context.Context) (*Result, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	source, err := e.source()
+	if err != nil {
+		return nil, err
+	}
+	syncedAt := time.Now()
+	projects, err := source.GetProjects()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching projects: %v", err)
+	}
+	result := &Result{SyncedAt: syncedAt, Projects: projects}
+	all := append([]client.Project{client.InboxProject()}, projects...)
+	result.Data, result.Errors = fetchProjects(ctx, source, all)
+
+	snapshot, err := e.mirror.LoadSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("error loading snapshot: %v", err)
+	}
+	if snapshot.SyncedAt.IsZero() {
+		result.Baseline = true
+	} else {
+		result.Changes = diff(source, snapshot, all, result.Data)
+	}
+
+	tasks := make(map[string][]client.Task, len(result.Data))
+	for key, data := range result.Data {
+		tasks[key] = data.Tasks
+	}
+	if err := e.mirror.SaveSnapshot(projects, tasks, result.Changes, syncedAt); err != nil {
+		return nil, fmt.Errorf("error saving snapshot: %v", err)
+	}
+	if e.retention > 0 {
+		if err := e.mirror.PruneChanges(syncedAt.Add(-e.retention)); err != nil {
+			return nil, fmt.Errorf("error pruning changes: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// fetchProjects 以有限并发拉取项目数据，失败的项目通过 errs 返回
+func fetchProjects(ctx context.Context, source Source, projects []client.Project) (map[string]*client.ProjectData, []error) {
+	data := make([]*client.ProjectData, len(projects))
+	fetchErrs := make([]error, len(projects))
+	sem := make(chan struct{}, maxConcurrentFetches)
+	var wg sync.WaitGroup
+	for i, project := range projects {
+		wg.Add(1)
+		go func(i int, project client.Project) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				fetchErrs[i] = fmt.Errorf("project %s: %v", project.Name, ctx.Err())
+				return
+			}
+			data[i], fetchErrs[i] = source.GetProjectWithData(project.ID)
+			if fetchErrs[i] != nil {
+				fetchErrs[i] = fmt.Errorf("project %s: %v", project.Name, fetchErrs[i])
+			}
+		}(i, project)
+	}
+	wg.Wait()
+
+	fetched := make(map[string]*client.ProjectData, len(projects))
+	var errs []error
+	for i, project := range projects {
+		if fetchErrs[i] != nil {
+			errs = append(errs, fetchErrs[i])
+			continue
+		}
+		fetched[mirror.ProjectKey(project.ID)] = data[i]
+	}
+	return fetched, errs
+}
+
+// diff 比较快照与本次拉取的数据，返回检测到的变更
+func diff(source Source, snapshot *mirror.Snapshot, projects []client.Project, data map[string]*client.ProjectData) []mirror.Change {
+	var changes []mirror.Change
+	names := make(map[string]string, len(projects))
+	for _, p := range projects {
+		key := mirror.ProjectKey(p.ID)
+		names[key] = p.Name
+		if key == client.InboxProjectID {
+			continue
+		}
+		old, ok := snapshot.Projects[p.ID]
+		switch {
+		case !ok:
+			changes = append(changes, mirror.Change{Kind: mirror.ChangeProjectCreated, ProjectID: p.ID, ProjectName: p.Name})
+		case old != p.Name:
+			changes = append(changes, mirror.Change{Kind: mirror.ChangeProjectRenamed, ProjectID: p.ID, ProjectName: p.Name,
+				Details: fmt.Sprintf("renamed from %q", old)})
+		}
+	}
+	for id, name := range snapshot.Projects {
+		if _, ok := names[id]; !ok {
+			changes = append(changes, mirror.Change{Kind: mirror.ChangeProjectDeleted, ProjectID: id, ProjectName: name})
+		}
+	}
+
+	projectName := func(key string) string {
+		if name, ok := names[key]; ok {
+			return name
+		}
+		return snapshot.Projects[key]
+	}
+
+	current := make(map[string]bool)
+	for _, p := range projects {
+		key := mirror.ProjectKey(p.ID)
+		projectData, ok := data[key]
+		if !ok {
+			continue
+		}
+		for _, task := range projectData.Tasks {
+			current[task.ID] = true
+			change := mirror.Change{ProjectID: task.ProjectID, ProjectName: p.Name, TaskID: task.ID, Title: task.Title}
+			old, ok := snapshot.Tasks[task.ID]
+			switch {
+			case !ok:
+				change.Kind = mirror.ChangeTaskCreated
+			case old.ProjectKey != key:
+				change.Kind = mirror.ChangeTaskMoved
+				change.Details = fmt.Sprintf("moved from %s", projectName(old.ProjectKey))
+			default:
+				fields := ChangedFields(old.Task, task)
+				if len(fields) == 0 {
+					continue
+				}
+				change.Kind = mirror.ChangeTaskUpdated
+				change.Details = "changed " + strings.Join(fields, ", ")
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	// 快照中有、本次没有的任务：只检查本次成功拉取的项目和已删除的项目
+	var missing []mirror.SnapshotTask
+	for id, old := range snapshot.Tasks {
+		if current[id] {
+			continue
+		}
+		_, fetched := data[old.ProjectKey]
+		_, exists := names[old.ProjectKey]
+		if fetched || !exists {
+			missing = append(missing, old)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].ProjectKey != missing[j].ProjectKey {
+			return missing[i].ProjectKey < missing[j].ProjectKey
+		}
+		return missing[i].Task.ID < missing[j].Task.ID
+	})
+	for _, old := range missing {
+		change := mirror.Change{
+			Kind:        mirror.ChangeTaskDeleted,
+			ProjectID:   old.Task.ProjectID,
+			ProjectName: projectName(old.ProjectKey),
+			TaskID:      old.Task.ID,
+			Title:       old.Task.Title,
+		}
+		// 项目数据只包含未完成任务，查询任务本身以区分完成和删除
+		task, err := source.GetTask(old.Task.ProjectID, old.Task.ID)
+		switch {
+		case err == nil && task.Status == client.TaskStatusCompleted:
+			change.Kind = mirror.ChangeTaskCompleted
+		case err == nil && mirror.ProjectKey(task.ProjectID) != old.ProjectKey:
+			change.Kind = mirror.ChangeTaskMoved
+			change.ProjectID = task.ProjectID
+			change.ProjectName = projectName(mirror.ProjectKey(task.ProjectID))
+			change.Details = fmt.Sprintf("moved from %s", projectName(old.ProjectKey))
+		case err == nil:
+			// 任务仍然存在且未完成，可能是 API 列表的暂时不一致，不记录
+			continue
+		case errors.GetStatusCode(err) == http.StatusNotFound:
+		default:
+			change.Details = fmt.Sprintf("no longer listed, could not verify: %v", err)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// ChangedFields 返回两个版本之间发生变化的任务字段名，忽略排序值等内部字段
+func ChangedFields(before, after client.Task) []string {
+	var fields []string
+	check := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	check("title", before.Title != after.Title)
+	check("content", before.Content != after.Content || before.Desc != after.Desc)
+	check("start date", before.StartDate.String() != after.StartDate.String())
+	check("due date", before.DueDate.String() != after.DueDate.String())
+	check("all day", before.IsAllDay != after.IsAllDay)
+	check("priority", before.Priority != after.Priority)
+	check("reminders", strings.Join(before.Reminders, ",") != strings.Join(after.Reminders, ","))
+	check("repeat", before.RepeatFlag != after.RepeatFlag)
+	check("tags", strings.Join(before.Tags, ",") != strings.Join(after.Tags, ","))
+	check("column", before.ColumnID != after.ColumnID)
+	check("subtasks", subtaskSummary(before.Items) != subtaskSummary(after.Items))
+	return fields
+}
+
+// subtaskSummary 将子任务标题和状态拼接为可比较的字符串
+func subtaskSummary(items []client.TaskItem) string {
+	var b strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&b, "%d:%s\n", item.Status, item.Title)
+	}
+	return b.String()
+}
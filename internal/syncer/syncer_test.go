@@ -0,0 +1,165 @@
+package syncer
+
+import (
+	"context"
+	"dida/internal/client"
+	"dida/internal/errors"
+	"dida/internal/mirror"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeSource 内存中的数据来源；tasks 为各项目的未完成任务，closed 为已完成或已移走、只能单独查询到的任务
+type fakeSource struct {
+	projects []client.Project
+	tasks    map[string][]client.Task
+	closed   map[string]client.Task
+	failing  map[string]bool
+}
+
+func (f *fakeSource) GetProjects() ([]client.Project, error) {
+	return f.projects, nil
+}
+
+func (f *fakeSource) GetProjectWithData(projectID string) (*client.ProjectData, error) {
+	if f.failing[projectID] {
+		return nil, fmt.Errorf("unavailable")
+	}
+	return &client.ProjectData{Tasks: f.tasks[projectID]}, nil
+}
+
+func (f *fakeSource) GetTask(projectID, taskID string) (*client.Task, error) {
+	if task, ok := f.closed[taskID]; ok {
+		return &task, nil
+	}
+	return nil, &errors.AppError{Code: errors.ErrAPIRequest, StatusCode: http.StatusNotFound}
+}
+
+func newEngine(t *testing.T, source *fakeSource) *Engine {
+	t.Helper()
+	m, err := mirror.Open(filepath.Join(t.TempDir(), "mirror.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return New(func() (Source, error) { return source, nil }, m, 0)
+}
+
+func task(id, projectID, title string) client.Task {
+	return client.Task{ID: id, ProjectID: projectID, Title: title}
+}
+
+// describeChanges 将变更格式化为 "类型 ID 说明" 并排序，便于比较
+func describeChanges(changes []mirror.Change) []string {
+	lines := make([]string, len(changes))
+	for i, c := range changes {
+		id := c.TaskID
+		if id == "" {
+			id = c.ProjectID
+		}
+		lines[i] = strings.TrimSpace(fmt.Sprintf("%s %s %s", c.Kind, id, c.Details))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func TestSync(t *testing.T) {
+	source := &fakeSource{
+		projects: []client.Project{{ID: "p1", Name: "Work"}, {ID: "p2", Name: "Home"}, {ID: "p3", Name: "Old"}},
+		tasks: map[string][]client.Task{
+			"inbox": {task("a", "inbox123", "Inbox task")},
+			"p1":    {task("b", "p1", "Report"), task("c", "p1", "Done soon"), task("d", "p1", "Delete me"), task("e", "p1", "Move me")},
+			"p2":    {task("f", "p2", "Gym"), task("g", "p2", "Moved away")},
+		},
+	}
+	engine := newEngine(t, source)
+
+	result, err := engine.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Baseline || len(result.Changes) != 0 {
+		t.Fatalf("first sync = baseline %v, changes %v", result.Baseline, result.Changes)
+	}
+
+	updated := task("b", "p1", "Report v2")
+	updated.Priority = 5
+	source.projects = []client.Project{{ID: "p1", Name: "Work"}, {ID: "p2", Name: "House"}, {ID: "p4", Name: "New"}}
+	source.tasks = map[string][]client.Task{
+		"inbox": {task("a", "inbox123", "Inbox task"), task("h", "inbox123", "Fresh")},
+		"p1":    {updated},
+		"p2":    {task("f", "p2", "Gym"), task("e", "p2", "Move me")},
+		"p4":    nil,
+	}
+	completed := task("c", "p1", "Done soon")
+	completed.Status = client.TaskStatusCompleted
+	source.closed = map[string]client.Task{"c": completed, "g": task("g", "p4", "Moved away")}
+
+	result, err = engine.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"project_created p4",
+		"project_deleted p3",
+		`project_renamed p2 renamed from "Home"`,
+		"task_completed c",
+		"task_created h",
+		"task_deleted d",
+		"task_moved e moved from Work",
+		"task_moved g moved from House",
+		"task_updated b changed title, priority",
+	}
+	if got := describeChanges(result.Changes); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 没有变化时不记录任何变更
+	result, err = engine.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 0 {
+		t.Errorf("unchanged sync recorded %v", describeChanges(result.Changes))
+	}
+}
+
+func TestSyncSkipsFailedProjects(t *testing.T) {
+	source := &fakeSource{
+		projects: []client.Project{{ID: "p1", Name: "Work"}},
+		tasks:    map[string][]client.Task{"p1": {task("a", "p1", "Keep")}},
+	}
+	engine := newEngine(t, source)
+	if _, err := engine.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 拉取失败的项目中的任务不应被当作已删除
+	source.failing = map[string]bool{"p1": true}
+	result, err := engine.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 1 || len(result.Changes) != 0 {
+		t.Errorf("errors %v, changes %v", result.Errors, describeChanges(result.Changes))
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	before := client.Task{Title: "a", Reminders: []string{"TRIGGER:PT0S"}, Items: []client.TaskItem{{Title: "x"}}, SortOrder: 1}
+	after := before
+	after.SortOrder = 2
+	if fields := ChangedFields(before, after); len(fields) != 0 {
+		t.Errorf("sort order change reported as %v", fields)
+	}
+	after.Items = []client.TaskItem{{Title: "x", Status: client.ItemStatusCompleted}}
+	after.Tags = []string{"t"}
+	after.Content = "c"
+	if got := strings.Join(ChangedFields(before, after), ", "); got != "content, tags, subtasks" {
+		t.Errorf("ChangedFields = %s", got)
+	}
+}
@@ -0,0 +1,71 @@
+// Package tags 处理任务标签：规范化、合并以及从标题中提取 #标签
+package tags
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// inlinePattern 匹配标题中的 #标签，标签前须为行首或空白
+var inlinePattern = regexp.MustCompile(`(^|\s)#([\p{L}\p{N}_\-/]+)`)
+
+// Normalize 规范化标签：去掉前导 #、首尾空白并转为小写（TickTick 的标签不区分大小写）
+func Normalize(tag string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(tag), "#")))
+}
+
+// ParseTitle 从标题中提取 #标签，返回去掉标签后的标题和提取出的标签
+// 纯数字的 #123 通常是编号而不是标签，保留在标题中
+func ParseTitle(title string) (string, []string) {
+	var found []string
+	cleaned := inlinePattern.ReplaceAllStringFunc(title, func(match string) string {
+		groups := inlinePattern.FindStringSubmatch(match)
+		if !hasLetter(groups[2]) {
+			return match
+		}
+		found = append(found, Normalize(groups[2]))
+		return groups[1]
+	})
+	if len(found) == 0 {
+		return title, nil
+	}
+	return strings.Join(strings.Fields(cleaned), " "), Merge(nil, found)
+}
+
+// Merge 合并标签列表，规范化并去重，保持首次出现的顺序
+func Merge(lists ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, tag := range list {
+			tag = Normalize(tag)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// Contains 判断标签列表中是否包含 tag（不区分大小写，忽略 #）
+func Contains(list []string, tag string) bool {
+	tag = Normalize(tag)
+	for _, t := range list {
+		if Normalize(t) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLetter(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,353 @@
+package taskfile
+
+import (
+	"dida/internal/client"
+	"dida/internal/reminder"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV 列名
+const (
+	ColumnID        = "id"
+	ColumnParentID  = "parent_id"
+	ColumnTitle     = "title"
+	ColumnContent   = "content"
+	ColumnStatus    = "status"
+	ColumnPriority  = "priority"
+	ColumnStartDate = "start_date"
+	ColumnDueDate   = "due_date"
+	ColumnAllDay    = "all_day"
+	ColumnTags      = "tags"
+	ColumnReminders = "reminders"
+	ColumnRepeat    = "repeat"
+)
+
+// DefaultColumns 导出 CSV 的默认列及其顺序
+var DefaultColumns = []string{
+	ColumnID, ColumnParentID, ColumnTitle, ColumnContent, ColumnStatus, ColumnPriority,
+	ColumnStartDate, ColumnDueDate, ColumnAllDay, ColumnTags, ColumnReminders, ColumnRepeat,
+}
+
+// columnAliases 导入时识别的常见表头写法（已规范化）
+var columnAliases = map[string]string{
+	"name": ColumnTitle, "task": ColumnTitle, "summary": ColumnTitle, "subject": ColumnTitle,
+	"notes": ColumnContent, "note": ColumnContent, "description": ColumnContent, "desc": ColumnContent,
+	"due": ColumnDueDate, "deadline": ColumnDueDate, "due_time": ColumnDueDate,
+	"start": ColumnStartDate, "start_time": ColumnStartDate,
+	"labels": ColumnTags, "tag": ColumnTags,
+	"done": ColumnStatus, "completed": ColumnStatus, "state": ColumnStatus,
+	"reminder": ColumnReminders, "recurrence": ColumnRepeat, "rrule": ColumnRepeat,
+	"parent": ColumnParentID, "task_id": ColumnID,
+}
+
+// 多值字段的分隔符
+const (
+	tagSeparator      = ","
+	reminderSeparator = ";"
+)
+
+// CSVOptions 导入 CSV 的选项
+type CSVOptions struct {
+	// Mapping 字段到表头的映射，如 {"title": "Name", "due_date": "Deadline"}；
+	// 未映射的字段按表头名称（不区分大小写，忽略空格和连字符）及常见别名识别
+	Mapping map[string]string
+	// Delimiter 字段分隔符，为 0 时使用逗号
+	Delimiter rune
+}
+
+// IsColumn 判断是否为支持的列名
+func IsColumn(name string) bool {
+	for _, column := range DefaultColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteCSV 将任务输出为 CSV，第一行为表头；每个子任务占一行，parent_id 为所属任务的 ID
+func WriteCSV(w io.Writer, tasks []client.Task, opts ExportOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	for _, column := range columns {
+		if !IsColumn(column) {
+			return fmt.Errorf("unknown column %q, expected one of %s", column, strings.Join(DefaultColumns, ", "))
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if err := writer.Write(taskRow(task, columns, opts.location())); err != nil {
+			return err
+		}
+		for _, item := range task.Items {
+			if err := writer.Write(itemRow(task, item, columns)); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// taskRow 输出任务行
+func taskRow(task client.Task, columns []string, loc *time.Location) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		switch column {
+		case ColumnID:
+			row[i] = task.ID
+		case ColumnTitle:
+			row[i] = task.Title
+		case ColumnContent:
+			row[i] = task.Content
+		case ColumnStatus:
+			row[i] = statusName(task.Status == client.TaskStatusCompleted)
+		case ColumnPriority:
+			row[i] = priorityNames[task.Priority]
+		case ColumnStartDate:
+			row[i] = formatDate(task, task.StartDate, loc)
+		case ColumnDueDate:
+			row[i] = formatDate(task, task.DueDate, loc)
+		case ColumnAllDay:
+			if !task.StartDate.IsZero() || !task.DueDate.IsZero() {
+				row[i] = strconv.FormatBool(task.IsAllDay)
+			}
+		case ColumnTags:
+			row[i] = strings.Join(task.Tags, tagSeparator)
+		case ColumnReminders:
+			described := make([]string, len(task.Reminders))
+			for j, trigger := range task.Reminders {
+				described[j] = reminder.Describe(trigger, task.IsAllDay)
+			}
+			row[i] = strings.Join(described, reminderSeparator+" ")
+		case ColumnRepeat:
+			row[i] = task.RepeatFlag
+		}
+	}
+	return row
+}
+
+// itemRow 输出子任务行，只填写 ID、所属任务、标题和状态
+func itemRow(task client.Task, item client.TaskItem, columns []string) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		switch column {
+		case ColumnID:
+			row[i] = item.ID
+		case ColumnParentID:
+			row[i] = task.ID
+		case ColumnTitle:
+			row[i] = item.Title
+		case ColumnStatus:
+			row[i] = statusName(item.Status == client.ItemStatusCompleted)
+		}
+	}
+	return row
+}
+
+// statusName 状态列的取值
+func statusName(completed bool) string {
+	if completed {
+		return "completed"
+	}
+	return "open"
+}
+
+// ReadCSV 读取带表头的 CSV，每行为一个任务；parent_id 与其他行的 id 相同的行作为该任务的子任务
+// 子任务行只使用标题和状态。日期、提醒、重复等原样放入参数，由调用方解析
+func ReadCSV(r io.Reader, opts CSVOptions) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("empty CSV, expected a header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	index, err := columnIndex(header, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := index[ColumnTitle]; !ok {
+		return nil, fmt.Errorf("no title column in header %q, map one with title=<header>", strings.Join(header, ","))
+	}
+
+	type row struct {
+		line   int
+		fields map[string]string
+	}
+	var rows []row
+	ids := make(map[string]bool)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		fields := make(map[string]string, len(index))
+		empty := true
+		for column, i := range index {
+			if i < len(record) {
+				fields[column] = strings.TrimSpace(record[i])
+				empty = empty && fields[column] == ""
+			}
+		}
+		if empty {
+			continue
+		}
+		if fields[ColumnTitle] == "" {
+			return nil, fmt.Errorf("line %d: missing title", line)
+		}
+		if id := fields[ColumnID]; id != "" {
+			ids[id] = true
+		}
+		rows = append(rows, row{line: line, fields: fields})
+	}
+
+	// 先创建任务条目，再把子任务行挂到所属任务上，子任务行可以出现在任务行之前
+	var entries []Entry
+	byID := make(map[string]int)
+	for _, row := range rows {
+		if parent := row.fields[ColumnParentID]; parent != "" && ids[parent] {
+			continue
+		}
+		entry, err := csvEntry(row.line, row.fields)
+		if err != nil {
+			return nil, err
+		}
+		if id := row.fields[ColumnID]; id != "" {
+			byID[id] = len(entries)
+		}
+		entries = append(entries, entry)
+	}
+	for _, row := range rows {
+		parent := row.fields[ColumnParentID]
+		if parent == "" || !ids[parent] {
+			continue
+		}
+		i, ok := byID[parent]
+		if !ok {
+			return nil, fmt.Errorf("line %d: parent %s is itself a subtask", row.line, parent)
+		}
+		entries[i].Subtasks = append(entries[i].Subtasks, Subtask{
+			Title:     row.fields[ColumnTitle],
+			Completed: completedValues[strings.ToLower(row.fields[ColumnStatus])],
+		})
+	}
+	return entries, nil
+}
+
+// columnIndex 确定各字段所在的列：先按映射查找表头，未映射的字段按表头名称和别名识别
+func columnIndex(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int)
+	normalized := make([]string, len(header))
+	for i, name := range header {
+		normalized[i] = normalizeHeader(name)
+	}
+
+	mapped := make(map[int]bool)
+	for field, name := range mapping {
+		field = normalizeHeader(field)
+		if !IsColumn(field) {
+			return nil, fmt.Errorf("unknown field %q in column mapping, expected one of %s", field, strings.Join(DefaultColumns, ", "))
+		}
+		found := false
+		for i := range header {
+			if normalized[i] == normalizeHeader(name) {
+				index[field], mapped[i], found = i, true, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("column %q mapped to %s not found in header %q", name, field, strings.Join(header, ","))
+		}
+	}
+
+	for i, name := range normalized {
+		if mapped[i] {
+			continue
+		}
+		field := name
+		if alias, ok := columnAliases[name]; ok {
+			field = alias
+		}
+		if _, taken := index[field]; IsColumn(field) && !taken {
+			index[field] = i
+		}
+	}
+	return index, nil
+}
+
+// normalizeHeader 规范化表头：小写，空格和连字符替换为下划线，去掉 BOM
+func normalizeHeader(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	}), "_")
+}
+
+// csvEntry 将一行转换为 create_task 参数
+func csvEntry(line int, fields map[string]string) (Entry, error) {
+	entry := Entry{Line: line, Args: map[string]any{ColumnTitle: fields[ColumnTitle]}}
+	for _, column := range []string{ColumnContent, ColumnStartDate, ColumnDueDate, ColumnRepeat} {
+		if value := fields[column]; value != "" {
+			entry.Args[column] = value
+		}
+	}
+	// 全天列为 true 时只保留日期部分，使任务成为全天任务
+	if allDay, err := strconv.ParseBool(fields[ColumnAllDay]); err == nil && allDay {
+		for _, column := range []string{ColumnStartDate, ColumnDueDate} {
+			if value, ok := entry.Args[column].(string); ok && len(value) > len(dateLayout) {
+				if _, err := time.Parse(dateLayout, value[:len(dateLayout)]); err == nil {
+					entry.Args[column] = value[:len(dateLayout)]
+				}
+			}
+		}
+	}
+	if value := fields[ColumnPriority]; value != "" {
+		priority, ok := priorityValues[strings.ToLower(value)]
+		if !ok {
+			return Entry{}, fmt.Errorf("line %d: invalid priority %q, expected none, low, medium or high", line, value)
+		}
+		entry.Args[ColumnPriority] = priority
+	}
+	if tags := splitField(fields[ColumnTags], tagSeparator); len(tags) > 0 {
+		entry.Args[ColumnTags] = tags
+	}
+	if reminders := splitField(fields[ColumnReminders], reminderSeparator); len(reminders) > 0 {
+		entry.Args[ColumnReminders] = reminders
+	}
+	entry.Completed = completedValues[strings.ToLower(fields[ColumnStatus])]
+	return entry, nil
+}
+
+// splitField 拆分多值字段，忽略空项
+func splitField(value, separator string) []string {
+	var items []string
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,166 @@
+package taskfile
+
+import (
+	"dida/internal/client"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testLoc = time.FixedZone("CST", 8*3600)
+
+// testTasks 导出测试使用的任务
+func testTasks() []client.Task {
+	due := time.Date(2026, 11, 1, 18, 30, 0, 0, testLoc)
+	return []client.Task{
+		{
+			ID: "t1", Title: "Report, draft", Content: "line 1\nline 2", Priority: 5,
+			StartDate: client.NewTime(due.Add(-2 * time.Hour)), DueDate: client.NewTime(due),
+			Tags: []string{"work", "q4"}, Reminders: []string{"TRIGGER:-PT15M", "TRIGGER:PT0S"},
+			RepeatFlag: "RRULE:FREQ=WEEKLY;INTERVAL=1",
+			Items:      []client.TaskItem{{ID: "i1", Title: "outline"}, {ID: "i2", Title: "review", Status: client.ItemStatusCompleted}},
+		},
+		{
+			ID: "t2", Title: "Holiday", IsAllDay: true, Status: client.TaskStatusCompleted,
+			DueDate: client.NewTime(time.Date(2026, 10, 31, 16, 0, 0, 0, time.UTC)), Reminders: []string{"TRIGGER:-PT15H"},
+		},
+		{ID: "t3", Title: "Someday"},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b strings.Builder
+	if err := WriteCSV(&b, testTasks(), ExportOptions{Location: testLoc}); err != nil {
+		t.Fatal(err)
+	}
+	want := `id,parent_id,title,content,status,priority,start_date,due_date,all_day,tags,reminders,repeat
+t1,,"Report, draft","line 1
+line 2",open,high,2026-11-01 16:30,2026-11-01 18:30,false,"work,q4",15m before; on time,RRULE:FREQ=WEEKLY;INTERVAL=1
+i1,t1,outline,,open,,,,,,,
+i2,t1,review,,completed,,,,,,,
+t2,,Holiday,,completed,none,,2026-11-01,true,,1d before 9:00,
+t3,,Someday,,open,none,,,,,,
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteCSV =\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	if err := WriteCSV(&b, testTasks()[2:], ExportOptions{Columns: []string{ColumnTitle, ColumnStatus}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "title,status\nSomeday,open\n" {
+		t.Errorf("WriteCSV with columns = %q", got)
+	}
+	if err := WriteCSV(&b, nil, ExportOptions{Columns: []string{"color"}}); err == nil {
+		t.Error("WriteCSV accepted an unknown column")
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	var b strings.Builder
+	if err := WriteCSV(&b, testTasks(), ExportOptions{Location: testLoc}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ReadCSV(strings.NewReader(b.String()), CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Line: 2, Args: map[string]any{
+			"title": "Report, draft", "content": "line 1\nline 2", "priority": 5,
+			"start_date": "2026-11-01 16:30", "due_date": "2026-11-01 18:30",
+			"tags": []string{"work", "q4"}, "reminders": []string{"15m before", "on time"},
+			"repeat": "RRULE:FREQ=WEEKLY;INTERVAL=1",
+		}, Subtasks: []Subtask{{Title: "outline"}, {Title: "review", Completed: true}}},
+		{Line: 6, Args: map[string]any{
+			"title": "Holiday", "priority": 0, "due_date": "2026-11-01", "reminders": []string{"1d before 9:00"},
+		}, Completed: true},
+		{Line: 7, Args: map[string]any{"title": "Someday", "priority": 0}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ReadCSV =\n%+v\nwant\n%+v", entries, want)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		opts CSVOptions
+		want []Entry
+	}{
+		{
+			name: "aliases, BOM and blank rows",
+			data: "\uFEFFName,Notes,Due,Done,Labels\nBuy milk,2%,tomorrow,yes,home; errands\n,,,,\n",
+			want: []Entry{{Line: 2, Args: map[string]any{
+				"title": "Buy milk", "content": "2%", "due_date": "tomorrow", "tags": []string{"home; errands"},
+			}, Completed: true}},
+		},
+		{
+			name: "column mapping and tab delimiter",
+			data: "Task\tWhen\tTask ID\nWrite\t2026-11-01 09:00\t7\n",
+			opts: CSVOptions{Mapping: map[string]string{"due_date": "when", "title": "Task"}, Delimiter: '\t'},
+			want: []Entry{{Line: 2, Args: map[string]any{"title": "Write", "due_date": "2026-11-01 09:00"}}},
+		},
+		{
+			name: "all_day keeps the date only",
+			data: "title,due_date,all_day\nTrip,2026-11-01 09:00,true\n",
+			want: []Entry{{Line: 2, Args: map[string]any{"title": "Trip", "due_date": "2026-11-01"}}},
+		},
+		{
+			name: "subtask rows before their task",
+			data: "id,parent_id,title,status\n2,1,child,done\n1,,parent,\n3,missing,orphan,\n",
+			want: []Entry{
+				{Line: 3, Args: map[string]any{"title": "parent"}, Subtasks: []Subtask{{Title: "child", Completed: true}}},
+				{Line: 4, Args: map[string]any{"title": "orphan"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ReadCSV(strings.NewReader(tt.data), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("ReadCSV =\n%+v\nwant\n%+v", entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		opts CSVOptions
+		want string
+	}{
+		{"", CSVOptions{}, "empty CSV"},
+		{"name_of_thing\nx\n", CSVOptions{}, "no title column"},
+		{"title,notes\n,text\n", CSVOptions{}, "line 2: missing title"},
+		{"title,priority\nx,urgent\n", CSVOptions{}, "invalid priority"},
+		{"title\nx\n", CSVOptions{Mapping: map[string]string{"color": "title"}}, "unknown field"},
+		{"title\nx\n", CSVOptions{Mapping: map[string]string{"due_date": "Deadline"}}, "not found in header"},
+		{"id,parent_id,title\n1,,a\n2,1,b\n3,2,c\n", CSVOptions{}, "is itself a subtask"},
+	}
+	for _, tt := range tests {
+		_, err := ReadCSV(strings.NewReader(tt.data), tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ReadCSV(%q) error = %v, want %q", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]string{
+		"tasks.csv": FormatCSV, "TASKS.TSV": FormatCSV, "notes.md": FormatMarkdown,
+		"a.markdown": FormatMarkdown, "list.txt": FormatMarkdown, "data.json": "", "": "",
+	}
+	for path, want := range tests {
+		if got := FormatFromPath(path); got != want {
+			t.Errorf("FormatFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
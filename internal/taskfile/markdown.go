@@ -0,0 +1,157 @@
+package taskfile
+
+import (
+	"bufio"
+	"dida/internal/client"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Markdown 中日期的标记，与 Obsidian Tasks 插件的写法一致
+const (
+	dueMarker   = "📅"
+	startMarker = "🛫"
+)
+
+var (
+	// listItemPattern 匹配列表项 "- [ ] 标题"、"* [x] 标题" 和不带复选框的 "- 标题"
+	listItemPattern = regexp.MustCompile(`^(\s*)[-*+]\s+(?:\[([ xX])\]\s+)?(.*)$`)
+	// datePattern 匹配标题中的日期标记及其后的日期和可选的时刻
+	datePattern = regexp.MustCompile(`\s*(📅|🛫|⏳|✅)\s*(\d{4}-\d{2}-\d{2}(?:[ T]\d{1,2}:\d{2})?)`)
+)
+
+// WriteMarkdown 将任务输出为 Markdown 清单：子任务缩进在任务下，正文以缩进的段落放在子任务之前
+func WriteMarkdown(w io.Writer, tasks []client.Task, opts ExportOptions) error {
+	var b strings.Builder
+	if opts.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", opts.Title)
+	}
+	for _, task := range tasks {
+		line := "- " + checkbox(task.Status == client.TaskStatusCompleted) + " " + singleLine(task.Title)
+		for _, tag := range task.Tags {
+			if !strings.ContainsAny(tag, " \t") {
+				line += " #" + tag
+			}
+		}
+		if date := formatDate(task, task.StartDate, opts.location()); date != "" && !task.StartDate.Equal(task.DueDate.Time) {
+			line += " " + startMarker + " " + date
+		}
+		if date := formatDate(task, task.DueDate, opts.location()); date != "" {
+			line += " " + dueMarker + " " + date
+		}
+		b.WriteString(line + "\n")
+
+		if content := strings.TrimSpace(task.Content); content != "" {
+			for _, text := range strings.Split(content, "\n") {
+				if strings.TrimSpace(text) == "" {
+					b.WriteString("\n")
+					continue
+				}
+				b.WriteString("  " + strings.TrimRight(text, " \t") + "\n")
+			}
+		}
+		for _, item := range task.Items {
+			fmt.Fprintf(&b, "  - %s %s\n", checkbox(item.Status == client.ItemStatusCompleted), singleLine(item.Title))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ReadMarkdown 读取 Markdown 清单
+// 缩进最少的列表项为任务，缩进更深的列表项为最近一个任务的子任务（多层嵌套展平为一层）；
+// 任务下缩进的其他文本作为正文；标题中的 📅 / 🛫 日期作为到期和开始日期；标题行和列表之外的文本被忽略
+func ReadMarkdown(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var content []string
+	blank := false
+	baseIndent := -1
+	flush := func() {
+		if len(entries) > 0 && len(content) > 0 {
+			entries[len(entries)-1].Args["content"] = strings.Join(content, "\n")
+		}
+		content, blank = nil, false
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.ReplaceAll(scanner.Text(), "\t", "    ")
+		if strings.TrimSpace(line) == "" {
+			blank = len(content) > 0
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if m := listItemPattern.FindStringSubmatch(line); m != nil {
+			title := strings.TrimSpace(m[3])
+			if title == "" {
+				continue
+			}
+			if baseIndent < 0 || indent <= baseIndent || len(entries) == 0 {
+				flush()
+				baseIndent = indent
+				entries = append(entries, newMarkdownEntry(lineNo, title, m[2] != "" && m[2] != " "))
+				continue
+			}
+			last := &entries[len(entries)-1]
+			last.Subtasks = append(last.Subtasks, Subtask{Title: stripDates(title), Completed: m[2] != "" && m[2] != " "})
+			continue
+		}
+
+		// 任务下缩进的文本作为正文（子任务之后的忽略），其余文本（如标题、说明）结束当前任务
+		if baseIndent >= 0 && indent > baseIndent {
+			if len(entries[len(entries)-1].Subtasks) > 0 {
+				continue
+			}
+			if blank {
+				content = append(content, "")
+				blank = false
+			}
+			content = append(content, strings.TrimSpace(line))
+			continue
+		}
+		flush()
+		baseIndent = -1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return entries, nil
+}
+
+// newMarkdownEntry 由列表项的文字创建条目，提取日期标记
+func newMarkdownEntry(line int, text string, completed bool) Entry {
+	args := map[string]any{}
+	for _, m := range datePattern.FindAllStringSubmatch(text, -1) {
+		switch m[1] {
+		case dueMarker:
+			args["due_date"] = strings.Replace(m[2], "T", " ", 1)
+		case startMarker:
+			args["start_date"] = strings.Replace(m[2], "T", " ", 1)
+		}
+	}
+	args["title"] = stripDates(text)
+	return Entry{Line: line, Args: args, Completed: completed}
+}
+
+// stripDates 去掉文字中的日期标记
+func stripDates(text string) string {
+	return strings.TrimSpace(datePattern.ReplaceAllString(text, ""))
+}
+
+// checkbox 返回 Markdown 复选框
+func checkbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// singleLine 将多行文字合并为一行
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,92 @@
+package taskfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMarkdown(&b, testTasks(), ExportOptions{Title: "Work", Location: testLoc}); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Work
+
+- [ ] Report, draft #work #q4 🛫 2026-11-01 16:30 📅 2026-11-01 18:30
+  line 1
+  line 2
+  - [ ] outline
+  - [x] review
+- [x] Holiday 📅 2026-11-01
+- [ ] Someday
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMarkdown(&b, testTasks(), ExportOptions{Title: "Work", Location: testLoc}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ReadMarkdown(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 标签留在标题中，由 create_task 的行内 #标签 规则识别
+	want := []Entry{
+		{Line: 3, Args: map[string]any{
+			"title": "Report, draft #work #q4", "content": "line 1\nline 2",
+			"start_date": "2026-11-01 16:30", "due_date": "2026-11-01 18:30",
+		}, Subtasks: []Subtask{{Title: "outline"}, {Title: "review", Completed: true}}},
+		{Line: 8, Args: map[string]any{"title": "Holiday", "due_date": "2026-11-01"}, Completed: true},
+		{Line: 9, Args: map[string]any{"title": "Someday"}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ReadMarkdown =\n%+v\nwant\n%+v", entries, want)
+	}
+}
+
+func TestReadMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Entry
+	}{
+		{
+			name: "bullets without checkboxes and other markers",
+			data: "* first\n+ [X] second ✅ 2026-10-01\n- [ ]   \n",
+			want: []Entry{
+				{Line: 1, Args: map[string]any{"title": "first"}},
+				{Line: 2, Args: map[string]any{"title": "second"}, Completed: true},
+			},
+		},
+		{
+			name: "content paragraphs and nested subtasks",
+			data: "- task\n\tfirst paragraph\n\n\tsecond paragraph\n\t- [ ] a\n\t\t- [x] deeper 📅 2026-11-01\n\tignored after subtasks\n",
+			want: []Entry{{Line: 1, Args: map[string]any{"title": "task", "content": "first paragraph\n\nsecond paragraph"},
+				Subtasks: []Subtask{{Title: "a"}, {Title: "deeper", Completed: true}}}},
+		},
+		{
+			name: "headings and text end the current task",
+			data: "Intro text\n\n## Today\n  - [ ] indented task 🛫 2026-11-01T09:00\n## Later\n- [ ] next\n",
+			want: []Entry{
+				{Line: 4, Args: map[string]any{"title": "indented task", "start_date": "2026-11-01 09:00"}},
+				{Line: 6, Args: map[string]any{"title": "next"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ReadMarkdown(strings.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("ReadMarkdown =\n%+v\nwant\n%+v", entries, tt.want)
+			}
+		})
+	}
+}
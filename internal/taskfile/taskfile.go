@@ -0,0 +1,103 @@
+// Package taskfile 在任务与 CSV 表格、Markdown 清单之间转换
+//
+// 导出时每个任务及其子任务（检查项）都会写出；导入时读出的条目以 create_task 参数的形式返回，
+// 由调用方按与 create_task 相同的规则解析日期、提醒、重复和标签。
+package taskfile
+
+import (
+	"dida/internal/client"
+	"strings"
+	"time"
+)
+
+// 支持的文件格式
+const (
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+// 日期的输出格式：全天任务只输出日期
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04"
+)
+
+// Entry 从文件中读取的一个待创建任务
+type Entry struct {
+	// Line 条目在文件中的行号，用于报错
+	Line int
+	// Args 与 create_task 同名的参数，如 title、due_date、tags
+	Args map[string]any
+	// Completed 任务本身已勾选
+	Completed bool
+	// Subtasks 子任务（检查项）
+	Subtasks []Subtask
+}
+
+// Title 返回条目的标题
+func (e Entry) Title() string {
+	title, _ := e.Args["title"].(string)
+	return title
+}
+
+// Subtask 待创建的子任务
+type Subtask struct {
+	Title     string
+	Completed bool
+}
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	// Title Markdown 的一级标题，通常为项目名称，为空时不输出
+	Title string
+	// Location 非全天任务的日期所用的时区
+	Location *time.Location
+	// Columns CSV 输出的列及其顺序，为空时使用 DefaultColumns
+	Columns []string
+}
+
+func (o ExportOptions) location() *time.Location {
+	if o.Location == nil {
+		return time.Local
+	}
+	return o.Location
+}
+
+// FormatFromPath 根据文件扩展名推断格式，无法推断时返回空字符串
+func FormatFromPath(path string) string {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".csv"), strings.HasSuffix(lower, ".tsv"):
+		return FormatCSV
+	case strings.HasSuffix(lower, ".md"), strings.HasSuffix(lower, ".markdown"), strings.HasSuffix(lower, ".txt"):
+		return FormatMarkdown
+	}
+	return ""
+}
+
+// formatDate 输出任务日期：全天任务在任务时区中取日期，其余任务在 loc 中输出日期和时刻
+func formatDate(task client.Task, t client.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	if task.IsAllDay {
+		return t.InZone(task, loc).Format(dateLayout)
+	}
+	return t.InZone(task, loc).Format(dateTimeLayout)
+}
+
+// priorityNames 优先级名称
+var priorityNames = map[int]string{0: "none", 1: "low", 3: "medium", 5: "high"}
+
+// priorityValues 导入时接受的优先级写法
+var priorityValues = map[string]int{
+	"": 0, "0": 0, "none": 0,
+	"1": 1, "low": 1,
+	"3": 3, "medium": 3, "med": 3,
+	"5": 5, "high": 5,
+}
+
+// completedValues 导入时视为已完成的状态写法
+var completedValues = map[string]bool{
+	"completed": true, "complete": true, "done": true, "x": true, "yes": true, "true": true, "2": true,
+}
@@ -0,0 +1,216 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// variablePattern 匹配 {{name}} 或 {{ name }}
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// relativeDatePattern 匹配相对日期，如 +3d、-1w、+2h、+1mo 17:00
+var relativeDatePattern = regexp.MustCompile(`^([+-]\d+)\s*(h|d|w|mo|y)(?:\s+(?:at\s+)?(\d{1,2}):(\d{2}))?$`)
+
+// priorityNames 优先级名称与 TickTick 数值的对应关系
+var priorityNames = map[string]string{
+	"": "", "none": "0", "low": "1", "medium": "3", "med": "3", "high": "5",
+	"0": "0", "1": "1", "3": "3", "5": "5",
+}
+
+// TaskSpec 实例化后的任务，日期已解析为绝对时间，字段与 create_task 参数一一对应
+type TaskSpec struct {
+	ProjectID string
+	Title     string
+	Content   string
+	StartDate string
+	DueDate   string
+	Priority  string
+	Reminders []string
+	Repeat    string
+	Tags      []string
+	Subtasks  []Subtask
+}
+
+// Args 返回与 create_task 工具参数同名的参数表，未设置的字段不包含在内
+func (s TaskSpec) Args() map[string]any {
+	args := map[string]any{"title": s.Title}
+	optional := map[string]string{
+		"project_id": s.ProjectID,
+		"content":    s.Content,
+		"start_date": s.StartDate,
+		"due_date":   s.DueDate,
+		"priority":   s.Priority,
+		"repeat":     s.Repeat,
+	}
+	for key, value := range optional {
+		if value != "" {
+			args[key] = value
+		}
+	}
+	if len(s.Reminders) > 0 {
+		reminders := make([]any, len(s.Reminders))
+		for i, r := range s.Reminders {
+			reminders[i] = r
+		}
+		args["reminders"] = reminders
+	}
+	if len(s.Tags) > 0 {
+		tags := make([]any, len(s.Tags))
+		for i, t := range s.Tags {
+			tags[i] = t
+		}
+		args["tags"] = tags
+	}
+	return args
+}
+
+// Render 用变量实例化模板，相对日期以 base 为基准
+// 内置变量 date 为 base 的日期（2006-01-02），可被同名变量覆盖
+func (t *Template) Render(vars map[string]string, base time.Time) ([]TaskSpec, error) {
+	values, err := t.resolveVariables(vars, base)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []TaskSpec
+	for i, task := range t.AllTasks() {
+		r := &renderer{values: values}
+		spec := TaskSpec{
+			ProjectID: r.expand(task.ProjectID),
+			Title:     r.expand(task.Title),
+			Content:   r.expand(task.Content),
+			Repeat:    r.expand(task.Repeat),
+		}
+		for _, reminder := range task.Reminders {
+			spec.Reminders = append(spec.Reminders, r.expand(reminder))
+		}
+		for _, tag := range task.Tags {
+			spec.Tags = append(spec.Tags, r.expand(tag))
+		}
+		for _, subtask := range task.Subtasks {
+			spec.Subtasks = append(spec.Subtasks, Subtask{Title: r.expand(subtask.Title), Completed: subtask.Completed})
+		}
+
+		priority, ok := priorityNames[strings.ToLower(strings.TrimSpace(r.expand(string(task.Priority))))]
+		if !ok {
+			return nil, fmt.Errorf("task %d: invalid priority %q, use none, low, medium or high", i+1, task.Priority)
+		}
+		spec.Priority = priority
+
+		if spec.StartDate, err = resolveDate(r.expand(task.Start), base); err != nil {
+			return nil, fmt.Errorf("task %d: invalid start: %v", i+1, err)
+		}
+		if spec.DueDate, err = resolveDate(r.expand(task.Due), base); err != nil {
+			return nil, fmt.Errorf("task %d: invalid due: %v", i+1, err)
+		}
+		if len(r.missing) > 0 {
+			return nil, fmt.Errorf("task %d: undefined variable(s): %s", i+1, strings.Join(r.missing, ", "))
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// resolveVariables 合并默认值和传入的变量，检查必填变量和未声明的变量
+func (t *Template) resolveVariables(vars map[string]string, base time.Time) (map[string]string, error) {
+	values := map[string]string{"date": base.Format("2006-01-02")}
+	declared := map[string]bool{"date": true}
+	var missing []string
+	for _, v := range t.Variables {
+		declared[v.Name] = true
+		if value, ok := vars[v.Name]; ok {
+			values[v.Name] = value
+			continue
+		}
+		if v.Required() {
+			missing = append(missing, v.Name)
+			continue
+		}
+		values[v.Name] = *v.Default
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required variable(s): %s", strings.Join(missing, ", "))
+	}
+
+	var unknown []string
+	for name, value := range vars {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+		values[name] = value
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown variable(s): %s", strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+// renderer 替换变量并记录未定义的变量
+type renderer struct {
+	values  map[string]string
+	missing []string
+}
+
+func (r *renderer) expand(s string) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		value, ok := r.values[name]
+		if !ok {
+			for _, m := range r.missing {
+				if m == name {
+					return match
+				}
+			}
+			r.missing = append(r.missing, name)
+			return match
+		}
+		return value
+	})
+}
+
+// resolveDate 将相对日期转换为绝对时间，其他写法原样返回，由 create_task 的日期解析处理
+// 以天、周、月、年为单位且没有时刻时结果为日期（全天任务）
+func resolveDate(value string, base time.Time) (string, error) {
+	value = strings.TrimSpace(value)
+	m := relativeDatePattern.FindStringSubmatch(value)
+	if m == nil {
+		return value, nil
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return "", err
+	}
+
+	if m[2] == "h" {
+		if m[3] != "" {
+			return "", fmt.Errorf("%q: a time of day cannot be combined with hours", value)
+		}
+		return base.Add(time.Duration(n) * time.Hour).Format("2006-01-02T15:04:05-0700"), nil
+	}
+
+	day := base
+	switch m[2] {
+	case "d":
+		day = day.AddDate(0, 0, n)
+	case "w":
+		day = day.AddDate(0, 0, 7*n)
+	case "mo":
+		day = day.AddDate(0, n, 0)
+	case "y":
+		day = day.AddDate(n, 0, 0)
+	}
+	if m[3] == "" {
+		return day.Format("2006-01-02"), nil
+	}
+	hour, _ := strconv.Atoi(m[3])
+	minute, _ := strconv.Atoi(m[4])
+	if hour > 23 || minute > 59 {
+		return "", fmt.Errorf("%q: invalid time of day", value)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, base.Location()).Format("2006-01-02T15:04:05-0700"), nil
+}
@@ -0,0 +1,205 @@
+// Package templates 实现任务模板：在本地目录中以 YAML 或 JSON 描述一组任务，
+// 支持 {{变量}}、相对日期（如 "+3d"、"+1w 17:00"）、优先级、提醒、重复规则和子任务
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Template 任务模板
+type Template struct {
+	// Name 模板名称，未填写时使用文件名（不含扩展名）
+	Name        string     `yaml:"name" json:"name"`
+	Description string     `yaml:"description" json:"description"`
+	Variables   []Variable `yaml:"variables" json:"variables"`
+	// Task 只包含一个任务时可以使用 task 代替 tasks
+	Task  *TaskTemplate  `yaml:"task" json:"task"`
+	Tasks []TaskTemplate `yaml:"tasks" json:"tasks"`
+
+	// Path 模板文件路径
+	Path string `yaml:"-" json:"-"`
+}
+
+// Variable 模板变量，没有默认值的变量在实例化时必须提供
+type Variable struct {
+	Name        string  `yaml:"name" json:"name"`
+	Description string  `yaml:"description" json:"description"`
+	Default     *string `yaml:"default" json:"default"`
+}
+
+// Required 判断变量是否必须提供
+func (v Variable) Required() bool {
+	return v.Default == nil
+}
+
+// TaskTemplate 模板中的一个任务，所有字符串字段都可以使用 {{变量}}
+type TaskTemplate struct {
+	ProjectID string `yaml:"project_id" json:"project_id"`
+	Title     string `yaml:"title" json:"title"`
+	Content   string `yaml:"content" json:"content"`
+	// Start、Due 接受相对日期（+3d、+1w 17:00、-2h）、标准格式或自然语言
+	Start     string     `yaml:"start" json:"start"`
+	Due       string     `yaml:"due" json:"due"`
+	Priority  flexString `yaml:"priority" json:"priority"`
+	Reminders []string   `yaml:"reminders" json:"reminders"`
+	Repeat    string     `yaml:"repeat" json:"repeat"`
+	Tags      []string   `yaml:"tags" json:"tags"`
+	Subtasks  []Subtask  `yaml:"subtasks" json:"subtasks"`
+}
+
+// Subtask 模板中的子任务，可以直接写成字符串
+type Subtask struct {
+	Title     string `yaml:"title" json:"title"`
+	Completed bool   `yaml:"completed" json:"completed"`
+}
+
+// UnmarshalYAML 允许子任务写成字符串
+func (s *Subtask) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Title = node.Value
+		return nil
+	}
+	type plain Subtask
+	return node.Decode((*plain)(s))
+}
+
+// UnmarshalJSON 允许子任务写成字符串
+func (s *Subtask) UnmarshalJSON(data []byte) error {
+	var title string
+	if err := json.Unmarshal(data, &title); err == nil {
+		s.Title = title
+		return nil
+	}
+	type plain Subtask
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// flexString 既可以写成字符串也可以写成数字的字段（如 priority: 5 或 priority: high）
+type flexString string
+
+// UnmarshalJSON 接受字符串或数字
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = flexString(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("expected a string or number, got %s", data)
+	}
+	*f = flexString(n.String())
+	return nil
+}
+
+// AllTasks 返回模板中的全部任务
+func (t *Template) AllTasks() []TaskTemplate {
+	if t.Task == nil {
+		return t.Tasks
+	}
+	return append([]TaskTemplate{*t.Task}, t.Tasks...)
+}
+
+// extensions 支持的模板文件扩展名
+var extensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// Load 加载目录中的全部模板，按名称排序
+// 单个文件解析失败不影响其他模板，错误通过 errs 返回
+func Load(dir string) (templates []*Template, errs []error, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read template directory %s: %v", dir, err)
+	}
+
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !extensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		t, err := LoadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if other, ok := seen[t.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s: template name %q already used by %s", entry.Name(), t.Name, other))
+			continue
+		}
+		seen[t.Name] = entry.Name()
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, errs, nil
+}
+
+// LoadFile 加载单个模板文件
+func LoadFile(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var t Template
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &t)
+	} else {
+		err = yaml.Unmarshal(data, &t)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Base(path), err)
+	}
+
+	t.Path = path
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Base(path), err)
+	}
+	return &t, nil
+}
+
+// Find 按名称（不区分大小写）查找模板
+func Find(dir, name string) (*Template, error) {
+	templates, _, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("template %q not found in %s", name, dir)
+}
+
+// Validate 检查模板结构
+func (t *Template) Validate() error {
+	tasks := t.AllTasks()
+	if len(tasks) == 0 {
+		return fmt.Errorf("template has no tasks")
+	}
+	for i, task := range tasks {
+		if strings.TrimSpace(task.Title) == "" {
+			return fmt.Errorf("task %d has no title", i+1)
+		}
+	}
+	names := make(map[string]bool)
+	for _, v := range t.Variables {
+		if !variablePattern.MatchString("{{" + v.Name + "}}") {
+			return fmt.Errorf("invalid variable name %q", v.Name)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("variable %q declared twice", v.Name)
+		}
+		names[v.Name] = true
+	}
+	return nil
+}